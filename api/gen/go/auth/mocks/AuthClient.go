@@ -53,6 +53,228 @@ func (_m *AuthClient) ChangeLinksRemaining(ctx context.Context, in *authv1.Chang
 	return r0, r1
 }
 
+// ChangePassword provides a mock function with given fields: ctx, in, opts
+func (_m *AuthClient) ChangePassword(ctx context.Context, in *authv1.ChangePasswordRequest, opts ...grpc.CallOption) (*authv1.ChangePasswordResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 *authv1.ChangePasswordResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ChangePasswordRequest, ...grpc.CallOption) (*authv1.ChangePasswordResponse, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ChangePasswordRequest, ...grpc.CallOption) *authv1.ChangePasswordResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.ChangePasswordResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.ChangePasswordRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangePlan provides a mock function with given fields: ctx, in, opts
+func (_m *AuthClient) ChangePlan(ctx context.Context, in *authv1.ChangePlanRequest, opts ...grpc.CallOption) (*authv1.ChangePlanResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePlan")
+	}
+
+	var r0 *authv1.ChangePlanResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ChangePlanRequest, ...grpc.CallOption) (*authv1.ChangePlanResponse, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ChangePlanRequest, ...grpc.CallOption) *authv1.ChangePlanResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.ChangePlanResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.ChangePlanRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConsumeLinkQuota provides a mock function with given fields: ctx, in, opts
+func (_m *AuthClient) ConsumeLinkQuota(ctx context.Context, in *authv1.ConsumeLinkQuotaRequest, opts ...grpc.CallOption) (*authv1.ConsumeLinkQuotaResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeLinkQuota")
+	}
+
+	var r0 *authv1.ConsumeLinkQuotaResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ConsumeLinkQuotaRequest, ...grpc.CallOption) (*authv1.ConsumeLinkQuotaResponse, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ConsumeLinkQuotaRequest, ...grpc.CallOption) *authv1.ConsumeLinkQuotaResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.ConsumeLinkQuotaResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.ConsumeLinkQuotaRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreatePlan provides a mock function with given fields: ctx, in, opts
+func (_m *AuthClient) CreatePlan(ctx context.Context, in *authv1.CreatePlanRequest, opts ...grpc.CallOption) (*authv1.CreatePlanResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePlan")
+	}
+
+	var r0 *authv1.CreatePlanResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.CreatePlanRequest, ...grpc.CallOption) (*authv1.CreatePlanResponse, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.CreatePlanRequest, ...grpc.CallOption) *authv1.CreatePlanResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.CreatePlanResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.CreatePlanRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPlanLimits provides a mock function with given fields: ctx, in, opts
+func (_m *AuthClient) GetPlanLimits(ctx context.Context, in *authv1.GetPlanLimitsRequest, opts ...grpc.CallOption) (*authv1.GetPlanLimitsResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPlanLimits")
+	}
+
+	var r0 *authv1.GetPlanLimitsResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.GetPlanLimitsRequest, ...grpc.CallOption) (*authv1.GetPlanLimitsResponse, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.GetPlanLimitsRequest, ...grpc.CallOption) *authv1.GetPlanLimitsResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.GetPlanLimitsResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.GetPlanLimitsRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPlans provides a mock function with given fields: ctx, in, opts
+func (_m *AuthClient) ListPlans(ctx context.Context, in *authv1.ListPlansRequest, opts ...grpc.CallOption) (*authv1.ListPlansResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListPlans")
+	}
+
+	var r0 *authv1.ListPlansResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ListPlansRequest, ...grpc.CallOption) (*authv1.ListPlansResponse, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ListPlansRequest, ...grpc.CallOption) *authv1.ListPlansResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.ListPlansResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.ListPlansRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Login provides a mock function with given fields: ctx, in, opts
 func (_m *AuthClient) Login(ctx context.Context, in *authv1.LoginRequest, opts ...grpc.CallOption) (*authv1.LoginResponse, error) {
 	_va := make([]interface{}, len(opts))
@@ -90,6 +312,117 @@ func (_m *AuthClient) Login(ctx context.Context, in *authv1.LoginRequest, opts .
 	return r0, r1
 }
 
+// Logout provides a mock function with given fields: ctx, in, opts
+func (_m *AuthClient) Logout(ctx context.Context, in *authv1.LogoutRequest, opts ...grpc.CallOption) (*authv1.LogoutResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Logout")
+	}
+
+	var r0 *authv1.LogoutResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.LogoutRequest, ...grpc.CallOption) (*authv1.LogoutResponse, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.LogoutRequest, ...grpc.CallOption) *authv1.LogoutResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.LogoutResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.LogoutRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Refresh provides a mock function with given fields: ctx, in, opts
+func (_m *AuthClient) Refresh(ctx context.Context, in *authv1.RefreshRequest, opts ...grpc.CallOption) (*authv1.RefreshResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Refresh")
+	}
+
+	var r0 *authv1.RefreshResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.RefreshRequest, ...grpc.CallOption) (*authv1.RefreshResponse, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.RefreshRequest, ...grpc.CallOption) *authv1.RefreshResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.RefreshResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.RefreshRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefundLinkQuota provides a mock function with given fields: ctx, in, opts
+func (_m *AuthClient) RefundLinkQuota(ctx context.Context, in *authv1.RefundLinkQuotaRequest, opts ...grpc.CallOption) (*authv1.RefundLinkQuotaResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefundLinkQuota")
+	}
+
+	var r0 *authv1.RefundLinkQuotaResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.RefundLinkQuotaRequest, ...grpc.CallOption) (*authv1.RefundLinkQuotaResponse, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.RefundLinkQuotaRequest, ...grpc.CallOption) *authv1.RefundLinkQuotaResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.RefundLinkQuotaResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.RefundLinkQuotaRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Register provides a mock function with given fields: ctx, in, opts
 func (_m *AuthClient) Register(ctx context.Context, in *authv1.RegisterRequest, opts ...grpc.CallOption) (*authv1.RegisterResponse, error) {
 	_va := make([]interface{}, len(opts))
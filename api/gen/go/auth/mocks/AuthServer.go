@@ -44,6 +44,186 @@ func (_m *AuthServer) ChangeLinksRemaining(_a0 context.Context, _a1 *authv1.Chan
 	return r0, r1
 }
 
+// ChangePassword provides a mock function with given fields: _a0, _a1
+func (_m *AuthServer) ChangePassword(_a0 context.Context, _a1 *authv1.ChangePasswordRequest) (*authv1.ChangePasswordResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 *authv1.ChangePasswordResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ChangePasswordRequest) (*authv1.ChangePasswordResponse, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ChangePasswordRequest) *authv1.ChangePasswordResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.ChangePasswordResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.ChangePasswordRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangePlan provides a mock function with given fields: _a0, _a1
+func (_m *AuthServer) ChangePlan(_a0 context.Context, _a1 *authv1.ChangePlanRequest) (*authv1.ChangePlanResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePlan")
+	}
+
+	var r0 *authv1.ChangePlanResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ChangePlanRequest) (*authv1.ChangePlanResponse, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ChangePlanRequest) *authv1.ChangePlanResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.ChangePlanResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.ChangePlanRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConsumeLinkQuota provides a mock function with given fields: _a0, _a1
+func (_m *AuthServer) ConsumeLinkQuota(_a0 context.Context, _a1 *authv1.ConsumeLinkQuotaRequest) (*authv1.ConsumeLinkQuotaResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeLinkQuota")
+	}
+
+	var r0 *authv1.ConsumeLinkQuotaResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ConsumeLinkQuotaRequest) (*authv1.ConsumeLinkQuotaResponse, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ConsumeLinkQuotaRequest) *authv1.ConsumeLinkQuotaResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.ConsumeLinkQuotaResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.ConsumeLinkQuotaRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreatePlan provides a mock function with given fields: _a0, _a1
+func (_m *AuthServer) CreatePlan(_a0 context.Context, _a1 *authv1.CreatePlanRequest) (*authv1.CreatePlanResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePlan")
+	}
+
+	var r0 *authv1.CreatePlanResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.CreatePlanRequest) (*authv1.CreatePlanResponse, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.CreatePlanRequest) *authv1.CreatePlanResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.CreatePlanResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.CreatePlanRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPlanLimits provides a mock function with given fields: _a0, _a1
+func (_m *AuthServer) GetPlanLimits(_a0 context.Context, _a1 *authv1.GetPlanLimitsRequest) (*authv1.GetPlanLimitsResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPlanLimits")
+	}
+
+	var r0 *authv1.GetPlanLimitsResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.GetPlanLimitsRequest) (*authv1.GetPlanLimitsResponse, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.GetPlanLimitsRequest) *authv1.GetPlanLimitsResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.GetPlanLimitsResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.GetPlanLimitsRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPlans provides a mock function with given fields: _a0, _a1
+func (_m *AuthServer) ListPlans(_a0 context.Context, _a1 *authv1.ListPlansRequest) (*authv1.ListPlansResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListPlans")
+	}
+
+	var r0 *authv1.ListPlansResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ListPlansRequest) (*authv1.ListPlansResponse, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.ListPlansRequest) *authv1.ListPlansResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.ListPlansResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.ListPlansRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Login provides a mock function with given fields: _a0, _a1
 func (_m *AuthServer) Login(_a0 context.Context, _a1 *authv1.LoginRequest) (*authv1.LoginResponse, error) {
 	ret := _m.Called(_a0, _a1)
@@ -74,6 +254,96 @@ func (_m *AuthServer) Login(_a0 context.Context, _a1 *authv1.LoginRequest) (*aut
 	return r0, r1
 }
 
+// Logout provides a mock function with given fields: _a0, _a1
+func (_m *AuthServer) Logout(_a0 context.Context, _a1 *authv1.LogoutRequest) (*authv1.LogoutResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Logout")
+	}
+
+	var r0 *authv1.LogoutResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.LogoutRequest) (*authv1.LogoutResponse, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.LogoutRequest) *authv1.LogoutResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.LogoutResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.LogoutRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Refresh provides a mock function with given fields: _a0, _a1
+func (_m *AuthServer) Refresh(_a0 context.Context, _a1 *authv1.RefreshRequest) (*authv1.RefreshResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Refresh")
+	}
+
+	var r0 *authv1.RefreshResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.RefreshRequest) (*authv1.RefreshResponse, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.RefreshRequest) *authv1.RefreshResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.RefreshResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.RefreshRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefundLinkQuota provides a mock function with given fields: _a0, _a1
+func (_m *AuthServer) RefundLinkQuota(_a0 context.Context, _a1 *authv1.RefundLinkQuotaRequest) (*authv1.RefundLinkQuotaResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefundLinkQuota")
+	}
+
+	var r0 *authv1.RefundLinkQuotaResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.RefundLinkQuotaRequest) (*authv1.RefundLinkQuotaResponse, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *authv1.RefundLinkQuotaRequest) *authv1.RefundLinkQuotaResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*authv1.RefundLinkQuotaResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *authv1.RefundLinkQuotaRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Register provides a mock function with given fields: _a0, _a1
 func (_m *AuthServer) Register(_a0 context.Context, _a1 *authv1.RegisterRequest) (*authv1.RegisterResponse, error) {
 	ret := _m.Called(_a0, _a1)
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"flag"
+	"fmt"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres" // Required for migrations
 	"github.com/golang-migrate/migrate/v4/source/iofs"
@@ -11,15 +12,28 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"min/internal/adapter/handler/grpc/auth"
+	handler "min/internal/adapter/handler/http"
 	"min/internal/adapter/repository/postgres"
 	"min/internal/core/service"
 	migrations "min/internal/migration"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 )
 
+// signingKeyConfig describes a token signing key in the configuration file. The key material is taken
+// from the first non-empty source: secret, the environment variable named by secret_env or private_key_file.
+type signingKeyConfig struct {
+	ID             string `mapstructure:"id"`
+	Algorithm      string `mapstructure:"algorithm"`
+	Secret         string `mapstructure:"secret"`
+	SecretEnv      string `mapstructure:"secret_env"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+}
+
 func main() {
 	// Parse command line flags
 	var configPath string
@@ -30,6 +44,8 @@ func main() {
 
 	// Initialize and load configuration from file
 	viper.SetConfigFile(configPath)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
 	if err := viper.ReadInConfig(); err != nil {
 		log.Panic("Error loading configuration:", err)
 	}
@@ -45,10 +61,26 @@ func main() {
 		log.Panic("Error applying migrations:", err)
 	}
 
+	// Load the token signing keys
+	keys, err := loadKeySet()
+	if err != nil {
+		log.Panic("Error loading signing keys:", err)
+	}
+
 	// Create and start the server
 	tokenMaxTime := viper.GetInt("token_max_time")
+	refreshTokenMaxTime := viper.GetInt("refresh_token_max_time")
 	usersRep := postgres.NewUserRepository(pgClient)
-	authService := service.NewAuthService(usersRep, time.Duration(tokenMaxTime)*time.Minute)
+	tokensRep := postgres.NewRefreshTokenRepository(pgClient)
+	plansRep := postgres.NewPlanRepository(pgClient)
+	authService := service.NewAuthService(
+		usersRep,
+		tokensRep,
+		plansRep,
+		keys,
+		time.Duration(tokenMaxTime)*time.Minute,
+		time.Duration(refreshTokenMaxTime)*time.Minute,
+	)
 	srv := auth.NewServer(authService)
 	go func() {
 		if err := srv.Start(port); err != nil {
@@ -57,6 +89,24 @@ func main() {
 	}()
 	defer srv.Stop()
 
+	// Publish the public keys, so that other services can verify tokens locally
+	if jwksAddr := viper.GetString("jwks_addr"); jwksAddr != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /.well-known/jwks.json", handler.NewJWKSHandler(keys).JWKS)
+		jwksSrv := &http.Server{
+			Addr:              jwksAddr,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			log.Printf("JWKS server is running on %s...", jwksAddr)
+			if err := jwksSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Panicf("Error starting JWKS server: %v", err)
+			}
+		}()
+		defer jwksSrv.Close()
+	}
+
 	// Set up a signal channel to handle interrupt and termination signals
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -66,6 +116,48 @@ func main() {
 	log.Printf("Received signal %v. Shutting down...", sig)
 }
 
+// loadKeySet loads the token signing keys from the configuration.
+func loadKeySet() (*service.KeySet, error) {
+	var configs []signingKeyConfig
+	if err := viper.UnmarshalKey("jwt.keys", &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse signing keys: %w", err)
+	}
+
+	keys := make([]*service.SigningKey, 0, len(configs))
+	for _, cfg := range configs {
+		material, err := keyMaterial(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := service.NewSigningKey(cfg.ID, cfg.Algorithm, material)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return service.NewKeySet(viper.GetString("jwt.active_key"), keys...)
+}
+
+// keyMaterial returns the key material of the signing key from the configured source.
+func keyMaterial(cfg signingKeyConfig) ([]byte, error) {
+	switch {
+	case cfg.Secret != "":
+		return []byte(cfg.Secret), nil
+	case cfg.SecretEnv != "":
+		return []byte(os.Getenv(cfg.SecretEnv)), nil
+	case cfg.PrivateKeyFile != "":
+		material, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key of signing key %s: %w", cfg.ID, err)
+		}
+		return material, nil
+	default:
+		return nil, fmt.Errorf("no key material configured for signing key %s", cfg.ID)
+	}
+}
+
 // applyMigrations applies all available migrations to the database.
 func applyMigrations(dbURL string) error {
 	log.Println("Trying to apply migrations...")
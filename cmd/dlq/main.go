@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"min/internal/adapter/kafka"
+	"os"
+	"os/signal"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// dlq re-drives the events of the statistics dead-letter topic back to their original topics.
+func main() {
+	var configPath string
+	var group string
+	var limit int
+	var dryRun bool
+	flag.StringVar(&configPath, "c", "config/statistics.yaml", "Path to configuration file")
+	flag.StringVar(&group, "group", "shortener-dlq-redrive", "Consumer group storing the re-drive progress")
+	flag.IntVar(&limit, "limit", 0, "Max number of messages to re-drive, 0 for all")
+	flag.BoolVar(&dryRun, "dry-run", false, "Only log the messages without re-driving them")
+	flag.Parse()
+
+	viper.SetConfigFile(configPath)
+	if err := viper.ReadInConfig(); err != nil {
+		log.Panic("Error loading configuration:", err)
+	}
+
+	topic := viper.GetString("dead_letter_topic")
+	if topic == "" {
+		log.Panic("Dead-letter topic is not configured")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	redriver, err := kafka.NewDeadLetterRedriver(viper.GetStringSlice("kafka_brokers"), topic, group)
+	if err != nil {
+		log.Panic("Error creating dead-letter redriver:", err)
+	}
+	defer func() {
+		if err := redriver.Close(); err != nil {
+			log.Errorf("Error closing dead-letter redriver: %v", err)
+		}
+	}()
+
+	redriven, err := redriver.Redrive(ctx, limit, dryRun)
+	log.Infof("Re-driven %d messages from %s", redriven, topic)
+	if err != nil {
+		log.Errorf("Error re-driving messages: %v", err)
+	}
+}
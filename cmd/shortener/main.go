@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"expvar"
 	"flag"
+	"fmt"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres" // Required for migrations
 	"github.com/golang-migrate/migrate/v4/source/iofs"
@@ -19,8 +21,11 @@ import (
 	"min/internal/adapter/repository/postgres"
 	"min/internal/adapter/repository/redis"
 	"min/internal/core/domain"
+	"min/internal/core/port"
 	"min/internal/core/service"
 	migrations "min/internal/migration"
+	"min/pkg/clientip"
+	"min/pkg/limiter"
 	"min/pkg/middleware"
 	"net"
 	"net/http"
@@ -30,6 +35,9 @@ import (
 	"time"
 )
 
+// shortCodeSequence is the Postgres sequence the numbers of the counter and hashids codes are taken from.
+const shortCodeSequence = "short_code_seq"
+
 func main() {
 	// Parse command line flags
 	var configPath string
@@ -79,52 +87,172 @@ func main() {
 	}
 
 	// Create a new instance of the ShortenerService.
-	shortenerService := service.NewShortener(pgRepo, redisRepo, viper.GetInt("shorten_length"), authClient)
+	generator, err := newShortCodeGenerator(pgClient)
+	if err != nil {
+		log.Panic("Error creating short code generator:", err)
+	}
+	shortenerService := service.NewShortener(pgRepo, redisRepo, generator, authClient)
 
 	// Kafka producer
 	kafkaBrokers := viper.GetStringSlice("kafka_brokers")
 	kafkaTopics := viper.GetString("kafka_event_topic")
-	eventProducer, err := kafka.NewEventProducer(kafkaBrokers, kafkaTopics)
+	eventSpool, err := newEventSpool()
+	if err != nil {
+		log.Panic("Error opening event spool:", err)
+	}
+	eventProducer, err := kafka.NewEventProducer(kafkaBrokers, kafkaTopics, kafka.ProducerConfig{
+		BufferSize:     viper.GetInt("event_buffer.size"),
+		Overflow:       kafka.OverflowPolicy(viper.GetString("event_buffer.overflow")),
+		Spool:          eventSpool,
+		ReplayInterval: viper.GetDuration("event_spool.replay_interval"),
+	})
 	if err != nil {
 		log.Panic("Error creating event producer:", err)
 	}
+	defer func() {
+		if err := eventProducer.Close(); err != nil {
+			log.Errorf("Error closing event producer: %v", err)
+		}
+	}()
+	if eventSpool != nil {
+		expvar.Publish("event_spool", expvar.Func(func() any { return eventSpool.Stats() }))
+	}
 
 	// Initialize and run the server
 	mux := http.NewServeMux()
-	shortenerHandler := handler.NewShortenerHandler(shortenerService, eventProducer)
+	redirectConfig, err := newRedirectConfig()
+	if err != nil {
+		log.Panic("Error loading redirect configuration:", err)
+	}
+	shortenerHandler := handler.NewShortenerHandler(shortenerService, eventProducer, redirectConfig)
 	if err != nil {
 		log.Panic("Error creating auth client:", err)
 	}
 	authHandler := handler.NewAuthHandler(authClient)
+	planHandler := handler.NewPlanHandler(authClient)
+
+	// Every request carrying a token is limited by IP in front of the authentication, so the bogus tokens
+	// can't cause unlimited token validations. Shortening, redirects and the rest of the API have separate
+	// rate limits, they are applied after the authentication to limit the users by their plans. They have
+	// separate pools of concurrent requests as well, so the redirects don't wait behind the slow shortening.
+	ipLimiter, err := newRateLimiter(redisClient, "ip", middleware.IPPolicy(middleware.Limit{
+		Rate:  viper.GetInt64("ip_rate_limit"),
+		Burst: viper.GetInt64("ip_max_tokens"),
+	}))
+	if err != nil {
+		log.Panic("Error creating rate limiter:", err)
+	}
+	planPolicy := handler.RateLimitPolicy(middleware.Limit{
+		Rate:  viper.GetInt64("rate_limit"),
+		Burst: viper.GetInt64("max_tokens"),
+	})
+	shortenLimiter, err := newRateLimiter(redisClient, "shorten", planPolicy)
+	if err != nil {
+		log.Panic("Error creating rate limiter:", err)
+	}
+	redirectLimiter, err := newRateLimiter(redisClient, "redirect", planPolicy)
+	if err != nil {
+		log.Panic("Error creating rate limiter:", err)
+	}
+	apiLimiter, err := newRateLimiter(redisClient, "api", planPolicy)
+	if err != nil {
+		log.Panic("Error creating rate limiter:", err)
+	}
+	shortenPool := newConcurrencyLimiter("shorten")
+	redirectPool := newConcurrencyLimiter("redirect")
+	apiPool := newConcurrencyLimiter("api")
+
 	mux.HandleFunc("POST /shorten", middleware.Chain(
 		shortenerHandler.Shorten,
+		ipLimiter.Limit,
 		handler.AuthenticationMiddleware(authClient, true),
+		shortenLimiter.Limit,
 		handler.AuthorizationMiddleware(domain.USER),
+		shortenPool.Limit,
 	))
 	mux.HandleFunc("DELETE /remove", middleware.Chain(
 		shortenerHandler.Remove,
+		ipLimiter.Limit,
+		handler.AuthenticationMiddleware(authClient, true),
+		apiLimiter.Limit,
+		handler.AuthorizationMiddleware(domain.USER),
+		apiPool.Limit,
+	))
+	mux.HandleFunc("GET /links", middleware.Chain(
+		shortenerHandler.List,
+		ipLimiter.Limit,
 		handler.AuthenticationMiddleware(authClient, true),
+		apiLimiter.Limit,
 		handler.AuthorizationMiddleware(domain.USER),
+		apiPool.Limit,
 	))
 	mux.HandleFunc("GET /", middleware.Chain(
 		shortenerHandler.Redirect,
+		ipLimiter.Limit,
+		handler.AuthenticationMiddleware(authClient, false),
+		redirectLimiter.Limit,
+		redirectPool.Limit,
+	))
+	mux.HandleFunc("POST /login", middleware.Chain(authHandler.Login, apiLimiter.Limit, apiPool.Limit))
+	mux.HandleFunc("POST /refresh", middleware.Chain(authHandler.Refresh, apiLimiter.Limit, apiPool.Limit))
+	mux.HandleFunc("POST /logout", middleware.Chain(
+		authHandler.Logout,
+		ipLimiter.Limit,
+		handler.AuthenticationMiddleware(authClient, true),
+		apiLimiter.Limit,
+		apiPool.Limit,
+	))
+	mux.HandleFunc("POST /password", middleware.Chain(
+		authHandler.ChangePassword,
+		ipLimiter.Limit,
+		handler.AuthenticationMiddleware(authClient, true),
+		apiLimiter.Limit,
+		apiPool.Limit,
 	))
-	mux.HandleFunc("POST /login", authHandler.Login)
 	mux.HandleFunc("POST /register", middleware.Chain(
 		authHandler.Register,
+		ipLimiter.Limit,
+		handler.AuthenticationMiddleware(authClient, true),
+		apiLimiter.Limit,
+		handler.AuthorizationMiddleware(domain.ADMIN),
+		apiPool.Limit,
+	))
+	mux.HandleFunc("POST /plans", middleware.Chain(
+		planHandler.CreatePlan,
+		ipLimiter.Limit,
+		handler.AuthenticationMiddleware(authClient, true),
+		apiLimiter.Limit,
+		handler.AuthorizationMiddleware(domain.ADMIN),
+		apiPool.Limit,
+	))
+	mux.HandleFunc("GET /plans", middleware.Chain(
+		planHandler.ListPlans,
+		ipLimiter.Limit,
 		handler.AuthenticationMiddleware(authClient, true),
+		apiLimiter.Limit,
 		handler.AuthorizationMiddleware(domain.ADMIN),
+		apiPool.Limit,
+	))
+	mux.HandleFunc("PUT /users/{username}/plan", middleware.Chain(
+		planHandler.ChangePlan,
+		ipLimiter.Limit,
+		handler.AuthenticationMiddleware(authClient, true),
+		apiLimiter.Limit,
+		handler.AuthorizationMiddleware(domain.ADMIN),
+		apiPool.Limit,
 	))
 
-	rl := middleware.NewRateLimiter(viper.GetInt64("rate_limit"), viper.GetInt64("max_tokens"))
-	cl := middleware.NewConcurrencyLimiter(viper.GetInt("concurrency_limit"))
+	ipResolver, err := clientip.NewResolver(viper.GetStringSlice("trusted_proxies"))
+	if err != nil {
+		log.Panic("Error loading trusted proxies:", err)
+	}
 
 	srv := &http.Server{
 		Addr: ":" + port,
 		BaseContext: func(_ net.Listener) context.Context {
 			return ctx
 		},
-		Handler:           middleware.Chain(mux.ServeHTTP, rl.Limit, cl.Limit),
+		Handler:           middleware.Chain(mux.ServeHTTP, ipResolver.Handle),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 	g, gCtx := errgroup.WithContext(ctx)
@@ -137,12 +265,201 @@ func main() {
 		log.Printf("Shut down signal received, shutting down server...")
 		return srv.Shutdown(context.Background())
 	})
+	g.Go(func() error {
+		sweepExpired(gCtx, shortenerService, viper.GetDuration("expired_sweep_interval"))
+		return nil
+	})
+	g.Go(func() error {
+		return serveMetrics(gCtx, viper.GetString("metrics_addr"))
+	})
 
 	if err := g.Wait(); err != nil {
 		log.Println("Exit reason:", err)
 	}
 }
 
+// serveMetrics serves the expvar variables, such as the state of the event spool, at /debug/vars until the context
+// is canceled. It listens on its own address, so the metrics are not exposed with the API.
+func serveMetrics(ctx context.Context, addr string) error {
+	if addr == "" {
+		log.Println("Metrics endpoint is disabled")
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		if err := srv.Shutdown(context.Background()); err != nil {
+			log.Errorf("Error shutting down metrics endpoint: %v", err)
+		}
+	}()
+
+	log.Printf("Metrics endpoint is running on %s...", addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// sweepExpired periodically removes expired URLs until the context is canceled.
+func sweepExpired(ctx context.Context, shortenerService *service.Shortener, interval time.Duration) {
+	if interval <= 0 {
+		log.Println("Expired URLs sweeper is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := shortenerService.RemoveExpired(ctx)
+			if err != nil {
+				log.Errorf("Error removing expired URLs: %v", err)
+				continue
+			}
+
+			if removed > 0 {
+				log.Infof("Removed %d expired URLs", removed)
+			}
+		}
+	}
+}
+
+// newEventSpool opens the spool for the events that could not be sent to Kafka.
+// Nil is returned if the spool directory is not configured.
+func newEventSpool() (*kafka.Spool, error) {
+	dir := viper.GetString("event_spool.dir")
+	if dir == "" {
+		log.Println("Event spool is disabled")
+		return nil, nil
+	}
+
+	return kafka.NewSpool(kafka.SpoolConfig{
+		Dir:          dir,
+		MaxBytes:     viper.GetInt64("event_spool.max_bytes"),
+		SegmentBytes: viper.GetInt64("event_spool.segment_bytes"),
+		Sync:         kafka.SyncPolicy(viper.GetString("event_spool.sync")),
+		SyncInterval: viper.GetDuration("event_spool.sync_interval"),
+	})
+}
+
+// newRateLimiter creates the rate limiter of the routes with the name. It limits the clients with the policy,
+// with the buckets stored in the configured backend.
+func newRateLimiter(
+	redisClient *goredis.Client,
+	name string,
+	policy middleware.RateLimitPolicy,
+) (*middleware.RateLimiter, error) {
+	cfg := middleware.RateLimiterConfig{
+		MaxClients:  viper.GetInt("rate_limiter.max_clients"),
+		IdleTimeout: viper.GetDuration("rate_limiter.idle_timeout"),
+	}
+
+	backend := viper.GetString("rate_limiter.backend")
+	switch backend {
+	case "", "memory":
+		return middleware.NewRateLimiterWithBuckets(policy, middleware.MemoryBuckets, cfg), nil
+	case "redis":
+		return middleware.NewRateLimiterWithBuckets(
+			policy,
+			func(key string, limit middleware.Limit) middleware.TokenBucket {
+				return limiter.NewRedisTokenBucket(
+					redisClient,
+					"ratelimit:"+name+":"+key,
+					limit.Rate,
+					limit.Burst,
+					cfg.IdleTimeout,
+				)
+			},
+			cfg,
+		), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limiter backend %q", backend)
+	}
+}
+
+// newConcurrencyLimiter creates the pool of concurrent requests of the routes with the name. The settings of
+// the pool in concurrency_limiter.routes override the common ones.
+func newConcurrencyLimiter(name string) *middleware.ConcurrencyLimiter {
+	setting := func(key string) string {
+		if routeKey := "concurrency_limiter.routes." + name + "." + key; viper.IsSet(routeKey) {
+			return routeKey
+		}
+
+		return "concurrency_limiter." + key
+	}
+
+	cfg := middleware.ConcurrencyLimiterConfig{
+		Limit:      viper.GetInt(setting("limit")),
+		QueueSize:  viper.GetInt(setting("queue_size")),
+		MaxWait:    viper.GetDuration(setting("max_wait")),
+		RetryAfter: viper.GetDuration(setting("retry_after")),
+	}
+	if viper.GetBool(setting("adaptive")) {
+		cfg.Adaptive = &middleware.AdaptiveConfig{
+			MinLimit: viper.GetInt(setting("min_limit")),
+			MaxLimit: viper.GetInt(setting("max_limit")),
+		}
+	}
+
+	return middleware.NewConcurrencyLimiterWithConfig(cfg)
+}
+
+// newRedirectConfig loads the default redirect settings from the configuration.
+func newRedirectConfig() (handler.RedirectConfig, error) {
+	cfg := handler.RedirectConfig{
+		DefaultCode:  viper.GetInt("redirect.default_code"),
+		ForwardQuery: viper.GetBool("redirect.forward_query"),
+	}
+	if cfg.DefaultCode == 0 {
+		cfg.DefaultCode = http.StatusFound
+	}
+
+	if !domain.IsRedirectCode(cfg.DefaultCode) {
+		return handler.RedirectConfig{}, fmt.Errorf("unsupported redirect code %d", cfg.DefaultCode)
+	}
+
+	return cfg, nil
+}
+
+// newShortCodeGenerator creates the short code generator with the strategy from the configuration.
+func newShortCodeGenerator(db *sql.DB) (port.ShortCodeGenerator, error) {
+	length := viper.GetInt("short_code.length")
+	alphabet := viper.GetString("short_code.alphabet")
+	if alphabet == "" {
+		alphabet = service.Base62Alphabet
+	}
+
+	switch strategy := viper.GetString("short_code.strategy"); strategy {
+	case "", "random":
+		return service.NewRandomCodeGenerator(length, alphabet)
+	case "counter":
+		return service.NewCounterCodeGenerator(postgres.NewSequenceRepository(db, shortCodeSequence), length, alphabet)
+	case "snowflake":
+		return service.NewSnowflakeCodeGenerator(viper.GetInt64("short_code.node_id"), alphabet, time.Now)
+	case "hashids":
+		return service.NewHashidsCodeGenerator(
+			postgres.NewSequenceRepository(db, shortCodeSequence),
+			viper.GetString("short_code.salt"),
+			length,
+			alphabet,
+		)
+	default:
+		return nil, fmt.Errorf("unknown short code strategy %q", strategy)
+	}
+}
+
 // applyMigrations applies all available migrations to the database.
 func applyMigrations(dbURL string) error {
 	log.Println("Trying to apply migrations...")
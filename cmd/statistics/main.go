@@ -9,11 +9,20 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/database/clickhouse"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"min/internal/adapter/client/auth"
+	"min/internal/adapter/enricher"
+	handler "min/internal/adapter/handler/http"
 	"min/internal/adapter/kafka"
+	"min/internal/core/domain"
 	"min/internal/migration"
+	"min/pkg/middleware"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	_ "github.com/ClickHouse/clickhouse-go"
 	log "github.com/sirupsen/logrus"
@@ -25,7 +34,9 @@ import (
 
 func main() {
 	var configPath string
+	var port string
 	flag.StringVar(&configPath, "c", "config/statistics.yaml", "Path to configuration file")
+	flag.StringVar(&port, "p", "8080", "Port to start server on")
 	flag.Parse()
 
 	viper.SetConfigFile(configPath)
@@ -50,24 +61,98 @@ func main() {
 		log.Panic("Error applying migrations:", err)
 	}
 	chRepo := ch.NewEventRepository(db)
+	if err := chRepo.SetRetention(ctx, viper.GetInt("event_retention_days")); err != nil {
+		log.Panic("Error setting events retention:", err)
+	}
+
+	// Enrichment of the events with the parsed user agent and the location
+	eventEnricher, err := enricher.NewEnricher(viper.GetString("geoip_path"))
+	if err != nil {
+		log.Panic("Error creating event enricher:", err)
+	}
+	defer func() {
+		if err := eventEnricher.Close(); err != nil {
+			log.Errorf("Error closing event enricher: %v", err)
+		}
+	}()
+
+	// Auth client used to authenticate statistics requests and to look up the plans of the owners
+	authClient, err := auth.NewClient(viper.GetString("auth_server_url"))
+	if err != nil {
+		log.Panic("Error creating auth client:", err)
+	}
 
 	// Statistics service
-	statsService := service.NewStatisticsService(chRepo)
+	statsService := service.NewStatisticsService(chRepo, eventEnricher, authClient)
 
 	// Kafka consumer
 	kafkaBrokers := viper.GetStringSlice("kafka_brokers")
 	consumerGroupID := viper.GetString("kafka_consumer_group_id")
 	kafkaTopics := viper.GetStringSlice("kafka_topics")
-	kafkaConsumer, err := kafka.NewKafkaConsumer(kafkaBrokers, consumerGroupID, statsService)
+	kafkaConsumer, err := kafka.NewKafkaConsumer(kafkaBrokers, consumerGroupID, statsService, kafka.ConsumerConfig{
+		BatchSize:       viper.GetInt("event_batch.size"),
+		FlushInterval:   viper.GetDuration("event_batch.flush_interval"),
+		MaxAttempts:     viper.GetInt("event_retry.max_attempts"),
+		InitialBackoff:  viper.GetDuration("event_retry.initial_backoff"),
+		MaxBackoff:      viper.GetDuration("event_retry.max_backoff"),
+		DeadLetterTopic: viper.GetString("dead_letter_topic"),
+	})
 	if err != nil {
 		log.Panic("Error creating Kafka consumer:", err)
 	}
+	defer func() {
+		if err := kafkaConsumer.Close(); err != nil {
+			log.Errorf("Error closing Kafka consumer: %v", err)
+		}
+	}()
+
+	// Statistics HTTP API
+	mux := http.NewServeMux()
+	statsHandler := handler.NewStatisticsHandler(statsService)
+	mux.HandleFunc("GET /stats/{short}", middleware.Chain(
+		statsHandler.Summary,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.USER),
+	))
+	mux.HandleFunc("GET /stats/{short}/timeseries", middleware.Chain(
+		statsHandler.TimeSeries,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.USER),
+	))
+	mux.HandleFunc("GET /stats/{short}/referrers", middleware.Chain(
+		statsHandler.TopReferrers,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.USER),
+	))
+	mux.HandleFunc("GET /stats/{short}/user-agents", middleware.Chain(
+		statsHandler.TopUserAgents,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.USER),
+	))
+
+	srv := &http.Server{
+		Addr: ":" + port,
+		BaseContext: func(_ net.Listener) context.Context {
+			return ctx
+		},
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
 
 	g, gCtx := errgroup.WithContext(ctx)
 	g.Go(func() error {
 		log.Println("Starting Kafka consumer...")
 		return kafkaConsumer.Start(gCtx, kafkaTopics)
 	})
+	g.Go(func() error {
+		log.Printf("Server is running on port %s...", port)
+		return srv.ListenAndServe()
+	})
+	g.Go(func() error {
+		<-gCtx.Done()
+		log.Printf("Shut down signal received, shutting down server...")
+		return srv.Shutdown(context.Background())
+	})
 
 	if err := g.Wait(); err != nil {
 		log.Println("Exit reason:", err)
@@ -83,7 +168,16 @@ func applyMigrations(dbURL string) error {
 		return err
 	}
 
-	m, err := migrate.NewWithSourceInstance("iofs", d, dbURL)
+	// Migrations that rebuild a table consist of several statements.
+	migrationURL, err := url.Parse(dbURL)
+	if err != nil {
+		return err
+	}
+	query := migrationURL.Query()
+	query.Set("x-multi-statement", "true")
+	migrationURL.RawQuery = query.Encode()
+
+	m, err := migrate.NewWithSourceInstance("iofs", d, migrationURL.String())
 	if err != nil {
 		return err
 	}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	authv1 "min/api/gen/go/auth"
 	"min/internal/core/domain"
+	"time"
 )
 
 // Client represents a gRPC client for auth operations.
@@ -42,7 +45,7 @@ func (c *Client) Close() {
 }
 
 // Login logs in the user with the specified username and password.
-func (c *Client) Login(ctx context.Context, username, password string) (string, error) {
+func (c *Client) Login(ctx context.Context, username, password string) (*domain.Tokens, error) {
 	resp, err := c.Client.Login(
 		ctx,
 		&authv1.LoginRequest{
@@ -52,10 +55,10 @@ func (c *Client) Login(ctx context.Context, username, password string) (string,
 	)
 
 	if err != nil {
-		return "", fmt.Errorf("failed to login: %w", err)
+		return nil, fmt.Errorf("failed to login: %w", err)
 	}
 
-	return resp.GetToken(), nil
+	return &domain.Tokens{Access: resp.GetToken(), Refresh: resp.GetRefreshToken()}, nil
 }
 
 // Register registers a new user with the specified username, password, and role.
@@ -81,7 +84,7 @@ func (c *Client) Register(ctx context.Context, username, password string, role d
 }
 
 // ValidateToken validates the specified token.
-func (c *Client) ValidateToken(ctx context.Context, token string) (*domain.User, error) {
+func (c *Client) ValidateToken(ctx context.Context, token string) (*domain.Principal, error) {
 	resp, err := c.Client.ValidateToken(
 		ctx,
 		&authv1.ValidateTokenRequest{
@@ -92,15 +95,13 @@ func (c *Client) ValidateToken(ctx context.Context, token string) (*domain.User,
 		return nil, fmt.Errorf("failed to validate token: %w", err)
 	}
 
-	user := domain.NewUser(
-		resp.GetUsername(),
-		resp.GetPassword(),
-		domain.Role(resp.GetRole()),
-		domain.Plan(resp.GetPlan()),
-		resp.GetLinksRemaining(),
-	)
-
-	return user, nil
+	return &domain.Principal{
+		Username:       resp.GetUsername(),
+		Role:           domain.Role(resp.GetRole()),
+		Plan:           domain.PlanName(resp.GetPlan()),
+		LinksRemaining: resp.GetLinksRemaining(),
+		Limits:         planLimitsFromProto(resp.GetLimits()),
+	}, nil
 }
 
 func (c *Client) ChangeLinksRemaining(ctx context.Context, username string, linksRemaining int64) error {
@@ -118,3 +119,193 @@ func (c *Client) ChangeLinksRemaining(ctx context.Context, username string, link
 
 	return nil
 }
+
+// ConsumeLinkQuota uses up one link of the specified user.
+// It returns domain.ErrLinkQuotaExceeded if the user has no links remaining.
+func (c *Client) ConsumeLinkQuota(ctx context.Context, username string) error {
+	_, err := c.Client.ConsumeLinkQuota(
+		ctx,
+		&authv1.ConsumeLinkQuotaRequest{
+			Username: username,
+		},
+	)
+	if err != nil {
+		if status.Code(err) == codes.ResourceExhausted {
+			return domain.ErrLinkQuotaExceeded
+		}
+		return fmt.Errorf("failed to consume link quota: %w", err)
+	}
+
+	return nil
+}
+
+// RefundLinkQuota gives one link back to the specified user.
+func (c *Client) RefundLinkQuota(ctx context.Context, username string) error {
+	_, err := c.Client.RefundLinkQuota(
+		ctx,
+		&authv1.RefundLinkQuotaRequest{
+			Username: username,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to refund link quota: %w", err)
+	}
+
+	return nil
+}
+
+// Refresh exchanges the specified refresh token for a new pair of tokens.
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*domain.Tokens, error) {
+	resp, err := c.Client.Refresh(
+		ctx,
+		&authv1.RefreshRequest{
+			RefreshToken: refreshToken,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	return &domain.Tokens{Access: resp.GetToken(), Refresh: resp.GetRefreshToken()}, nil
+}
+
+// Logout revokes all the tokens of the user the specified token belongs to.
+func (c *Client) Logout(ctx context.Context, token string) error {
+	_, err := c.Client.Logout(
+		ctx,
+		&authv1.LogoutRequest{
+			Token: token,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to logout: %w", err)
+	}
+
+	return nil
+}
+
+// ChangePassword changes the password of the user the specified token belongs to and revokes all their tokens.
+// It returns domain.ErrInvalidPassword if the old password doesn't match.
+func (c *Client) ChangePassword(ctx context.Context, token, oldPassword, newPassword string) error {
+	_, err := c.Client.ChangePassword(
+		ctx,
+		&authv1.ChangePasswordRequest{
+			Token:       token,
+			OldPassword: oldPassword,
+			NewPassword: newPassword,
+		},
+	)
+	if err != nil {
+		if status.Code(err) == codes.PermissionDenied {
+			return domain.ErrInvalidPassword
+		}
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+
+	return nil
+}
+
+// CreatePlan creates a new billing plan.
+// It returns domain.ErrInvalidPlan if the plan is malformed and domain.ErrPlanExists if its name is taken.
+func (c *Client) CreatePlan(ctx context.Context, plan *domain.Plan) error {
+	_, err := c.Client.CreatePlan(
+		ctx,
+		&authv1.CreatePlanRequest{
+			Plan: &authv1.Plan{
+				Name: string(plan.Name),
+				Limits: &authv1.PlanLimits{
+					LinkQuota:          plan.Limits.LinkQuota,
+					MaxLinkLifetime:    int64(plan.Limits.MaxLinkLifetime / time.Second),
+					CustomAliases:      plan.Limits.CustomAliases,
+					RateLimit:          plan.Limits.RateLimit,
+					RateBurst:          plan.Limits.RateBurst,
+					AnalyticsRetention: int64(plan.Limits.AnalyticsRetention / time.Second),
+				},
+				IsDefault: plan.Default,
+			},
+		},
+	)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			return fmt.Errorf("%w: %s", domain.ErrInvalidPlan, status.Convert(err).Message())
+		case codes.AlreadyExists:
+			return domain.ErrPlanExists
+		default:
+			return fmt.Errorf("failed to create plan: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListPlans returns all the billing plans.
+func (c *Client) ListPlans(ctx context.Context) ([]*domain.Plan, error) {
+	resp, err := c.Client.ListPlans(ctx, &authv1.ListPlansRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plans: %w", err)
+	}
+
+	plans := make([]*domain.Plan, 0, len(resp.GetPlans()))
+	for _, plan := range resp.GetPlans() {
+		plans = append(plans, &domain.Plan{
+			Name:    domain.PlanName(plan.GetName()),
+			Limits:  planLimitsFromProto(plan.GetLimits()),
+			Default: plan.GetIsDefault(),
+		})
+	}
+
+	return plans, nil
+}
+
+// ChangePlan upgrades or downgrades the specified user to the plan.
+// It returns domain.ErrUserNotFound or domain.ErrPlanNotFound if the user or the plan does not exist.
+func (c *Client) ChangePlan(ctx context.Context, username string, plan domain.PlanName) error {
+	_, err := c.Client.ChangePlan(
+		ctx,
+		&authv1.ChangePlanRequest{
+			Username: username,
+			Plan:     string(plan),
+		},
+	)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.NotFound:
+			return domain.ErrUserNotFound
+		case codes.FailedPrecondition:
+			return domain.ErrPlanNotFound
+		default:
+			return fmt.Errorf("failed to change plan: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetPlanLimits returns the limits of the plan of the specified user.
+// It returns domain.ErrUserNotFound if the user does not exist.
+func (c *Client) GetPlanLimits(ctx context.Context, username string) (*domain.PlanLimits, error) {
+	resp, err := c.Client.GetPlanLimits(ctx, &authv1.GetPlanLimitsRequest{Username: username})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, domain.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get plan limits: %w", err)
+	}
+
+	limits := planLimitsFromProto(resp.GetLimits())
+	return &limits, nil
+}
+
+// planLimitsFromProto converts the plan limits from the gRPC representation.
+func planLimitsFromProto(limits *authv1.PlanLimits) domain.PlanLimits {
+	return domain.PlanLimits{
+		LinkQuota:          limits.GetLinkQuota(),
+		MaxLinkLifetime:    time.Duration(limits.GetMaxLinkLifetime()) * time.Second,
+		CustomAliases:      limits.GetCustomAliases(),
+		RateLimit:          limits.GetRateLimit(),
+		RateBurst:          limits.GetRateBurst(),
+		AnalyticsRetention: time.Duration(limits.GetAnalyticsRetention()) * time.Second,
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	authv1 "min/api/gen/go/auth"
 	"min/api/gen/go/auth/mocks"
 	"min/internal/adapter/client/auth"
@@ -26,11 +29,11 @@ func TestClient_Login(t *testing.T) {
 		mockAuthClient.On("Login", mock.Anything, &authv1.LoginRequest{
 			Username: "testuser",
 			Password: "testpassword",
-		}).Return(&authv1.LoginResponse{Token: "testtoken"}, nil).Once()
+		}).Return(&authv1.LoginResponse{Token: "testtoken", RefreshToken: "testrefresh"}, nil).Once()
 
-		token, err := client.Login(context.Background(), "testuser", "testpassword")
+		tokens, err := client.Login(context.Background(), "testuser", "testpassword")
 		require.NoError(t, err)
-		assert.Equal(t, "testtoken", token)
+		assert.Equal(t, &domain.Tokens{Access: "testtoken", Refresh: "testrefresh"}, tokens)
 	})
 
 	t.Run("failed login", func(t *testing.T) {
@@ -39,9 +42,9 @@ func TestClient_Login(t *testing.T) {
 			Password: "testpassword",
 		}).Return(nil, errors.New("login error"))
 
-		token, err := client.Login(context.Background(), "testuser", "testpassword")
+		tokens, err := client.Login(context.Background(), "testuser", "testpassword")
 		require.Error(t, err)
-		assert.Empty(t, token)
+		assert.Nil(t, tokens)
 		assert.Contains(t, err.Error(), "failed to login")
 	})
 }
@@ -89,16 +92,17 @@ func TestClient_ValidateToken(t *testing.T) {
 			Token: "validtoken",
 		}).Return(&authv1.ValidateTokenResponse{
 			Username:       "user",
-			Password:       "password",
 			Role:           "USER",
 			Plan:           "FREE",
 			LinksRemaining: 10,
+			Limits:         &authv1.PlanLimits{LinkQuota: 100, MaxLinkLifetime: 3600, CustomAliases: true},
 		}, nil)
 
 		user, err := client.ValidateToken(context.Background(), "validtoken")
 		require.NoError(t, err)
 		assert.NotNil(t, user)
 		assert.Equal(t, "user", user.Username)
+		assert.Equal(t, domain.PlanLimits{LinkQuota: 100, MaxLinkLifetime: time.Hour, CustomAliases: true}, user.Limits)
 	})
 
 	t.Run("failed token validation", func(t *testing.T) {
@@ -142,3 +146,220 @@ func TestClient_ChangeLinksRemaining(t *testing.T) {
 		assert.Contains(t, err.Error(), "failed to change links remaining")
 	})
 }
+
+func TestClient_Refresh(t *testing.T) {
+	mockAuthClient := new(mocks.AuthClient)
+	client := &auth.Client{
+		Conn:   &grpc.ClientConn{},
+		Client: mockAuthClient,
+	}
+
+	t.Run("successful refresh", func(t *testing.T) {
+		mockAuthClient.On("Refresh", mock.Anything, &authv1.RefreshRequest{
+			RefreshToken: "testrefresh",
+		}).Return(&authv1.RefreshResponse{Token: "newtoken", RefreshToken: "newrefresh"}, nil).Once()
+
+		tokens, err := client.Refresh(context.Background(), "testrefresh")
+		require.NoError(t, err)
+		assert.Equal(t, &domain.Tokens{Access: "newtoken", Refresh: "newrefresh"}, tokens)
+	})
+
+	t.Run("failed refresh", func(t *testing.T) {
+		mockAuthClient.On("Refresh", mock.Anything, &authv1.RefreshRequest{
+			RefreshToken: "usedrefresh",
+		}).Return(nil, errors.New("refresh error")).Once()
+
+		tokens, err := client.Refresh(context.Background(), "usedrefresh")
+		require.Error(t, err)
+		assert.Nil(t, tokens)
+		assert.Contains(t, err.Error(), "failed to refresh token")
+	})
+}
+
+func TestClient_Logout(t *testing.T) {
+	mockAuthClient := new(mocks.AuthClient)
+	client := &auth.Client{
+		Conn:   &grpc.ClientConn{},
+		Client: mockAuthClient,
+	}
+
+	t.Run("successful logout", func(t *testing.T) {
+		mockAuthClient.On("Logout", mock.Anything, &authv1.LogoutRequest{
+			Token: "testtoken",
+		}).Return(&authv1.LogoutResponse{}, nil).Once()
+
+		err := client.Logout(context.Background(), "testtoken")
+		require.NoError(t, err)
+	})
+
+	t.Run("failed logout", func(t *testing.T) {
+		mockAuthClient.On("Logout", mock.Anything, &authv1.LogoutRequest{
+			Token: "testtoken",
+		}).Return(nil, errors.New("logout error")).Once()
+
+		err := client.Logout(context.Background(), "testtoken")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to logout")
+	})
+}
+
+func TestClient_ChangePassword(t *testing.T) {
+	mockAuthClient := new(mocks.AuthClient)
+	client := &auth.Client{
+		Conn:   &grpc.ClientConn{},
+		Client: mockAuthClient,
+	}
+	request := &authv1.ChangePasswordRequest{
+		Token:       "testtoken",
+		OldPassword: "old_password",
+		NewPassword: "new_password",
+	}
+
+	t.Run("password changed", func(t *testing.T) {
+		mockAuthClient.On("ChangePassword", mock.Anything, request).Return(&authv1.ChangePasswordResponse{}, nil).Once()
+
+		err := client.ChangePassword(context.Background(), "testtoken", "old_password", "new_password")
+		require.NoError(t, err)
+	})
+
+	t.Run("wrong old password", func(t *testing.T) {
+		mockAuthClient.On("ChangePassword", mock.Anything, request).
+			Return(nil, status.Error(codes.PermissionDenied, "invalid password")).Once()
+
+		err := client.ChangePassword(context.Background(), "testtoken", "old_password", "new_password")
+		require.ErrorIs(t, err, domain.ErrInvalidPassword)
+	})
+}
+
+func TestClient_ConsumeLinkQuota(t *testing.T) {
+	mockAuthClient := new(mocks.AuthClient)
+	client := &auth.Client{
+		Conn:   &grpc.ClientConn{},
+		Client: mockAuthClient,
+	}
+
+	t.Run("quota consumed", func(t *testing.T) {
+		mockAuthClient.On("ConsumeLinkQuota", mock.Anything, &authv1.ConsumeLinkQuotaRequest{
+			Username: "user",
+		}).Return(&authv1.ConsumeLinkQuotaResponse{}, nil).Once()
+
+		err := client.ConsumeLinkQuota(context.Background(), "user")
+		require.NoError(t, err)
+	})
+
+	t.Run("quota exhausted", func(t *testing.T) {
+		mockAuthClient.On("ConsumeLinkQuota", mock.Anything, &authv1.ConsumeLinkQuotaRequest{
+			Username: "user",
+		}).Return(nil, status.Error(codes.ResourceExhausted, "no links remaining")).Once()
+
+		err := client.ConsumeLinkQuota(context.Background(), "user")
+		require.ErrorIs(t, err, domain.ErrLinkQuotaExceeded)
+	})
+
+	t.Run("failed to consume quota", func(t *testing.T) {
+		mockAuthClient.On("ConsumeLinkQuota", mock.Anything, &authv1.ConsumeLinkQuotaRequest{
+			Username: "user",
+		}).Return(nil, errors.New("connection error")).Once()
+
+		err := client.ConsumeLinkQuota(context.Background(), "user")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, domain.ErrLinkQuotaExceeded)
+	})
+}
+
+func TestClient_RefundLinkQuota(t *testing.T) {
+	mockAuthClient := new(mocks.AuthClient)
+	client := &auth.Client{
+		Conn:   &grpc.ClientConn{},
+		Client: mockAuthClient,
+	}
+
+	mockAuthClient.On("RefundLinkQuota", mock.Anything, &authv1.RefundLinkQuotaRequest{
+		Username: "user",
+	}).Return(&authv1.RefundLinkQuotaResponse{}, nil).Once()
+
+	err := client.RefundLinkQuota(context.Background(), "user")
+	require.NoError(t, err)
+}
+
+func TestClient_ListPlans(t *testing.T) {
+	mockAuthClient := new(mocks.AuthClient)
+	client := &auth.Client{
+		Conn:   &grpc.ClientConn{},
+		Client: mockAuthClient,
+	}
+
+	mockAuthClient.On("ListPlans", mock.Anything, &authv1.ListPlansRequest{}).Return(&authv1.ListPlansResponse{
+		Plans: []*authv1.Plan{
+			{Name: "free", Limits: &authv1.PlanLimits{LinkQuota: 100, AnalyticsRetention: 86400}, IsDefault: true},
+		},
+	}, nil).Once()
+
+	plans, err := client.ListPlans(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []*domain.Plan{{
+		Name:    domain.FREE,
+		Limits:  domain.PlanLimits{LinkQuota: 100, AnalyticsRetention: 24 * time.Hour},
+		Default: true,
+	}}, plans)
+}
+
+func TestClient_ChangePlan(t *testing.T) {
+	mockAuthClient := new(mocks.AuthClient)
+	client := &auth.Client{
+		Conn:   &grpc.ClientConn{},
+		Client: mockAuthClient,
+	}
+
+	t.Run("successful change", func(t *testing.T) {
+		mockAuthClient.On("ChangePlan", mock.Anything, &authv1.ChangePlanRequest{
+			Username: "user",
+			Plan:     "premium",
+		}).Return(&authv1.ChangePlanResponse{}, nil).Once()
+
+		require.NoError(t, client.ChangePlan(context.Background(), "user", domain.PREMIUM))
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockAuthClient.On("ChangePlan", mock.Anything, &authv1.ChangePlanRequest{
+			Username: "ghost",
+			Plan:     "premium",
+		}).Return(nil, status.Error(codes.NotFound, "user not found")).Once()
+
+		require.ErrorIs(t, client.ChangePlan(context.Background(), "ghost", domain.PREMIUM), domain.ErrUserNotFound)
+	})
+
+	t.Run("unknown plan", func(t *testing.T) {
+		mockAuthClient.On("ChangePlan", mock.Anything, &authv1.ChangePlanRequest{
+			Username: "user",
+			Plan:     "gold",
+		}).Return(nil, status.Error(codes.FailedPrecondition, "plan not found")).Once()
+
+		require.ErrorIs(t, client.ChangePlan(context.Background(), "user", "gold"), domain.ErrPlanNotFound)
+	})
+}
+
+func TestClient_GetPlanLimits(t *testing.T) {
+	mockAuthClient := new(mocks.AuthClient)
+	client := &auth.Client{
+		Conn:   &grpc.ClientConn{},
+		Client: mockAuthClient,
+	}
+
+	t.Run("successful get", func(t *testing.T) {
+		mockAuthClient.On("GetPlanLimits", mock.Anything, &authv1.GetPlanLimitsRequest{Username: "user"}).
+			Return(&authv1.GetPlanLimitsResponse{Limits: &authv1.PlanLimits{AnalyticsRetention: 86400}}, nil).Once()
+
+		limits, err := client.GetPlanLimits(context.Background(), "user")
+		require.NoError(t, err)
+		assert.Equal(t, 24*time.Hour, limits.AnalyticsRetention)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockAuthClient.On("GetPlanLimits", mock.Anything, &authv1.GetPlanLimitsRequest{Username: "ghost"}).
+			Return(nil, status.Error(codes.NotFound, "user not found")).Once()
+
+		_, err := client.GetPlanLimits(context.Background(), "ghost")
+		require.ErrorIs(t, err, domain.ErrUserNotFound)
+	})
+}
@@ -0,0 +1,146 @@
+package enricher
+
+import (
+	"fmt"
+	"min/internal/core/domain"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/mileusna/useragent"
+	"github.com/oschwald/maxminddb-golang"
+	log "github.com/sirupsen/logrus"
+)
+
+// geoLookup looks up the record of the network containing the IP address in a MaxMind DB.
+type geoLookup interface {
+	Lookup(ip net.IP, result any) error
+}
+
+// geoRecord is the part of the GeoIP2/GeoLite2 City (or Country) record used by the enrichment.
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// Enricher derives the browser, OS, device and bot flag of the event from its user agent, the location from its
+// IP address and the preferred language from its Accept-Language header.
+type Enricher struct {
+	geo    geoLookup
+	reader *maxminddb.Reader
+}
+
+// NewEnricher creates an enricher using the MaxMind-format GeoIP database at geoIPPath, such as
+// GeoLite2-City.mmdb. The location is not resolved if the path is empty.
+func NewEnricher(geoIPPath string) (*Enricher, error) {
+	if geoIPPath == "" {
+		return &Enricher{}, nil
+	}
+
+	reader, err := maxminddb.Open(geoIPPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+
+	return &Enricher{geo: reader, reader: reader}, nil
+}
+
+// Enrich fills the derived attributes of the event.
+func (e *Enricher) Enrich(event *domain.Event) {
+	ua := useragent.Parse(event.UserAgent)
+	event.Browser = ua.Name
+	event.OS = ua.OS
+	event.Bot = ua.Bot
+	event.Device = device(ua)
+	event.Language = preferredLanguage(event.Language)
+
+	if e.geo == nil {
+		return
+	}
+
+	ip := parseIP(event.IP)
+	if ip == nil {
+		return
+	}
+
+	var record geoRecord
+	if err := e.geo.Lookup(ip, &record); err != nil {
+		log.Warnf("failed to look up location of %s: %v", ip, err)
+		return
+	}
+
+	event.Country = record.Country.ISOCode
+	event.City = record.City.Names["en"]
+}
+
+// Close closes the GeoIP database.
+func (e *Enricher) Close() error {
+	if e.reader == nil {
+		return nil
+	}
+
+	return e.reader.Close()
+}
+
+// device returns the device family of the user agent.
+func device(ua useragent.UserAgent) domain.Device {
+	switch {
+	case ua.Bot:
+		return domain.OTHER
+	case ua.Tablet:
+		return domain.TABLET
+	case ua.Mobile:
+		return domain.MOBILE
+	case ua.Desktop:
+		return domain.DESKTOP
+	default:
+		return domain.OTHER
+	}
+}
+
+// parseIP parses the IP address that may be followed by a port, nil is returned if it is not an IP address.
+func parseIP(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	return net.ParseIP(addr)
+}
+
+// preferredLanguage returns the language tag with the highest quality in the Accept-Language header, the first
+// one wins on a tie. Empty string is returned if no language is acceptable.
+func preferredLanguage(header string) string {
+	var preferred string
+	var best float64
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if name != "q" {
+				continue
+			}
+
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				q = 0
+			}
+			quality = q
+		}
+
+		if quality > best {
+			preferred, best = tag, quality
+		}
+	}
+
+	return preferred
+}
@@ -0,0 +1,125 @@
+package enricher
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"min/internal/core/domain"
+)
+
+const (
+	chromeDesktop = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) " +
+		"Chrome/126.0.0.0 Safari/537.36"
+	safariMobile = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 " +
+		"(KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"
+	googlebot = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+)
+
+// fakeGeo resolves the IP addresses to the records by their string form.
+type fakeGeo map[string]geoRecord
+
+func (g fakeGeo) Lookup(ip net.IP, result any) error {
+	record, ok := g[ip.String()]
+	if !ok {
+		return errors.New("lookup failed")
+	}
+
+	*result.(*geoRecord) = record
+	return nil
+}
+
+func TestEnricher_Enrich(t *testing.T) {
+	var berlin geoRecord
+	berlin.Country.ISOCode = "DE"
+	berlin.City.Names = map[string]string{"en": "Berlin", "de": "Berlin"}
+	enricher := &Enricher{geo: fakeGeo{"192.0.2.1": berlin, "2001:db8::1": berlin}}
+
+	tests := []struct {
+		name     string
+		event    domain.Event
+		expected domain.Event
+	}{
+		{
+			name:  "desktop browser",
+			event: domain.Event{UserAgent: chromeDesktop, Language: "de-DE,de;q=0.9,en;q=0.8", IP: "192.0.2.1:5123"},
+			expected: domain.Event{
+				UserAgent: chromeDesktop,
+				Language:  "de-DE",
+				IP:        "192.0.2.1:5123",
+				Browser:   "Chrome",
+				OS:        "Windows",
+				Device:    domain.DESKTOP,
+				Country:   "DE",
+				City:      "Berlin",
+			},
+		},
+		{
+			name:  "mobile browser with IPv6 address",
+			event: domain.Event{UserAgent: safariMobile, Language: "en;q=0.5, fr", IP: "[2001:db8::1]:443"},
+			expected: domain.Event{
+				UserAgent: safariMobile,
+				Language:  "fr",
+				IP:        "[2001:db8::1]:443",
+				Browser:   "Safari",
+				OS:        "iOS",
+				Device:    domain.MOBILE,
+				Country:   "DE",
+				City:      "Berlin",
+			},
+		},
+		{
+			name:  "crawler from unknown network",
+			event: domain.Event{UserAgent: googlebot, Language: "*", IP: "198.51.100.7"},
+			expected: domain.Event{
+				UserAgent: googlebot,
+				IP:        "198.51.100.7",
+				Browser:   "Googlebot",
+				Device:    domain.OTHER,
+				Bot:       true,
+			},
+		},
+		{
+			name:     "empty request attributes",
+			event:    domain.Event{},
+			expected: domain.Event{Device: domain.OTHER},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enricher.Enrich(&tt.event)
+			assert.Equal(t, tt.expected, tt.event)
+		})
+	}
+}
+
+func TestEnricher_WithoutGeoIP(t *testing.T) {
+	enricher, err := NewEnricher("")
+	assert.NoError(t, err)
+
+	event := domain.Event{UserAgent: chromeDesktop, IP: "192.0.2.1"}
+	enricher.Enrich(&event)
+
+	assert.Equal(t, "Chrome", event.Browser)
+	assert.Empty(t, event.Country)
+	assert.NoError(t, enricher.Close())
+}
+
+func TestPreferredLanguage(t *testing.T) {
+	tests := map[string]string{
+		"":                          "",
+		"en-US":                     "en-US",
+		"en-US,en;q=0.9":            "en-US",
+		"en;q=0.8, de;q=0.9":        "de",
+		"fr;q=0, it":                "it",
+		"*;q=0.5, pt-BR;q=0.4":      "pt-BR",
+		"es;q=0.7, ca;q=0.7":        "es",
+		" ru ; q=1 ; foo=bar , uk ": "ru",
+	}
+
+	for header, expected := range tests {
+		assert.Equal(t, expected, preferredLanguage(header), header)
+	}
+}
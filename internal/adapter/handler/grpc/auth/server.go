@@ -2,13 +2,17 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"log"
 	authv1 "min/api/gen/go/auth"
 	"min/internal/core/domain"
 	"min/internal/core/port"
 	"net"
+	"time"
 )
 
 // Server represents a gRPC server for auth operations.
@@ -57,12 +61,12 @@ func (s *Server) Stop() {
 // Login logs in the user with the specified username and password.
 func (s *Server) Login(ctx context.Context, req *authv1.LoginRequest) (*authv1.LoginResponse, error) {
 	log.Printf("Logging in user: %s\n", req.GetUsername())
-	token, err := s.authService.Login(ctx, req.GetUsername(), req.GetPassword())
+	tokens, err := s.authService.Login(ctx, req.GetUsername(), req.GetPassword())
 	if err != nil {
 		return nil, fmt.Errorf("failed to login: %w", err)
 	}
 
-	return &authv1.LoginResponse{Token: token}, nil
+	return &authv1.LoginResponse{Token: tokens.Access, RefreshToken: tokens.Refresh}, nil
 }
 
 // Register registers a new user.
@@ -72,7 +76,7 @@ func (s *Server) Register(ctx context.Context, req *authv1.RegisterRequest) (*au
 		Username:       req.GetUsername(),
 		Password:       req.GetPassword(),
 		Role:           domain.Role(req.GetRole()),
-		Plan:           domain.Plan(req.GetPlan()),
+		Plan:           domain.PlanName(req.GetPlan()),
 		LinksRemaining: req.GetLinksRemaining(),
 	})
 	if err != nil {
@@ -83,23 +87,23 @@ func (s *Server) Register(ctx context.Context, req *authv1.RegisterRequest) (*au
 	return &authv1.RegisterResponse{}, nil
 }
 
-// ValidateToken validates the specified token and returns the user details.
+// ValidateToken validates the specified token and returns the user details. Credentials are never returned.
 func (s *Server) ValidateToken(
 	ctx context.Context,
 	req *authv1.ValidateTokenRequest,
 ) (*authv1.ValidateTokenResponse, error) {
-	user, err := s.authService.ValidateToken(ctx, req.GetToken())
+	principal, err := s.authService.ValidateToken(ctx, req.GetToken())
 	if err != nil {
 		log.Println("Error validating token:", err)
 		return nil, fmt.Errorf("failed to validate token: %w", err)
 	}
 
 	return &authv1.ValidateTokenResponse{
-		Username:       user.Username,
-		Password:       user.Password,
-		Role:           string(user.Role),
-		Plan:           string(user.Plan),
-		LinksRemaining: user.LinksRemaining,
+		Username:       principal.Username,
+		Role:           string(principal.Role),
+		Plan:           string(principal.Plan),
+		LinksRemaining: principal.LinksRemaining,
+		Limits:         planLimitsToProto(principal.Limits),
 	}, nil
 }
 
@@ -117,3 +121,183 @@ func (s *Server) ChangeLinksRemaining(
 
 	return &authv1.ChangeLinksRemainingResponse{}, nil
 }
+
+// ConsumeLinkQuota uses up one link of the specified user.
+// The ResourceExhausted code is returned if the user has no links remaining.
+func (s *Server) ConsumeLinkQuota(
+	ctx context.Context,
+	req *authv1.ConsumeLinkQuotaRequest,
+) (*authv1.ConsumeLinkQuotaResponse, error) {
+	err := s.authService.ConsumeLinkQuota(ctx, req.GetUsername())
+	if err != nil {
+		log.Println("Error consuming link quota:", err)
+		if errors.Is(err, domain.ErrLinkQuotaExceeded) {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+		return nil, fmt.Errorf("failed to consume link quota: %w", err)
+	}
+
+	return &authv1.ConsumeLinkQuotaResponse{}, nil
+}
+
+// RefundLinkQuota gives one link back to the specified user.
+func (s *Server) RefundLinkQuota(
+	ctx context.Context,
+	req *authv1.RefundLinkQuotaRequest,
+) (*authv1.RefundLinkQuotaResponse, error) {
+	err := s.authService.RefundLinkQuota(ctx, req.GetUsername())
+	if err != nil {
+		log.Println("Error refunding link quota:", err)
+		return nil, fmt.Errorf("failed to refund link quota: %w", err)
+	}
+
+	return &authv1.RefundLinkQuotaResponse{}, nil
+}
+
+// Refresh exchanges the refresh token for a new pair of tokens.
+func (s *Server) Refresh(ctx context.Context, req *authv1.RefreshRequest) (*authv1.RefreshResponse, error) {
+	tokens, err := s.authService.Refresh(ctx, req.GetRefreshToken())
+	if err != nil {
+		log.Println("Error refreshing token:", err)
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	return &authv1.RefreshResponse{Token: tokens.Access, RefreshToken: tokens.Refresh}, nil
+}
+
+// Logout revokes all the tokens of the user the specified token belongs to.
+func (s *Server) Logout(ctx context.Context, req *authv1.LogoutRequest) (*authv1.LogoutResponse, error) {
+	err := s.authService.Logout(ctx, req.GetToken())
+	if err != nil {
+		log.Println("Error logging out:", err)
+		return nil, fmt.Errorf("failed to logout: %w", err)
+	}
+
+	return &authv1.LogoutResponse{}, nil
+}
+
+// ChangePassword changes the password of the user the specified token belongs to and revokes all their tokens.
+// The PermissionDenied code is returned if the old password doesn't match.
+func (s *Server) ChangePassword(
+	ctx context.Context,
+	req *authv1.ChangePasswordRequest,
+) (*authv1.ChangePasswordResponse, error) {
+	err := s.authService.ChangePassword(ctx, req.GetToken(), req.GetOldPassword(), req.GetNewPassword())
+	if err != nil {
+		log.Println("Error changing password:", err)
+		if errors.Is(err, domain.ErrInvalidPassword) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, fmt.Errorf("failed to change password: %w", err)
+	}
+
+	return &authv1.ChangePasswordResponse{}, nil
+}
+
+// CreatePlan creates a new billing plan.
+func (s *Server) CreatePlan(ctx context.Context, req *authv1.CreatePlanRequest) (*authv1.CreatePlanResponse, error) {
+	log.Printf("Creating plan: %s\n", req.GetPlan().GetName())
+	err := s.authService.CreatePlan(ctx, planFromProto(req.GetPlan()))
+	if err != nil {
+		log.Println("Error creating plan:", err)
+		switch {
+		case errors.Is(err, domain.ErrInvalidPlan):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, domain.ErrPlanExists):
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		default:
+			return nil, fmt.Errorf("failed to create plan: %w", err)
+		}
+	}
+
+	return &authv1.CreatePlanResponse{}, nil
+}
+
+// ListPlans returns all the billing plans.
+func (s *Server) ListPlans(ctx context.Context, _ *authv1.ListPlansRequest) (*authv1.ListPlansResponse, error) {
+	plans, err := s.authService.ListPlans(ctx)
+	if err != nil {
+		log.Println("Error listing plans:", err)
+		return nil, fmt.Errorf("failed to list plans: %w", err)
+	}
+
+	resp := &authv1.ListPlansResponse{Plans: make([]*authv1.Plan, 0, len(plans))}
+	for _, plan := range plans {
+		resp.Plans = append(resp.Plans, &authv1.Plan{
+			Name:      string(plan.Name),
+			Limits:    planLimitsToProto(plan.Limits),
+			IsDefault: plan.Default,
+		})
+	}
+
+	return resp, nil
+}
+
+// ChangePlan upgrades or downgrades the specified user to the plan.
+// The NotFound code is returned if the user does not exist and the FailedPrecondition code
+// is returned if the plan does not exist.
+func (s *Server) ChangePlan(ctx context.Context, req *authv1.ChangePlanRequest) (*authv1.ChangePlanResponse, error) {
+	log.Printf("Changing plan of user %s to %s\n", req.GetUsername(), req.GetPlan())
+	err := s.authService.ChangePlan(ctx, req.GetUsername(), domain.PlanName(req.GetPlan()))
+	if err != nil {
+		log.Println("Error changing plan:", err)
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, err.Error())
+		case errors.Is(err, domain.ErrPlanNotFound):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		default:
+			return nil, fmt.Errorf("failed to change plan: %w", err)
+		}
+	}
+
+	return &authv1.ChangePlanResponse{}, nil
+}
+
+// GetPlanLimits returns the limits of the plan of the specified user.
+// The NotFound code is returned if the user does not exist.
+func (s *Server) GetPlanLimits(
+	ctx context.Context,
+	req *authv1.GetPlanLimitsRequest,
+) (*authv1.GetPlanLimitsResponse, error) {
+	limits, err := s.authService.GetPlanLimits(ctx, req.GetUsername())
+	if err != nil {
+		log.Println("Error getting plan limits:", err)
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+
+		return nil, fmt.Errorf("failed to get plan limits: %w", err)
+	}
+
+	return &authv1.GetPlanLimitsResponse{Limits: planLimitsToProto(*limits)}, nil
+}
+
+// planFromProto converts the plan from the gRPC representation.
+func planFromProto(plan *authv1.Plan) *domain.Plan {
+	limits := plan.GetLimits()
+	return &domain.Plan{
+		Name: domain.PlanName(plan.GetName()),
+		Limits: domain.PlanLimits{
+			LinkQuota:          limits.GetLinkQuota(),
+			MaxLinkLifetime:    time.Duration(limits.GetMaxLinkLifetime()) * time.Second,
+			CustomAliases:      limits.GetCustomAliases(),
+			RateLimit:          limits.GetRateLimit(),
+			RateBurst:          limits.GetRateBurst(),
+			AnalyticsRetention: time.Duration(limits.GetAnalyticsRetention()) * time.Second,
+		},
+		Default: plan.GetIsDefault(),
+	}
+}
+
+// planLimitsToProto converts the plan limits to the gRPC representation.
+func planLimitsToProto(limits domain.PlanLimits) *authv1.PlanLimits {
+	return &authv1.PlanLimits{
+		LinkQuota:          limits.LinkQuota,
+		MaxLinkLifetime:    int64(limits.MaxLinkLifetime / time.Second),
+		CustomAliases:      limits.CustomAliases,
+		RateLimit:          limits.RateLimit,
+		RateBurst:          limits.RateBurst,
+		AnalyticsRetention: int64(limits.AnalyticsRetention / time.Second),
+	}
+}
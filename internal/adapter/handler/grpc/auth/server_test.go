@@ -1,20 +1,27 @@
 package auth_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 	"log"
 	"min/internal/adapter/handler/grpc/auth"
 	"min/internal/core/port"
+	"min/internal/core/service"
 	"min/internal/mocks"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 
 	authv1 "min/api/gen/go/auth"
@@ -63,13 +70,13 @@ func TestServer_Login(t *testing.T) {
 		mock.Anything,
 		"testuser",
 		"testpassword",
-	).Return("token123", nil)
+	).Return(&domain.Tokens{Access: "token123", Refresh: "refresh123"}, nil)
 	mockAuthService.On(
 		"Login",
 		mock.Anything,
 		"wronguser",
 		"wrongpassword",
-	).Return("", errors.New("invalid credentials"))
+	).Return(nil, errors.New("invalid credentials"))
 
 	conn, client := startTestServer(mockAuthService)
 	defer conn.Close()
@@ -83,6 +90,7 @@ func TestServer_Login(t *testing.T) {
 		require.NoError(t, err)
 		assert.NotNil(t, resp)
 		assert.Equal(t, "token123", resp.GetToken())
+		assert.Equal(t, "refresh123", resp.GetRefreshToken())
 	})
 
 	t.Run("failed login", func(t *testing.T) {
@@ -137,9 +145,8 @@ func TestServer_Register(t *testing.T) {
 
 func TestServer_ValidateToken(t *testing.T) {
 	mockAuthService := new(mocks.AuthService)
-	mockAuthService.On("ValidateToken", mock.Anything, "validtoken").Return(&domain.User{
+	mockAuthService.On("ValidateToken", mock.Anything, "validtoken").Return(&domain.Principal{
 		Username: "validuser",
-		Password: "validpassword",
 		Role:     domain.USER,
 	}, nil)
 	mockAuthService.On(
@@ -159,7 +166,6 @@ func TestServer_ValidateToken(t *testing.T) {
 		require.NoError(t, err)
 		assert.NotNil(t, resp)
 		assert.Equal(t, "validuser", resp.GetUsername())
-		assert.Equal(t, "validpassword", resp.GetPassword())
 		assert.Equal(t, "user", resp.GetRole())
 	})
 
@@ -172,3 +178,231 @@ func TestServer_ValidateToken(t *testing.T) {
 		assert.Nil(t, resp)
 	})
 }
+
+func TestServer_Refresh(t *testing.T) {
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On("Refresh", mock.Anything, "refresh123").Return(
+		&domain.Tokens{Access: "token456", Refresh: "refresh456"},
+		nil,
+	).Once()
+	mockAuthService.On("Refresh", mock.Anything, "used").Return(nil, domain.ErrInvalidRefreshToken).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	t.Run("successful refresh", func(t *testing.T) {
+		resp, err := client.Refresh(context.Background(), &authv1.RefreshRequest{RefreshToken: "refresh123"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "token456", resp.GetToken())
+		assert.Equal(t, "refresh456", resp.GetRefreshToken())
+	})
+
+	t.Run("failed refresh", func(t *testing.T) {
+		resp, err := client.Refresh(context.Background(), &authv1.RefreshRequest{RefreshToken: "used"})
+
+		require.Error(t, err)
+		assert.Nil(t, resp)
+	})
+}
+
+func TestServer_Logout(t *testing.T) {
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On("Logout", mock.Anything, "token123").Return(nil).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	resp, err := client.Logout(context.Background(), &authv1.LogoutRequest{Token: "token123"})
+
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestServer_ChangePassword(t *testing.T) {
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On("ChangePassword", mock.Anything, "token123", "old_password", "new_password").Return(nil).Once()
+	mockAuthService.On("ChangePassword", mock.Anything, "token123", "wrong_password", "new_password").
+		Return(domain.ErrInvalidPassword).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	resp, err := client.ChangePassword(context.Background(), &authv1.ChangePasswordRequest{
+		Token:       "token123",
+		OldPassword: "old_password",
+		NewPassword: "new_password",
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+
+	_, err = client.ChangePassword(context.Background(), &authv1.ChangePasswordRequest{
+		Token:       "token123",
+		OldPassword: "wrong_password",
+		NewPassword: "new_password",
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestServer_ValidateTokenNeverSerializesPassword(t *testing.T) {
+	const passwordHash = "$2a$10$/md3ztppcKhB9sjDb/GMZuYlb9o3bxvPnwO2v3up3/KlHCjMOskcG"
+
+	fields := (&authv1.ValidateTokenResponse{}).ProtoReflect().Descriptor().Fields()
+	for i := range fields.Len() {
+		assert.NotEqual(t, "password", string(fields.Get(i).Name()))
+	}
+
+	key, err := service.NewSigningKey("test", "HS256", []byte("secret"))
+	require.NoError(t, err)
+	keys, err := service.NewKeySet("test", key)
+	require.NoError(t, err)
+
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByUsername", mock.Anything, "validuser").Return(&domain.User{
+		Username: "validuser",
+		Password: passwordHash,
+		Role:     domain.USER,
+		Plan:     domain.FREE,
+	}, nil).Once()
+	plansRepo := new(mocks.PlanRepository)
+	plansRepo.On("Get", mock.Anything, domain.FREE).Return(&domain.Plan{Name: domain.FREE}, nil).Once()
+	authService := service.NewAuthService(
+		userRepo,
+		new(mocks.RefreshTokenRepository),
+		plansRepo,
+		keys,
+		time.Hour,
+		time.Hour,
+	)
+
+	token, err := keys.Sign(jwt.MapClaims{"username": "validuser", "exp": time.Now().Add(time.Hour).Unix()})
+	require.NoError(t, err)
+
+	conn, client := startTestServer(authService)
+	defer conn.Close()
+
+	resp, err := client.ValidateToken(context.Background(), &authv1.ValidateTokenRequest{Token: token})
+	require.NoError(t, err)
+	assert.Equal(t, "validuser", resp.GetUsername())
+
+	wire, err := proto.Marshal(resp)
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(wire, []byte(passwordHash)))
+	userRepo.AssertExpectations(t)
+}
+
+func TestServer_ConsumeLinkQuota(t *testing.T) {
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On("ConsumeLinkQuota", mock.Anything, "richuser").Return(nil).Once()
+	mockAuthService.On("ConsumeLinkQuota", mock.Anything, "pooruser").Return(domain.ErrLinkQuotaExceeded).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	t.Run("quota consumed", func(t *testing.T) {
+		_, err := client.ConsumeLinkQuota(context.Background(), &authv1.ConsumeLinkQuotaRequest{Username: "richuser"})
+		require.NoError(t, err)
+	})
+
+	t.Run("quota exhausted", func(t *testing.T) {
+		_, err := client.ConsumeLinkQuota(context.Background(), &authv1.ConsumeLinkQuotaRequest{Username: "pooruser"})
+		require.Error(t, err)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+}
+
+func TestServer_RefundLinkQuota(t *testing.T) {
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On("RefundLinkQuota", mock.Anything, "user").Return(nil).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	_, err := client.RefundLinkQuota(context.Background(), &authv1.RefundLinkQuotaRequest{Username: "user"})
+	require.NoError(t, err)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestServer_CreatePlan(t *testing.T) {
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On("CreatePlan", mock.Anything, &domain.Plan{
+		Name: "team",
+		Limits: domain.PlanLimits{
+			LinkQuota:       500,
+			MaxLinkLifetime: 24 * time.Hour,
+			CustomAliases:   true,
+			RateLimit:       20,
+			RateBurst:       200,
+		},
+	}).Return(nil).Once()
+	mockAuthService.On("CreatePlan", mock.Anything, mock.MatchedBy(func(plan *domain.Plan) bool {
+		return plan.Name == "free"
+	})).Return(domain.ErrPlanExists).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	t.Run("plan created", func(t *testing.T) {
+		_, err := client.CreatePlan(context.Background(), &authv1.CreatePlanRequest{Plan: &authv1.Plan{
+			Name: "team",
+			Limits: &authv1.PlanLimits{
+				LinkQuota:       500,
+				MaxLinkLifetime: 86400,
+				CustomAliases:   true,
+				RateLimit:       20,
+				RateBurst:       200,
+			},
+		}})
+		require.NoError(t, err)
+	})
+
+	t.Run("plan exists", func(t *testing.T) {
+		_, err := client.CreatePlan(context.Background(), &authv1.CreatePlanRequest{Plan: &authv1.Plan{Name: "free"}})
+		require.Error(t, err)
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	})
+
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestServer_ChangePlan(t *testing.T) {
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On("ChangePlan", mock.Anything, "user", domain.PREMIUM).Return(nil).Once()
+	mockAuthService.On("ChangePlan", mock.Anything, "ghost", domain.PREMIUM).Return(domain.ErrUserNotFound).Once()
+	mockAuthService.On("ChangePlan", mock.Anything, "user", domain.PlanName("gold")).Return(domain.ErrPlanNotFound).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	_, err := client.ChangePlan(context.Background(), &authv1.ChangePlanRequest{Username: "user", Plan: "premium"})
+	require.NoError(t, err)
+
+	_, err = client.ChangePlan(context.Background(), &authv1.ChangePlanRequest{Username: "ghost", Plan: "premium"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.ChangePlan(context.Background(), &authv1.ChangePlanRequest{Username: "user", Plan: "gold"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestServer_GetPlanLimits(t *testing.T) {
+	mockAuthService := new(mocks.AuthService)
+	limits := &domain.PlanLimits{LinkQuota: 100, AnalyticsRetention: 24 * time.Hour}
+	mockAuthService.On("GetPlanLimits", mock.Anything, "user").Return(limits, nil).Once()
+	mockAuthService.On("GetPlanLimits", mock.Anything, "ghost").Return(nil, domain.ErrUserNotFound).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	resp, err := client.GetPlanLimits(context.Background(), &authv1.GetPlanLimitsRequest{Username: "user"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(86400), resp.GetLimits().GetAnalyticsRetention())
+
+	_, err = client.GetPlanLimits(context.Background(), &authv1.GetPlanLimitsRequest{Username: "ghost"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	mockAuthService.AssertExpectations(t)
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/go-playground/validator/v10"
 	"log"
 	"min/internal/core/domain"
@@ -41,14 +42,17 @@ func (ah *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := ah.authClient.Login(r.Context(), creds.Username, creds.Password)
+	tokens, err := ah.authClient.Login(r.Context(), creds.Username, creds.Password)
 	if err != nil {
 		log.Printf("Error logging in: %v", err)
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	if err = json.NewEncoder(w).Encode(map[string]string{"token": token}); err != nil {
+	if err = json.NewEncoder(w).Encode(map[string]string{
+		"token":         tokens.Access,
+		"refresh_token": tokens.Refresh,
+	}); err != nil {
 		log.Printf("Error encoding response: %v", err)
 		return
 	}
@@ -92,3 +96,100 @@ func (ah *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusCreated)
 }
+
+// Refresh handles requests to exchange a refresh token for a new pair of tokens.
+func (ah *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" validate:"required"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error decoding request: %v", err)
+		http.Error(w, "Failed to parse request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(req)
+	if err != nil {
+		log.Printf("Error validating request: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := ah.authClient.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		log.Printf("Error refreshing token: %v", err)
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(map[string]string{
+		"token":         tokens.Access,
+		"refresh_token": tokens.Refresh,
+	}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+// Logout handles logout requests. It revokes all the tokens of the current user.
+func (ah *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := ah.authClient.Logout(r.Context(), token); err != nil {
+		log.Printf("Error logging out: %v", err)
+		http.Error(w, "Failed to logout", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangePassword handles requests to change the password of the current user. It revokes all the tokens of
+// the user, so the user has to log in again.
+func (ah *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		OldPassword string `json:"old_password" validate:"required"`
+		NewPassword string `json:"new_password" validate:"required,min=5,max=20"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error decoding request: %v", err)
+		http.Error(w, "Failed to parse request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(req)
+	if err != nil {
+		log.Printf("Error validating request: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err = ah.authClient.ChangePassword(r.Context(), token, req.OldPassword, req.NewPassword)
+	if err != nil {
+		log.Printf("Error changing password: %v", err)
+		if errors.Is(err, domain.ErrInvalidPassword) {
+			http.Error(w, "Invalid password", http.StatusForbidden)
+			return
+		}
+		http.Error(w, "Failed to change password", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
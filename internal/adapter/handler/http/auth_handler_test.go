@@ -21,7 +21,7 @@ func TestAuthHandler_Login(t *testing.T) {
 		mock.Anything,
 		"valid_user",
 		"valid_pass",
-	).Return("valid_token", nil).Once()
+	).Return(&domain.Tokens{Access: "valid_token", Refresh: "refresh_token"}, nil).Once()
 
 	handler := NewAuthHandler(authClient)
 	creds := map[string]string{
@@ -34,6 +34,7 @@ func TestAuthHandler_Login(t *testing.T) {
 	handler.Login(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"token": "valid_token", "refresh_token": "refresh_token"}`, rr.Body.String())
 	authClient.AssertExpectations(t)
 }
 
@@ -44,7 +45,7 @@ func TestAuthHandler_LoginInvalidCredentials(t *testing.T) {
 		mock.Anything,
 		"invalid_user",
 		"invalid_pass",
-	).Return("", errors.New("invalid")).Once()
+	).Return(nil, errors.New("invalid")).Once()
 
 	handler := NewAuthHandler(authClient)
 	creds := map[string]string{
@@ -78,7 +79,7 @@ func TestAuthHandler_Register(t *testing.T) {
 	}
 	credsBytes, _ := json.Marshal(creds)
 	req, _ := http.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(credsBytes))
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.ADMIN}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{Role: domain.ADMIN}))
 	rr := httptest.NewRecorder()
 	handler.Register(rr, req)
 
@@ -97,7 +98,7 @@ func TestAuthHandler_RegisterInvalidRole(t *testing.T) {
 	}
 	credsBytes, _ := json.Marshal(creds)
 	req, _ := http.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(credsBytes))
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.ADMIN}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{Role: domain.ADMIN}))
 	rr := httptest.NewRecorder()
 	handler.Register(rr, req)
 
@@ -116,7 +117,7 @@ func TestAuthHandler_Register_InvalidRole(t *testing.T) {
 	}
 	credsBytes, _ := json.Marshal(creds)
 	req, _ := http.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(credsBytes))
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.ADMIN}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{Role: domain.ADMIN}))
 	rr := httptest.NewRecorder()
 	handler.Register(rr, req)
 
@@ -129,7 +130,7 @@ func TestAuthHandler_Register_DecodeError(t *testing.T) {
 
 	handler := NewAuthHandler(authClient)
 	req, _ := http.NewRequest(http.MethodPost, "/register", bytes.NewBufferString("{invalid json}"))
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.ADMIN}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{Role: domain.ADMIN}))
 	rr := httptest.NewRecorder()
 	handler.Register(rr, req)
 
@@ -148,7 +149,7 @@ func TestAuthHandler_Register_ValidationError(t *testing.T) {
 	}
 	credsBytes, _ := json.Marshal(creds)
 	req, _ := http.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(credsBytes))
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.ADMIN}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{Role: domain.ADMIN}))
 	rr := httptest.NewRecorder()
 	handler.Register(rr, req)
 
@@ -174,10 +175,93 @@ func TestAuthHandler_Register_ServiceError(t *testing.T) {
 	}
 	credsBytes, _ := json.Marshal(creds)
 	req, _ := http.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(credsBytes))
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.ADMIN}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{Role: domain.ADMIN}))
 	rr := httptest.NewRecorder()
 	handler.Register(rr, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 	authClient.AssertExpectations(t)
 }
+
+func TestAuthHandler_Refresh(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("Refresh", mock.Anything, "refresh_token").Return(
+		&domain.Tokens{Access: "new_token", Refresh: "new_refresh_token"},
+		nil,
+	).Once()
+	authClient.On("Refresh", mock.Anything, "used_token").Return(nil, errors.New("invalid")).Once()
+
+	handler := NewAuthHandler(authClient)
+
+	req, _ := http.NewRequest(http.MethodPost, "/refresh", bytes.NewBufferString(`{"refresh_token": "refresh_token"}`))
+	rr := httptest.NewRecorder()
+	handler.Refresh(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"token": "new_token", "refresh_token": "new_refresh_token"}`, rr.Body.String())
+
+	req, _ = http.NewRequest(http.MethodPost, "/refresh", bytes.NewBufferString(`{"refresh_token": "used_token"}`))
+	rr = httptest.NewRecorder()
+	handler.Refresh(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/refresh", bytes.NewBufferString(`{}`))
+	rr = httptest.NewRecorder()
+	handler.Refresh(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	authClient.AssertExpectations(t)
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("Logout", mock.Anything, "valid_token").Return(nil).Once()
+
+	handler := NewAuthHandler(authClient)
+
+	req, _ := http.NewRequest(http.MethodPost, "/logout", nil)
+	req.Header.Set("Authorization", "Bearer valid_token")
+	rr := httptest.NewRecorder()
+	handler.Logout(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/logout", nil)
+	rr = httptest.NewRecorder()
+	handler.Logout(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	authClient.AssertExpectations(t)
+}
+
+func TestAuthHandler_ChangePassword(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("ChangePassword", mock.Anything, "valid_token", "old_password", "new_password").Return(nil).Once()
+	authClient.On("ChangePassword", mock.Anything, "valid_token", "wrong_password", "new_password").
+		Return(domain.ErrInvalidPassword).Once()
+	handler := NewAuthHandler(authClient)
+
+	changePassword := func(token, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/password", bytes.NewBufferString(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ChangePassword(rr, req)
+		return rr
+	}
+
+	rr := changePassword("valid_token", `{"old_password": "old_password", "new_password": "new_password"}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = changePassword("valid_token", `{"old_password": "wrong_password", "new_password": "new_password"}`)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = changePassword("valid_token", `{"old_password": "old_password", "new_password": "new"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = changePassword("", `{"old_password": "old_password", "new_password": "new_password"}`)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	authClient.AssertExpectations(t)
+}
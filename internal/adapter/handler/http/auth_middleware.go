@@ -17,7 +17,7 @@ func AuthorizationMiddleware(role domain.Role) func(http.HandlerFunc) http.Handl
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
 			userData := r.Context().Value(currentUserKey)
-			user, _ := userData.(*domain.User)
+			user, _ := userData.(*domain.Principal)
 
 			if user == nil || user.Role > role {
 				log.Printf(
@@ -40,8 +40,8 @@ func AuthorizationMiddleware(role domain.Role) func(http.HandlerFunc) http.Handl
 func AuthenticationMiddleware(authClient port.AuthClient, required bool) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			tokenString := r.Header.Get("Authorization")
-			if len(tokenString) <= len("Bearer ") {
+			tokenString, ok := bearerToken(r)
+			if !ok {
 				if required {
 					http.Error(w, "Unauthorized", http.StatusUnauthorized)
 					return
@@ -51,7 +51,7 @@ func AuthenticationMiddleware(authClient port.AuthClient, required bool) func(ht
 				return
 			}
 
-			user, err := authClient.ValidateToken(r.Context(), tokenString[len("Bearer "):])
+			user, err := authClient.ValidateToken(r.Context(), tokenString)
 			if err != nil {
 				log.Printf("Error validating token: %v", err)
 				if required {
@@ -68,3 +68,13 @@ func AuthenticationMiddleware(authClient port.AuthClient, required bool) func(ht
 		}
 	}
 }
+
+// bearerToken returns the token from the Authorization header of the request.
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if len(header) <= len("Bearer ") {
+		return "", false
+	}
+
+	return header[len("Bearer "):], true
+}
@@ -14,7 +14,7 @@ import (
 func TestAuthorizationMiddlewareWithAdminRole(t *testing.T) {
 	handler := AuthorizationMiddleware(domain.ADMIN)(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
 	req, _ := http.NewRequest(http.MethodGet, "/", nil)
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.ADMIN}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{Role: domain.ADMIN}))
 	rr := httptest.NewRecorder()
 	handler.ServeHTTP(rr, req)
 
@@ -24,7 +24,7 @@ func TestAuthorizationMiddlewareWithAdminRole(t *testing.T) {
 func TestAuthorizationMiddlewareWithUserRole(t *testing.T) {
 	handler := AuthorizationMiddleware(domain.ADMIN)(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
 	req, _ := http.NewRequest(http.MethodGet, "/", nil)
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.USER}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{Role: domain.USER}))
 	rr := httptest.NewRecorder()
 	handler.ServeHTTP(rr, req)
 
@@ -33,7 +33,7 @@ func TestAuthorizationMiddlewareWithUserRole(t *testing.T) {
 
 func TestAuthenticationMiddlewareWithValidToken(t *testing.T) {
 	authClient := new(mocks.AuthClient)
-	authClient.On("ValidateToken", mock.Anything, "valid_token").Return(&domain.User{}, nil).Once()
+	authClient.On("ValidateToken", mock.Anything, "valid_token").Return(&domain.Principal{}, nil).Once()
 
 	var handler = AuthenticationMiddleware(
 		authClient,
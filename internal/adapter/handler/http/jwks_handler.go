@@ -0,0 +1,22 @@
+package http
+
+import (
+	"min/internal/core/port"
+	"net/http"
+)
+
+// JWKSHandler provides the public keys used to verify tokens, so that services can verify them locally.
+type JWKSHandler struct {
+	keyProvider port.KeyProvider
+}
+
+// NewJWKSHandler creates a new instance of JWKSHandler.
+func NewJWKSHandler(keyProvider port.KeyProvider) *JWKSHandler {
+	return &JWKSHandler{keyProvider: keyProvider}
+}
+
+// JWKS handles requests for the JSON Web Key Set.
+func (jh *JWKSHandler) JWKS(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, jh.keyProvider.JWKS())
+}
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"min/internal/core/domain"
+	"min/internal/mocks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJWKSHandler_JWKS(t *testing.T) {
+	keyProviderMock := new(mocks.KeyProvider)
+	handler := NewJWKSHandler(keyProviderMock)
+
+	keyProviderMock.On("JWKS").Return(domain.JSONWebKeySet{Keys: []domain.JSONWebKey{{
+		KeyType:   "OKP",
+		KeyID:     "ed",
+		Use:       "sig",
+		Algorithm: "EdDSA",
+		Curve:     "Ed25519",
+		X:         "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",
+	}}}).Once()
+
+	req, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	handler.JWKS(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"keys": [{
+		"kty": "OKP",
+		"kid": "ed",
+		"use": "sig",
+		"alg": "EdDSA",
+		"crv": "Ed25519",
+		"x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"
+	}]}`, rr.Body.String())
+	keyProviderMock.AssertExpectations(t)
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	log "github.com/sirupsen/logrus"
+	"min/internal/core/domain"
+	"min/internal/core/port"
+	"net/http"
+	"time"
+)
+
+// PlanHandler provides methods for handling the admin requests managing the billing plans.
+type PlanHandler struct {
+	authClient port.AuthClient
+}
+
+// NewPlanHandler creates a new instance of PlanHandler.
+func NewPlanHandler(authClient port.AuthClient) *PlanHandler {
+	return &PlanHandler{authClient: authClient}
+}
+
+// planBody is a JSON representation of a billing plan. Durations are in seconds, zero means unlimited.
+type planBody struct {
+	Name               string `json:"name" validate:"required,max=64"`
+	LinkQuota          int64  `json:"link_quota" validate:"gte=0"`
+	MaxLinkLifetime    int64  `json:"max_link_lifetime" validate:"gte=0"`
+	CustomAliases      bool   `json:"custom_aliases"`
+	RateLimit          int64  `json:"rate_limit" validate:"gt=0"`
+	RateBurst          int64  `json:"rate_burst" validate:"gt=0"`
+	AnalyticsRetention int64  `json:"analytics_retention" validate:"gte=0"`
+	Default            bool   `json:"default"`
+}
+
+// CreatePlan handles requests to create a new billing plan.
+func (ph *PlanHandler) CreatePlan(w http.ResponseWriter, r *http.Request) {
+	var body planBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		log.Errorf("Error decoding request: %v", err)
+		http.Error(w, "Failed to parse request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(body); err != nil {
+		log.Errorf("Error validating request: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err := ph.authClient.CreatePlan(r.Context(), &domain.Plan{
+		Name: domain.PlanName(body.Name),
+		Limits: domain.PlanLimits{
+			LinkQuota:          body.LinkQuota,
+			MaxLinkLifetime:    time.Duration(body.MaxLinkLifetime) * time.Second,
+			CustomAliases:      body.CustomAliases,
+			RateLimit:          body.RateLimit,
+			RateBurst:          body.RateBurst,
+			AnalyticsRetention: time.Duration(body.AnalyticsRetention) * time.Second,
+		},
+		Default: body.Default,
+	})
+	if err != nil {
+		log.Errorf("Failed to create plan: %v", err)
+		switch {
+		case errors.Is(err, domain.ErrInvalidPlan):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, domain.ErrPlanExists):
+			http.Error(w, "Plan already exists", http.StatusConflict)
+		default:
+			http.Error(w, "Failed to create plan", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// ListPlans handles requests for all the billing plans.
+func (ph *PlanHandler) ListPlans(w http.ResponseWriter, r *http.Request) {
+	plans, err := ph.authClient.ListPlans(r.Context())
+	if err != nil {
+		log.Errorf("Failed to list plans: %v", err)
+		http.Error(w, "Failed to list plans", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]planBody, 0, len(plans))
+	for _, plan := range plans {
+		resp = append(resp, planBody{
+			Name:               string(plan.Name),
+			LinkQuota:          plan.Limits.LinkQuota,
+			MaxLinkLifetime:    int64(plan.Limits.MaxLinkLifetime / time.Second),
+			CustomAliases:      plan.Limits.CustomAliases,
+			RateLimit:          plan.Limits.RateLimit,
+			RateBurst:          plan.Limits.RateBurst,
+			AnalyticsRetention: int64(plan.Limits.AnalyticsRetention / time.Second),
+			Default:            plan.Default,
+		})
+	}
+
+	writeJSON(w, resp)
+}
+
+// ChangePlan handles requests to upgrade or downgrade the user from the path to another plan.
+func (ph *PlanHandler) ChangePlan(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Plan string `json:"plan" validate:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		log.Errorf("Error decoding request: %v", err)
+		http.Error(w, "Failed to parse request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(body); err != nil {
+		log.Errorf("Error validating request: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	username := r.PathValue("username")
+	err := ph.authClient.ChangePlan(r.Context(), username, domain.PlanName(body.Plan))
+	if err != nil {
+		log.Errorf("Failed to change plan of user %s: %v", username, err)
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			http.Error(w, "User not found", http.StatusNotFound)
+		case errors.Is(err, domain.ErrPlanNotFound):
+			http.Error(w, "Plan not found", http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to change plan", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
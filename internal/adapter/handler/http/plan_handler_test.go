@@ -0,0 +1,118 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"min/internal/core/domain"
+	"min/internal/mocks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPlanHandler_CreatePlan(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	handler := NewPlanHandler(authClient)
+
+	t.Run("successful create", func(t *testing.T) {
+		authClient.On("CreatePlan", mock.Anything, &domain.Plan{
+			Name: "team",
+			Limits: domain.PlanLimits{
+				LinkQuota:       500,
+				MaxLinkLifetime: time.Hour,
+				CustomAliases:   true,
+				RateLimit:       20,
+				RateBurst:       200,
+			},
+		}).Return(nil).Once()
+
+		body := `{"name":"team","link_quota":500,"max_link_lifetime":3600,"custom_aliases":true,` +
+			`"rate_limit":20,"rate_burst":200}`
+		req := httptest.NewRequest(http.MethodPost, "/plans", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		handler.CreatePlan(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	})
+
+	t.Run("negative quota", func(t *testing.T) {
+		body := `{"name":"team","link_quota":-1,"rate_limit":20,"rate_burst":200}`
+		req := httptest.NewRequest(http.MethodPost, "/plans", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		handler.CreatePlan(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("plan exists", func(t *testing.T) {
+		authClient.On("CreatePlan", mock.Anything, mock.Anything).Return(domain.ErrPlanExists).Once()
+
+		body := `{"name":"free","link_quota":100,"rate_limit":10,"rate_burst":100}`
+		req := httptest.NewRequest(http.MethodPost, "/plans", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		handler.CreatePlan(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	authClient.AssertExpectations(t)
+}
+
+func TestPlanHandler_ListPlans(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("ListPlans", mock.Anything).Return([]*domain.Plan{{
+		Name:    domain.FREE,
+		Limits:  domain.PlanLimits{LinkQuota: 100, MaxLinkLifetime: time.Hour, RateLimit: 10, RateBurst: 100},
+		Default: true,
+	}}, nil).Once()
+
+	handler := NewPlanHandler(authClient)
+	req := httptest.NewRequest(http.MethodGet, "/plans", nil)
+	rr := httptest.NewRecorder()
+	handler.ListPlans(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{"name":"free","link_quota":100,"max_link_lifetime":3600,"custom_aliases":false,
+		"rate_limit":10,"rate_burst":100,"analytics_retention":0,"default":true}]`, rr.Body.String())
+	authClient.AssertExpectations(t)
+}
+
+func TestPlanHandler_ChangePlan(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	handler := NewPlanHandler(authClient)
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /users/{username}/plan", handler.ChangePlan)
+
+	tests := []struct {
+		name     string
+		username string
+		err      error
+		code     int
+	}{
+		{name: "successful change", username: "user", code: http.StatusNoContent},
+		{name: "unknown user", username: "ghost", err: domain.ErrUserNotFound, code: http.StatusNotFound},
+		{name: "unknown plan", username: "user", err: domain.ErrPlanNotFound, code: http.StatusBadRequest},
+		{name: "auth server error", username: "user", err: errors.New("unavailable"), code: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authClient.On("ChangePlan", mock.Anything, tt.username, domain.PREMIUM).Return(tt.err).Once()
+
+			req := httptest.NewRequest(
+				http.MethodPut,
+				"/users/"+tt.username+"/plan",
+				bytes.NewBufferString(`{"plan":"premium"}`),
+			)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code)
+		})
+	}
+
+	authClient.AssertExpectations(t)
+}
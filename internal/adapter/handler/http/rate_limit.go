@@ -0,0 +1,27 @@
+package http
+
+import (
+	"min/internal/core/domain"
+	"min/pkg/clientip"
+	"min/pkg/middleware"
+	"net/http"
+)
+
+// RateLimitPolicy limits the authenticated users by their usernames with the rate limits of their plans and
+// the anonymous clients by their IP addresses with the default limit. Users whose plans have no rate limits
+// get the default limit as well. The authentication middleware must be in front of the limiter.
+func RateLimitPolicy(defaultLimit middleware.Limit) middleware.RateLimitPolicy {
+	return func(r *http.Request) (string, middleware.Limit) {
+		user, _ := r.Context().Value(currentUserKey).(*domain.Principal)
+		if user == nil {
+			return "ip:" + clientip.FromRequest(r), defaultLimit
+		}
+
+		limit := middleware.Limit{Rate: user.Limits.RateLimit, Burst: user.Limits.RateBurst}
+		if limit.Rate <= 0 || limit.Burst <= 0 {
+			limit = defaultLimit
+		}
+
+		return "user:" + user.Username, limit
+	}
+}
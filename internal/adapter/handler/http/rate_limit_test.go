@@ -0,0 +1,60 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"min/internal/core/domain"
+	"min/pkg/middleware"
+)
+
+func TestRateLimitPolicy(t *testing.T) {
+	defaultLimit := middleware.Limit{Rate: 1, Burst: 10}
+	policy := RateLimitPolicy(defaultLimit)
+
+	tests := []struct {
+		name          string
+		user          *domain.Principal
+		expectedKey   string
+		expectedLimit middleware.Limit
+	}{
+		{
+			name:          "anonymous client",
+			expectedKey:   "ip:192.0.2.1",
+			expectedLimit: defaultLimit,
+		},
+		{
+			name: "user with plan limits",
+			user: &domain.Principal{
+				Username: "user",
+				Plan:     domain.PREMIUM,
+				Limits:   domain.PlanLimits{RateLimit: 20, RateBurst: 200},
+			},
+			expectedKey:   "user:user",
+			expectedLimit: middleware.Limit{Rate: 20, Burst: 200},
+		},
+		{
+			name:          "user without plan limits",
+			user:          &domain.Principal{Username: "user"},
+			expectedKey:   "user:user",
+			expectedLimit: defaultLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/", nil)
+			assert.NoError(t, err)
+			req.RemoteAddr = "192.0.2.1:1234"
+			if tt.user != nil {
+				req = req.WithContext(context.WithValue(req.Context(), currentUserKey, tt.user))
+			}
+
+			key, limit := policy(req)
+			assert.Equal(t, tt.expectedKey, key)
+			assert.Equal(t, tt.expectedLimit, limit)
+		})
+	}
+}
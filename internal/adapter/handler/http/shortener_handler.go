@@ -1,27 +1,44 @@
 package http
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	log "github.com/sirupsen/logrus"
 	"min/internal/core/domain"
 	"min/internal/core/port"
+	"min/pkg/clientip"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 )
 
+// RedirectConfig describes how the short URLs are redirected by default.
+type RedirectConfig struct {
+	// DefaultCode is the HTTP status code of the links without their own one.
+	DefaultCode int
+	// ForwardQuery appends the query parameters of the request to the original URL of every link.
+	ForwardQuery bool
+}
+
 // ShortenerHandler provides methods for handling redirect requests and shorten requests.
 type ShortenerHandler struct {
 	shortenerService port.ShortenerService
 	eventProducer    port.EventProducer
+	redirect         RedirectConfig
 }
 
 // NewShortenerHandler creates a new instance of ShortenerHandler.
 func NewShortenerHandler(
 	shortenerService port.ShortenerService,
 	eventProducer port.EventProducer,
+	redirect RedirectConfig,
 ) *ShortenerHandler {
 	return &ShortenerHandler{
 		shortenerService: shortenerService,
 		eventProducer:    eventProducer,
+		redirect:         redirect,
 	}
 }
 
@@ -35,27 +52,90 @@ func (sh *ShortenerHandler) Redirect(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	original, err := sh.shortenerService.Resolve(r.Context(), short)
+	resolved, err := sh.shortenerService.Resolve(r.Context(), short)
 	if err != nil {
 		log.Errorf("Failed to resolve URL: %v", err)
-		http.Error(w, "Failed to resolve URL: "+err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, domain.ErrURLExpired):
+			http.Error(w, "Short URL has expired", http.StatusGone)
+		case errors.Is(err, domain.ErrShortURLNotFound):
+			http.Error(w, "Short URL not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Failed to resolve URL: "+err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
-	err = sh.eventProducer.Produce(domain.NewEvent(short, original, r.UserAgent(), r.RemoteAddr))
+	// Losing a click event is better than failing the redirect, so the error is only logged.
+	event, err := domain.NewEvent(
+		resolved,
+		r.UserAgent(),
+		r.Referer(),
+		r.Header.Get("Accept-Language"),
+		clientip.FromRequest(r),
+	)
+	if err == nil {
+		err = sh.eventProducer.Produce(event)
+	}
 	if err != nil {
 		log.Errorf("Failed to produce event: %v", err)
-		http.Error(w, "Failed to produce event: "+err.Error(), http.StatusInternalServerError)
-		return
 	}
 
-	log.Infof("Successfully redirecting to: %s", original)
-	http.Redirect(w, r, original, http.StatusPermanentRedirect)
+	code := resolved.RedirectCode
+	if code == 0 {
+		code = sh.redirect.DefaultCode
+	}
+
+	target := resolved.Original
+	if resolved.ForwardQuery || sh.redirect.ForwardQuery {
+		target = appendQuery(target, r.URL.Query())
+	}
+
+	log.Infof("Successfully redirecting to: %s", target)
+	http.Redirect(w, r, target, code)
+}
+
+// appendQuery adds the query parameters to the original URL, keeping its fragment at the end.
+// The parameters of the original URL take precedence over the forwarded ones with the same name.
+func appendQuery(original string, query url.Values) string {
+	if len(query) == 0 {
+		return original
+	}
+
+	target, err := url.Parse(original)
+	if err != nil {
+		log.Errorf("Failed to parse original URL %s: %v", original, err)
+		return original
+	}
+
+	existing := target.Query()
+	forwarded := make(url.Values, len(query))
+	for key, values := range query {
+		if _, ok := existing[key]; !ok {
+			forwarded[key] = values
+		}
+	}
+	if len(forwarded) == 0 {
+		return original
+	}
+
+	if target.RawQuery != "" {
+		target.RawQuery += "&"
+	}
+	target.RawQuery += forwarded.Encode()
+
+	return target.String()
 }
 
 // Shorten handles shorten requests by shortening the original URL and returning the shortened URL.
+// An optional alias query parameter can be used to request a custom short URL.
+// The link lifetime can be limited either with an absolute expires_at time (RFC 3339)
+// or with a relative ttl duration (e.g. 72h). With dedupe=true the existing link of the user to the same
+// page is returned instead of creating a new one. The redirect_code (301, 302, 307 or 308) and
+// forward_query parameters override the default redirect behavior for the link.
 func (sh *ShortenerHandler) Shorten(w http.ResponseWriter, r *http.Request) {
 	original := r.URL.Query().Get("url")
+	alias := r.URL.Query().Get("alias")
 	log.Infof("Got request to shorten: %s", original)
 	if original == "" {
 		log.Errorf("Original URL is required")
@@ -63,19 +143,69 @@ func (sh *ShortenerHandler) Shorten(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expiresAt, err := parseExpiration(r.URL.Query().Get("expires_at"), r.URL.Query().Get("ttl"))
+	if err != nil {
+		log.Errorf("Invalid expiration: %v", err)
+		http.Error(w, "Invalid expiration: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var opts domain.ShortenOptions
+	if dedupe := r.URL.Query().Get("dedupe"); dedupe != "" {
+		opts.Deduplicate, err = strconv.ParseBool(dedupe)
+		if err != nil {
+			http.Error(w, "Dedupe must be a boolean", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var redirectCode int
+	if code := r.URL.Query().Get("redirect_code"); code != "" {
+		redirectCode, err = strconv.Atoi(code)
+		if err != nil {
+			http.Error(w, "Redirect code must be a number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var forwardQuery bool
+	if forward := r.URL.Query().Get("forward_query"); forward != "" {
+		forwardQuery, err = strconv.ParseBool(forward)
+		if err != nil {
+			http.Error(w, "Forward query must be a boolean", http.StatusBadRequest)
+			return
+		}
+	}
+
 	log.Infof("Request to shorten made by user: %v", r.Context().Value(currentUserKey))
 	userData := r.Context().Value(currentUserKey)
-	user, _ := userData.(*domain.User)
+	user, _ := userData.(*domain.Principal)
 	if user == nil {
 		log.Errorf("User is required to perform this action")
 		http.Error(w, "User is required to perform this action", http.StatusBadRequest)
 		return
 	}
 
-	short, err := sh.shortenerService.Shorten(r.Context(), original, user)
+	link := domain.NewURL(alias, original, expiresAt)
+	link.RedirectCode = redirectCode
+	link.ForwardQuery = forwardQuery
+
+	short, err := sh.shortenerService.Shorten(r.Context(), link, user, opts)
 	if err != nil {
 		log.Errorf("Failed to shorten URL: %v", err)
-		http.Error(w, "Failed to shorten URL: "+err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, domain.ErrInvalidURL), errors.Is(err, domain.ErrInvalidAlias),
+			errors.Is(err, domain.ErrInvalidExpiration), errors.Is(err, domain.ErrInvalidRedirectCode):
+			http.Error(w, "Failed to shorten URL: "+err.Error(), http.StatusBadRequest)
+		case errors.Is(err, domain.ErrShortURLExists):
+			http.Error(w, "Alias is already taken", http.StatusConflict)
+		case errors.Is(err, domain.ErrLinkQuotaExceeded):
+			http.Error(w, "No links remaining", http.StatusForbidden)
+		case errors.Is(err, domain.ErrForbidden):
+			http.Error(w, "Failed to shorten URL: "+err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, "Failed to shorten URL: "+err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -93,7 +223,7 @@ func (sh *ShortenerHandler) Shorten(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// Remove handles remove requests by removing the short URL from the cache.
+// Remove handles remove requests by removing the short URL. Only the owner of the URL or an admin can remove it.
 func (sh *ShortenerHandler) Remove(w http.ResponseWriter, r *http.Request) {
 	short := r.URL.Query().Get("url")
 	log.Infof("Got request to remove: %s", short)
@@ -103,13 +233,145 @@ func (sh *ShortenerHandler) Remove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := sh.shortenerService.Remove(r.Context(), short)
+	userData := r.Context().Value(currentUserKey)
+	user, _ := userData.(*domain.Principal)
+	if user == nil {
+		log.Errorf("User is required to perform this action")
+		http.Error(w, "User is required to perform this action", http.StatusBadRequest)
+		return
+	}
+
+	err := sh.shortenerService.Remove(r.Context(), short, user)
 	if err != nil {
 		log.Errorf("Failed to remove URL: %v", err)
-		http.Error(w, "Failed to remove URL: "+err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, domain.ErrShortURLNotFound):
+			http.Error(w, "Short URL not found", http.StatusNotFound)
+		case errors.Is(err, domain.ErrForbidden):
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		default:
+			http.Error(w, "Failed to remove URL: "+err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
 	log.Infof("Successfully removed: %s", short)
 	w.WriteHeader(http.StatusOK)
 }
+
+// linkResponse is a JSON representation of a shortened link.
+type linkResponse struct {
+	ShortURL     string     `json:"short_url"`
+	OriginalURL  string     `json:"original_url"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	RedirectCode int        `json:"redirect_code,omitempty"`
+	ForwardQuery bool       `json:"forward_query,omitempty"`
+}
+
+// linksResponse is a JSON representation of a page of shortened links.
+type linksResponse struct {
+	Links      []linkResponse `json:"links"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// List handles requests for the links created by the current user. It supports cursor pagination
+// (cursor, limit), substring search on the original URL (search) and sorting by creation date
+// (sort=created_at for the oldest first or sort=-created_at for the newest first, which is the default).
+func (sh *ShortenerHandler) List(w http.ResponseWriter, r *http.Request) {
+	userData := r.Context().Value(currentUserKey)
+	user, _ := userData.(*domain.Principal)
+	if user == nil {
+		log.Errorf("User is required to perform this action")
+		http.Error(w, "User is required to perform this action", http.StatusBadRequest)
+		return
+	}
+
+	params := r.URL.Query()
+	query := domain.LinksQuery{
+		Owner:  user.Username,
+		Search: params.Get("search"),
+		Cursor: params.Get("cursor"),
+	}
+
+	switch params.Get("sort") {
+	case "", "-created_at":
+		query.Desc = true
+	case "created_at":
+		query.Desc = false
+	default:
+		http.Error(w, "Sort must be either created_at or -created_at", http.StatusBadRequest)
+		return
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		var err error
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit <= 0 {
+			http.Error(w, "Limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := sh.shortenerService.List(r.Context(), query)
+	if err != nil {
+		log.Errorf("Failed to list URLs: %v", err)
+		if errors.Is(err, domain.ErrInvalidCursor) {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+
+		http.Error(w, "Failed to list URLs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := linksResponse{
+		Links:      make([]linkResponse, 0, len(page.Links)),
+		NextCursor: page.NextCursor,
+	}
+	for _, link := range page.Links {
+		item := linkResponse{
+			ShortURL:     link.Short,
+			OriginalURL:  link.Original,
+			CreatedAt:    link.CreatedAt,
+			RedirectCode: link.RedirectCode,
+			ForwardQuery: link.ForwardQuery,
+		}
+		if !link.ExpiresAt.IsZero() {
+			expiresAt := link.ExpiresAt
+			item.ExpiresAt = &expiresAt
+		}
+		resp.Links = append(resp.Links, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorf("Failed to encode response: %v", err)
+	}
+}
+
+// parseExpiration converts either an absolute expiration time or a relative lifetime into an expiration time.
+// Zero time is returned if neither of them is specified.
+func parseExpiration(expiresAt, ttl string) (time.Time, error) {
+	switch {
+	case expiresAt != "" && ttl != "":
+		return time.Time{}, errors.New("only one of expires_at and ttl can be specified")
+	case expiresAt != "":
+		t, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("expires_at must be in RFC 3339 format: %w", err)
+		}
+		return t, nil
+	case ttl != "":
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("ttl must be a duration: %w", err)
+		}
+		if d <= 0 {
+			return time.Time{}, errors.New("ttl must be positive")
+		}
+		return time.Now().Add(d), nil
+	default:
+		return time.Time{}, nil
+	}
+}
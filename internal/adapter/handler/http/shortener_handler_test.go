@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -11,12 +12,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
+var testRedirectConfig = RedirectConfig{DefaultCode: http.StatusPermanentRedirect}
+
 func TestShortenerHandler_Redirect(t *testing.T) {
 	shortenerServiceMock := new(mocks.ShortenerService)
 	eventProducerMock := new(mocks.EventProducer)
-	handler := NewShortenerHandler(shortenerServiceMock, eventProducerMock)
+	handler := NewShortenerHandler(shortenerServiceMock, eventProducerMock, testRedirectConfig)
 
 	t.Run("successful redirect", func(t *testing.T) {
 		req, err := http.NewRequest(http.MethodGet, "/shortUrl", nil)
@@ -27,8 +31,10 @@ func TestShortenerHandler_Redirect(t *testing.T) {
 			"Resolve",
 			mock.Anything,
 			"shortUrl",
-		).Return("http://original.url", nil).Once()
-		eventProducerMock.On("Produce", mock.Anything).Return(nil)
+		).Return(&domain.URL{Short: "shortUrl", Original: "http://original.url"}, nil).Once()
+		eventProducerMock.On("Produce", mock.MatchedBy(func(event *domain.Event) bool {
+			return event.ID != "" && event.ShortURL == "shortUrl"
+		})).Return(nil).Once()
 
 		handler.Redirect(rr, req)
 
@@ -57,7 +63,7 @@ func TestShortenerHandler_Redirect(t *testing.T) {
 			"Resolve",
 			mock.Anything,
 			"shortUrl",
-		).Return("", errors.New("resolve error"))
+		).Return(nil, errors.New("resolve error")).Once()
 
 		handler.Redirect(rr, req)
 
@@ -65,40 +71,124 @@ func TestShortenerHandler_Redirect(t *testing.T) {
 		shortenerServiceMock.AssertCalled(t, "Resolve", mock.Anything, "shortUrl")
 	})
 
-	t.Run("produce event error", func(t *testing.T) {
+	t.Run("expired short URL", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/expiredUrl", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		shortenerServiceMock.On(
+			"Resolve",
+			mock.Anything,
+			"expiredUrl",
+		).Return(nil, domain.ErrURLExpired).Once()
+
+		handler.Redirect(rr, req)
+
+		assert.Equal(t, http.StatusGone, rr.Code)
+	})
+
+	t.Run("produce event error does not fail redirect", func(t *testing.T) {
 		req, err := http.NewRequest(http.MethodGet, "/shortUrl", nil)
 		require.NoError(t, err)
 		rr := httptest.NewRecorder()
 
-		shortenerServiceMock.On("Resolve", mock.Anything, "shortUrl").Return("http://original.url", nil)
-		eventProducerMock.On("Produce", mock.Anything).Return(errors.New("produce error"))
+		shortenerServiceMock.On("Resolve", mock.Anything, "shortUrl").Return(
+			&domain.URL{Short: "shortUrl", Original: "http://original.url"},
+			nil,
+		).Once()
+		eventProducerMock.On("Produce", mock.Anything).Return(domain.ErrEventDropped).Once()
 
 		handler.Redirect(rr, req)
 
-		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Equal(t, http.StatusPermanentRedirect, rr.Code)
+		assert.Equal(t, "http://original.url", rr.Header().Get("Location"))
 		shortenerServiceMock.AssertCalled(t, "Resolve", mock.Anything, "shortUrl")
 		eventProducerMock.AssertCalled(t, "Produce", mock.Anything)
 	})
 }
 
+func TestShortenerHandler_RedirectOptions(t *testing.T) {
+	shortenerServiceMock := new(mocks.ShortenerService)
+	eventProducerMock := new(mocks.EventProducer)
+	eventProducerMock.On("Produce", mock.Anything).Return(nil)
+
+	t.Run("link redirect code", func(t *testing.T) {
+		handler := NewShortenerHandler(shortenerServiceMock, eventProducerMock, testRedirectConfig)
+		req, err := http.NewRequest(http.MethodGet, "/temporary?utm_source=mail", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		shortenerServiceMock.On("Resolve", mock.Anything, "temporary").Return(
+			&domain.URL{Short: "temporary", Original: "http://original.url", RedirectCode: http.StatusFound},
+			nil,
+		).Once()
+
+		handler.Redirect(rr, req)
+
+		assert.Equal(t, http.StatusFound, rr.Code)
+		assert.Equal(t, "http://original.url", rr.Header().Get("Location"))
+	})
+
+	t.Run("link forwards query", func(t *testing.T) {
+		handler := NewShortenerHandler(shortenerServiceMock, eventProducerMock, testRedirectConfig)
+		req, err := http.NewRequest(http.MethodGet, "/forwarding?utm_source=mail&id=2", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		shortenerServiceMock.On("Resolve", mock.Anything, "forwarding").Return(
+			&domain.URL{Short: "forwarding", Original: "http://original.url/page?id=1#top", ForwardQuery: true},
+			nil,
+		).Once()
+
+		handler.Redirect(rr, req)
+
+		assert.Equal(t, http.StatusPermanentRedirect, rr.Code)
+		assert.Equal(t, "http://original.url/page?id=1&utm_source=mail#top", rr.Header().Get("Location"))
+	})
+
+	t.Run("global defaults", func(t *testing.T) {
+		handler := NewShortenerHandler(
+			shortenerServiceMock,
+			eventProducerMock,
+			RedirectConfig{DefaultCode: http.StatusTemporaryRedirect, ForwardQuery: true},
+		)
+		req, err := http.NewRequest(http.MethodGet, "/shortUrl?utm_source=mail", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		shortenerServiceMock.On("Resolve", mock.Anything, "shortUrl").Return(
+			&domain.URL{Short: "shortUrl", Original: "http://original.url"},
+			nil,
+		).Once()
+
+		handler.Redirect(rr, req)
+
+		assert.Equal(t, http.StatusTemporaryRedirect, rr.Code)
+		assert.Equal(t, "http://original.url?utm_source=mail", rr.Header().Get("Location"))
+	})
+
+	shortenerServiceMock.AssertExpectations(t)
+}
+
 func TestShortenerHandler_Shorten(t *testing.T) {
 	shortenerServiceMock := new(mocks.ShortenerService)
 	eventProducerMock := new(mocks.EventProducer)
-	handler := NewShortenerHandler(shortenerServiceMock, eventProducerMock)
+	handler := NewShortenerHandler(shortenerServiceMock, eventProducerMock, testRedirectConfig)
 
 	t.Run("successful shorten", func(t *testing.T) {
 		req, err := http.NewRequest(http.MethodGet, "/shorten?url=http://original.url", nil)
 		require.NoError(t, err)
 		rr := httptest.NewRecorder()
 
-		ctx := context.WithValue(req.Context(), currentUserKey, &domain.User{Username: "user1"})
+		ctx := context.WithValue(req.Context(), currentUserKey, &domain.Principal{Username: "user1"})
 		req = req.WithContext(ctx)
 
 		shortenerServiceMock.On(
 			"Shorten",
 			mock.Anything,
-			"http://original.url",
-			&domain.User{Username: "user1"},
+			domain.NewURL("", "http://original.url", time.Time{}),
+			&domain.Principal{Username: "user1"},
+			domain.ShortenOptions{},
 		).Return("shortUrl", nil).Once()
 
 		handler.Shorten(rr, req)
@@ -109,8 +199,9 @@ func TestShortenerHandler_Shorten(t *testing.T) {
 			t,
 			"Shorten",
 			mock.Anything,
-			"http://original.url",
-			&domain.User{Username: "user1"},
+			domain.NewURL("", "http://original.url", time.Time{}),
+			&domain.Principal{Username: "user1"},
+			domain.ShortenOptions{},
 		)
 	})
 
@@ -139,14 +230,15 @@ func TestShortenerHandler_Shorten(t *testing.T) {
 		require.NoError(t, err)
 		rr := httptest.NewRecorder()
 
-		ctx := context.WithValue(req.Context(), currentUserKey, &domain.User{Username: "user1"})
+		ctx := context.WithValue(req.Context(), currentUserKey, &domain.Principal{Username: "user1"})
 		req = req.WithContext(ctx)
 
 		shortenerServiceMock.On(
 			"Shorten",
 			mock.Anything,
-			"http://original.url",
-			&domain.User{Username: "user1"},
+			domain.NewURL("", "http://original.url", time.Time{}),
+			&domain.Principal{Username: "user1"},
+			domain.ShortenOptions{},
 		).Return("", errors.New("shorten error"))
 
 		handler.Shorten(rr, req)
@@ -156,28 +248,338 @@ func TestShortenerHandler_Shorten(t *testing.T) {
 			t,
 			"Shorten",
 			mock.Anything,
-			"http://original.url",
-			&domain.User{Username: "user1"},
+			domain.NewURL("", "http://original.url", time.Time{}),
+			&domain.Principal{Username: "user1"},
+			domain.ShortenOptions{},
 		)
 	})
 }
 
+func TestShortenerHandler_ShortenDeduplicate(t *testing.T) {
+	shortenerServiceMock := new(mocks.ShortenerService)
+	eventProducerMock := new(mocks.EventProducer)
+	handler := NewShortenerHandler(shortenerServiceMock, eventProducerMock, testRedirectConfig)
+	user := &domain.Principal{Username: "user1"}
+
+	t.Run("dedupe requested", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/shorten?url=http://original.url&dedupe=true", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		shortenerServiceMock.On(
+			"Shorten",
+			mock.Anything,
+			domain.NewURL("", "http://original.url", time.Time{}),
+			user,
+			domain.ShortenOptions{Deduplicate: true},
+		).Return("existing", nil).Once()
+
+		handler.Shorten(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "http://"+req.Host+"/existing", rr.Body.String())
+	})
+
+	t.Run("invalid dedupe", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/shorten?url=http://original.url&dedupe=maybe", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		handler.Shorten(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	shortenerServiceMock.AssertExpectations(t)
+}
+
+func TestShortenerHandler_ShortenInvalidURL(t *testing.T) {
+	shortenerServiceMock := new(mocks.ShortenerService)
+	eventProducerMock := new(mocks.EventProducer)
+	handler := NewShortenerHandler(shortenerServiceMock, eventProducerMock, testRedirectConfig)
+	user := &domain.Principal{Username: "user1"}
+
+	req, err := http.NewRequest(http.MethodPost, "/shorten?url=example.com", nil)
+	require.NoError(t, err)
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+	rr := httptest.NewRecorder()
+
+	shortenerServiceMock.On(
+		"Shorten",
+		mock.Anything,
+		domain.NewURL("", "example.com", time.Time{}),
+		user,
+		domain.ShortenOptions{},
+	).Return("", fmt.Errorf("%w: must be absolute", domain.ErrInvalidURL)).Once()
+
+	handler.Shorten(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "must be absolute")
+	shortenerServiceMock.AssertExpectations(t)
+}
+
+func TestShortenerHandler_ShortenQuotaExceeded(t *testing.T) {
+	shortenerServiceMock := new(mocks.ShortenerService)
+	eventProducerMock := new(mocks.EventProducer)
+	handler := NewShortenerHandler(shortenerServiceMock, eventProducerMock, testRedirectConfig)
+	user := &domain.Principal{Username: "user1"}
+
+	req, err := http.NewRequest(http.MethodPost, "/shorten?url=http://original.url", nil)
+	require.NoError(t, err)
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+	rr := httptest.NewRecorder()
+
+	shortenerServiceMock.On(
+		"Shorten",
+		mock.Anything,
+		domain.NewURL("", "http://original.url", time.Time{}),
+		user,
+		domain.ShortenOptions{},
+	).Return("", domain.ErrLinkQuotaExceeded).Once()
+
+	handler.Shorten(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestShortenerHandler_ShortenWithAlias(t *testing.T) {
+	shortenerServiceMock := new(mocks.ShortenerService)
+	eventProducerMock := new(mocks.EventProducer)
+	handler := NewShortenerHandler(shortenerServiceMock, eventProducerMock, testRedirectConfig)
+	user := &domain.Principal{Username: "user1"}
+
+	t.Run("successful shorten with alias", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/shorten?url=http://original.url&alias=q3-report", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		shortenerServiceMock.On(
+			"Shorten",
+			mock.Anything,
+			domain.NewURL("q3-report", "http://original.url", time.Time{}),
+			user,
+			domain.ShortenOptions{},
+		).Return("q3-report", nil).Once()
+
+		handler.Shorten(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "http://"+req.Host+"/q3-report", rr.Body.String())
+	})
+
+	t.Run("invalid alias", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/shorten?url=http://original.url&alias=login", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		shortenerServiceMock.On(
+			"Shorten",
+			mock.Anything,
+			domain.NewURL("login", "http://original.url", time.Time{}),
+			user,
+			domain.ShortenOptions{},
+		).Return("", domain.ErrInvalidAlias).Once()
+
+		handler.Shorten(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("alias is already taken", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/shorten?url=http://original.url&alias=taken", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		shortenerServiceMock.On(
+			"Shorten",
+			mock.Anything,
+			domain.NewURL("taken", "http://original.url", time.Time{}),
+			user,
+			domain.ShortenOptions{},
+		).Return("", domain.ErrShortURLExists).Once()
+
+		handler.Shorten(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	shortenerServiceMock.AssertExpectations(t)
+}
+
+func TestShortenerHandler_ShortenWithExpiration(t *testing.T) {
+	shortenerServiceMock := new(mocks.ShortenerService)
+	eventProducerMock := new(mocks.EventProducer)
+	handler := NewShortenerHandler(shortenerServiceMock, eventProducerMock, testRedirectConfig)
+	user := &domain.Principal{Username: "user1"}
+
+	t.Run("successful shorten with expires_at", func(t *testing.T) {
+		req, err := http.NewRequest(
+			http.MethodPost,
+			"/shorten?url=http://original.url&expires_at=2030-01-02T15:04:05Z",
+			nil,
+		)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		expiresAt := time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC)
+		shortenerServiceMock.On(
+			"Shorten",
+			mock.Anything,
+			domain.NewURL("", "http://original.url", expiresAt),
+			user,
+			domain.ShortenOptions{},
+		).Return("shortUrl", nil).Once()
+
+		handler.Shorten(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("successful shorten with ttl", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/shorten?url=http://original.url&ttl=1h", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		shortenerServiceMock.On(
+			"Shorten",
+			mock.Anything,
+			mock.MatchedBy(func(url *domain.URL) bool {
+				return url.Original == "http://original.url" &&
+					time.Until(url.ExpiresAt) > 59*time.Minute &&
+					time.Until(url.ExpiresAt) <= time.Hour
+			}),
+			user,
+			domain.ShortenOptions{},
+		).Return("shortUrl", nil).Once()
+
+		handler.Shorten(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("both expires_at and ttl", func(t *testing.T) {
+		req, err := http.NewRequest(
+			http.MethodPost,
+			"/shorten?url=http://original.url&expires_at=2030-01-02T15:04:05Z&ttl=1h",
+			nil,
+		)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		handler.Shorten(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("invalid ttl", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/shorten?url=http://original.url&ttl=-1h", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		handler.Shorten(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	shortenerServiceMock.AssertExpectations(t)
+}
+
+func TestShortenerHandler_ShortenRedirectOptions(t *testing.T) {
+	shortenerServiceMock := new(mocks.ShortenerService)
+	eventProducerMock := new(mocks.EventProducer)
+	handler := NewShortenerHandler(shortenerServiceMock, eventProducerMock, testRedirectConfig)
+	user := &domain.Principal{Username: "user1"}
+
+	t.Run("redirect options are passed to the service", func(t *testing.T) {
+		req, err := http.NewRequest(
+			http.MethodPost,
+			"/shorten?url=http://original.url&redirect_code=307&forward_query=true",
+			nil,
+		)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		link := domain.NewURL("", "http://original.url", time.Time{})
+		link.RedirectCode = http.StatusTemporaryRedirect
+		link.ForwardQuery = true
+		shortenerServiceMock.On("Shorten", mock.Anything, link, user, domain.ShortenOptions{}).
+			Return("shortUrl", nil).Once()
+
+		handler.Shorten(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("invalid redirect code format", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/shorten?url=http://original.url&redirect_code=found", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		handler.Shorten(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("invalid forward query", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/shorten?url=http://original.url&forward_query=maybe", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		handler.Shorten(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("unsupported redirect code", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/shorten?url=http://original.url&redirect_code=200", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		link := domain.NewURL("", "http://original.url", time.Time{})
+		link.RedirectCode = http.StatusOK
+		shortenerServiceMock.On("Shorten", mock.Anything, link, user, domain.ShortenOptions{}).
+			Return("", domain.ErrInvalidRedirectCode).Once()
+
+		handler.Shorten(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	shortenerServiceMock.AssertExpectations(t)
+}
+
 func TestShortenerHandler_Remove(t *testing.T) {
 	shortenerServiceMock := new(mocks.ShortenerService)
 	eventProducerMock := new(mocks.EventProducer)
-	handler := NewShortenerHandler(shortenerServiceMock, eventProducerMock)
+	handler := NewShortenerHandler(shortenerServiceMock, eventProducerMock, testRedirectConfig)
+	user := &domain.Principal{Username: "user1"}
 
 	t.Run("successful remove", func(t *testing.T) {
 		req, err := http.NewRequest(http.MethodGet, "/remove?url=shortUrl", nil)
 		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
 		rr := httptest.NewRecorder()
 
-		shortenerServiceMock.On("Remove", mock.Anything, "shortUrl").Return(nil).Once()
+		shortenerServiceMock.On("Remove", mock.Anything, "shortUrl", user).Return(nil).Once()
 
 		handler.Remove(rr, req)
 
 		assert.Equal(t, http.StatusOK, rr.Code)
-		shortenerServiceMock.AssertCalled(t, "Remove", mock.Anything, "shortUrl")
+		shortenerServiceMock.AssertCalled(t, "Remove", mock.Anything, "shortUrl", user)
 	})
 
 	t.Run("missing short URL", func(t *testing.T) {
@@ -190,16 +592,162 @@ func TestShortenerHandler_Remove(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 	})
 
+	t.Run("missing user", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/remove?url=shortUrl", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		handler.Remove(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("not the owner", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/remove?url=foreignUrl", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		shortenerServiceMock.On("Remove", mock.Anything, "foreignUrl", user).Return(domain.ErrForbidden).Once()
+
+		handler.Remove(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("short URL not found", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/remove?url=missingUrl", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		shortenerServiceMock.On("Remove", mock.Anything, "missingUrl", user).Return(domain.ErrShortURLNotFound).Once()
+
+		handler.Remove(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
 	t.Run("remove error", func(t *testing.T) {
 		req, err := http.NewRequest(http.MethodGet, "/remove?url=shortUrl", nil)
 		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
 		rr := httptest.NewRecorder()
 
-		shortenerServiceMock.On("Remove", mock.Anything, "shortUrl").Return(errors.New("remove error"))
+		shortenerServiceMock.On("Remove", mock.Anything, "shortUrl", user).Return(errors.New("remove error"))
 
 		handler.Remove(rr, req)
 
 		assert.Equal(t, http.StatusInternalServerError, rr.Code)
-		shortenerServiceMock.AssertCalled(t, "Remove", mock.Anything, "shortUrl")
+		shortenerServiceMock.AssertCalled(t, "Remove", mock.Anything, "shortUrl", user)
+	})
+}
+
+func TestShortenerHandler_List(t *testing.T) {
+	shortenerServiceMock := new(mocks.ShortenerService)
+	eventProducerMock := new(mocks.EventProducer)
+	handler := NewShortenerHandler(shortenerServiceMock, eventProducerMock, testRedirectConfig)
+	user := &domain.Principal{Username: "user1"}
+
+	t.Run("successful list", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/links?search=report&sort=created_at&limit=2&cursor=abc", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		expiresAt := createdAt.Add(time.Hour)
+		shortenerServiceMock.On("List", mock.Anything, domain.LinksQuery{
+			Owner:  "user1",
+			Search: "report",
+			Cursor: "abc",
+			Limit:  2,
+		}).Return(&domain.LinksPage{
+			Links: []*domain.URL{
+				{Short: "q3-report", Original: "http://original.url/q3", CreatedAt: createdAt},
+				{Short: "q4-report", Original: "http://original.url/q4", CreatedAt: createdAt, ExpiresAt: expiresAt},
+			},
+			NextCursor: "next",
+		}, nil).Once()
+
+		handler.List(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{
+			"links": [
+				{"short_url": "q3-report", "original_url": "http://original.url/q3", "created_at": "2024-01-02T03:04:05Z"},
+				{
+					"short_url": "q4-report",
+					"original_url": "http://original.url/q4",
+					"created_at": "2024-01-02T03:04:05Z",
+					"expires_at": "2024-01-02T04:04:05Z"
+				}
+			],
+			"next_cursor": "next"
+		}`, rr.Body.String())
 	})
+
+	t.Run("newest first by default", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/links", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		shortenerServiceMock.On("List", mock.Anything, domain.LinksQuery{
+			Owner: "user1",
+			Desc:  true,
+		}).Return(&domain.LinksPage{}, nil).Once()
+
+		handler.List(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"links": []}`, rr.Body.String())
+	})
+
+	t.Run("invalid sort", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/links?sort=original_url", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		handler.List(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/links?limit=-1", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		handler.List(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/links?cursor=bad", nil)
+		require.NoError(t, err)
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+		rr := httptest.NewRecorder()
+
+		shortenerServiceMock.On("List", mock.Anything, mock.Anything).Return(nil, domain.ErrInvalidCursor).Once()
+
+		handler.List(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/links", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		handler.List(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	shortenerServiceMock.AssertExpectations(t)
 }
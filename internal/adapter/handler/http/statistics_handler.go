@@ -0,0 +1,220 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"min/internal/core/domain"
+	"min/internal/core/port"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// StatisticsHandler provides methods for handling requests for the click statistics of short URLs.
+type StatisticsHandler struct {
+	statisticsService port.StatisticsService
+}
+
+// NewStatisticsHandler creates a new instance of StatisticsHandler.
+func NewStatisticsHandler(statisticsService port.StatisticsService) *StatisticsHandler {
+	return &StatisticsHandler{statisticsService: statisticsService}
+}
+
+// summaryResponse is a JSON representation of the click summary.
+type summaryResponse struct {
+	ShortURL       string `json:"short_url"`
+	Clicks         int64  `json:"clicks"`
+	UniqueVisitors int64  `json:"unique_visitors"`
+}
+
+// bucketResponse is a JSON representation of the number of clicks in a time bucket.
+type bucketResponse struct {
+	Time   time.Time `json:"time"`
+	Clicks int64     `json:"clicks"`
+}
+
+// timeSeriesResponse is a JSON representation of the click time series.
+type timeSeriesResponse struct {
+	ShortURL string           `json:"short_url"`
+	Interval domain.Interval  `json:"interval"`
+	Buckets  []bucketResponse `json:"buckets"`
+}
+
+// topValueResponse is a JSON representation of the number of clicks with the given attribute value.
+type topValueResponse struct {
+	Value  string `json:"value"`
+	Clicks int64  `json:"clicks"`
+}
+
+// topResponse is a JSON representation of the top attribute values.
+type topResponse struct {
+	ShortURL string             `json:"short_url"`
+	Values   []topValueResponse `json:"values"`
+}
+
+// Summary handles requests for the total number of clicks and unique visitors of the short URL.
+func (sh *StatisticsHandler) Summary(w http.ResponseWriter, r *http.Request) {
+	query, ok := parseStatisticsQuery(w, r)
+	if !ok {
+		return
+	}
+
+	summary, err := sh.statisticsService.GetSummary(r.Context(), query)
+	if err != nil {
+		writeStatisticsError(w, err)
+		return
+	}
+
+	writeJSON(w, summaryResponse{
+		ShortURL:       query.ShortURL,
+		Clicks:         summary.Clicks,
+		UniqueVisitors: summary.UniqueVisitors,
+	})
+}
+
+// TimeSeries handles requests for the number of clicks on the short URL bucketed by hour or day.
+func (sh *StatisticsHandler) TimeSeries(w http.ResponseWriter, r *http.Request) {
+	query, ok := parseStatisticsQuery(w, r)
+	if !ok {
+		return
+	}
+
+	buckets, err := sh.statisticsService.GetTimeSeries(r.Context(), query)
+	if err != nil {
+		writeStatisticsError(w, err)
+		return
+	}
+
+	resp := timeSeriesResponse{
+		ShortURL: query.ShortURL,
+		Interval: query.Interval,
+		Buckets:  make([]bucketResponse, 0, len(buckets)),
+	}
+	if resp.Interval == "" {
+		resp.Interval = domain.DAY
+	}
+	for _, bucket := range buckets {
+		resp.Buckets = append(resp.Buckets, bucketResponse{Time: bucket.Time, Clicks: bucket.Clicks})
+	}
+
+	writeJSON(w, resp)
+}
+
+// TopReferrers handles requests for the referrers with the most clicks on the short URL.
+func (sh *StatisticsHandler) TopReferrers(w http.ResponseWriter, r *http.Request) {
+	sh.top(w, r, sh.statisticsService.GetTopReferrers)
+}
+
+// TopUserAgents handles requests for the user agents with the most clicks on the short URL.
+func (sh *StatisticsHandler) TopUserAgents(w http.ResponseWriter, r *http.Request) {
+	sh.top(w, r, sh.statisticsService.GetTopUserAgents)
+}
+
+func (sh *StatisticsHandler) top(
+	w http.ResponseWriter,
+	r *http.Request,
+	get func(ctx context.Context, query domain.StatisticsQuery) ([]domain.TopValue, error),
+) {
+	query, ok := parseStatisticsQuery(w, r)
+	if !ok {
+		return
+	}
+
+	values, err := get(r.Context(), query)
+	if err != nil {
+		writeStatisticsError(w, err)
+		return
+	}
+
+	resp := topResponse{
+		ShortURL: query.ShortURL,
+		Values:   make([]topValueResponse, 0, len(values)),
+	}
+	for _, value := range values {
+		resp.Values = append(resp.Values, topValueResponse{Value: value.Value, Clicks: value.Clicks})
+	}
+
+	writeJSON(w, resp)
+}
+
+// parseStatisticsQuery builds the statistics query from the request. The statistics of the links are only
+// available to their owners, admins can see the statistics of any link.
+// If the query can't be built, an error is written to the response and false is returned.
+func parseStatisticsQuery(w http.ResponseWriter, r *http.Request) (domain.StatisticsQuery, bool) {
+	userData := r.Context().Value(currentUserKey)
+	user, _ := userData.(*domain.Principal)
+	if user == nil {
+		log.Errorf("User is required to perform this action")
+		http.Error(w, "User is required to perform this action", http.StatusBadRequest)
+		return domain.StatisticsQuery{}, false
+	}
+
+	params := r.URL.Query()
+	query := domain.StatisticsQuery{
+		ShortURL: r.PathValue("short"),
+		Interval: domain.Interval(params.Get("interval")),
+	}
+	if user.Role != domain.ADMIN {
+		query.Owner = user.Username
+	}
+
+	var err error
+	if query.From, err = parseTime(params.Get("from")); err != nil {
+		http.Error(w, "From must be in RFC 3339 format", http.StatusBadRequest)
+		return domain.StatisticsQuery{}, false
+	}
+	if query.To, err = parseTime(params.Get("to")); err != nil {
+		http.Error(w, "To must be in RFC 3339 format", http.StatusBadRequest)
+		return domain.StatisticsQuery{}, false
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit <= 0 {
+			http.Error(w, "Limit must be a positive number", http.StatusBadRequest)
+			return domain.StatisticsQuery{}, false
+		}
+	}
+
+	return query, true
+}
+
+// parseTime parses the time in RFC 3339 format. Zero time is returned for an empty string.
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse time: %w", err)
+	}
+
+	return t, nil
+}
+
+// writeStatisticsError writes the statistics service error to the response with the matching status code.
+func writeStatisticsError(w http.ResponseWriter, err error) {
+	log.Errorf("Failed to get statistics: %v", err)
+	switch {
+	case errors.Is(err, domain.ErrInvalidStatisticsQuery):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrShortURLNotFound):
+		http.Error(w, "Short URL not found", http.StatusNotFound)
+	case errors.Is(err, domain.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, "Failed to get statistics: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// writeJSON writes the value to the response as JSON.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("Failed to encode response: %v", err)
+	}
+}
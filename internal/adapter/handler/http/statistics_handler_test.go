@@ -0,0 +1,207 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"min/internal/core/domain"
+	"min/internal/mocks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newStatisticsRequest creates a request routed through the statistics patterns, so that path values are set.
+func newStatisticsRequest(
+	t *testing.T,
+	handler *StatisticsHandler,
+	target string,
+	user *domain.Principal,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /stats/{short}", handler.Summary)
+	mux.HandleFunc("GET /stats/{short}/timeseries", handler.TimeSeries)
+	mux.HandleFunc("GET /stats/{short}/referrers", handler.TopReferrers)
+	mux.HandleFunc("GET /stats/{short}/user-agents", handler.TopUserAgents)
+
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	require.NoError(t, err)
+	if user != nil {
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+	}
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestStatisticsHandler_Summary(t *testing.T) {
+	statisticsServiceMock := new(mocks.StatisticsService)
+	handler := NewStatisticsHandler(statisticsServiceMock)
+
+	t.Run("owner summary", func(t *testing.T) {
+		statisticsServiceMock.On("GetSummary", mock.Anything, domain.StatisticsQuery{
+			ShortURL: "shortUrl",
+			Owner:    "user1",
+		}).Return(&domain.ClickSummary{Clicks: 10, UniqueVisitors: 3}, nil).Once()
+
+		rr := newStatisticsRequest(t, handler, "/stats/shortUrl", &domain.Principal{Username: "user1", Role: domain.USER})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"short_url": "shortUrl", "clicks": 10, "unique_visitors": 3}`, rr.Body.String())
+	})
+
+	t.Run("admin summary is not scoped to owner", func(t *testing.T) {
+		statisticsServiceMock.On("GetSummary", mock.Anything, domain.StatisticsQuery{
+			ShortURL: "shortUrl",
+		}).Return(&domain.ClickSummary{Clicks: 1, UniqueVisitors: 1}, nil).Once()
+
+		rr := newStatisticsRequest(t, handler, "/stats/shortUrl", &domain.Principal{Username: "admin", Role: domain.ADMIN})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		rr := newStatisticsRequest(t, handler, "/stats/shortUrl", nil)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("invalid time range", func(t *testing.T) {
+		rr := newStatisticsRequest(t, handler, "/stats/shortUrl?from=yesterday", &domain.Principal{Username: "user1"})
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("link of another user", func(t *testing.T) {
+		statisticsServiceMock.On("GetSummary", mock.Anything, domain.StatisticsQuery{
+			ShortURL: "foreignUrl",
+			Owner:    "user1",
+		}).Return(nil, domain.ErrForbidden).Once()
+
+		rr := newStatisticsRequest(t, handler, "/stats/foreignUrl", &domain.Principal{Username: "user1"})
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("unknown link", func(t *testing.T) {
+		statisticsServiceMock.On("GetSummary", mock.Anything, domain.StatisticsQuery{
+			ShortURL: "unknownUrl",
+			Owner:    "user1",
+		}).Return(nil, domain.ErrShortURLNotFound).Once()
+
+		rr := newStatisticsRequest(t, handler, "/stats/unknownUrl", &domain.Principal{Username: "user1"})
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		statisticsServiceMock.On("GetSummary", mock.Anything, domain.StatisticsQuery{
+			ShortURL: "brokenUrl",
+			Owner:    "user1",
+		}).Return(nil, errors.New("clickhouse error")).Once()
+
+		rr := newStatisticsRequest(t, handler, "/stats/brokenUrl", &domain.Principal{Username: "user1"})
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestStatisticsHandler_TimeSeries(t *testing.T) {
+	statisticsServiceMock := new(mocks.StatisticsService)
+	handler := NewStatisticsHandler(statisticsServiceMock)
+	user := &domain.Principal{Username: "user1"}
+
+	t.Run("hourly time series", func(t *testing.T) {
+		from := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		to := from.Add(2 * time.Hour)
+		statisticsServiceMock.On("GetTimeSeries", mock.Anything, domain.StatisticsQuery{
+			ShortURL: "shortUrl",
+			Owner:    "user1",
+			From:     from,
+			To:       to,
+			Interval: domain.HOUR,
+		}).Return([]domain.ClickBucket{
+			{Time: from, Clicks: 2},
+			{Time: from.Add(time.Hour), Clicks: 5},
+		}, nil).Once()
+
+		rr := newStatisticsRequest(
+			t,
+			handler,
+			"/stats/shortUrl/timeseries?interval=hour&from=2024-01-02T00:00:00Z&to=2024-01-02T02:00:00Z",
+			user,
+		)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{
+			"short_url": "shortUrl",
+			"interval": "hour",
+			"buckets": [
+				{"time": "2024-01-02T00:00:00Z", "clicks": 2},
+				{"time": "2024-01-02T01:00:00Z", "clicks": 5}
+			]
+		}`, rr.Body.String())
+	})
+
+	t.Run("invalid interval", func(t *testing.T) {
+		statisticsServiceMock.On("GetTimeSeries", mock.Anything, domain.StatisticsQuery{
+			ShortURL: "shortUrl",
+			Owner:    "user1",
+			Interval: "week",
+		}).Return(nil, domain.ErrInvalidStatisticsQuery).Once()
+
+		rr := newStatisticsRequest(t, handler, "/stats/shortUrl/timeseries?interval=week", user)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestStatisticsHandler_Top(t *testing.T) {
+	statisticsServiceMock := new(mocks.StatisticsService)
+	handler := NewStatisticsHandler(statisticsServiceMock)
+	user := &domain.Principal{Username: "user1"}
+
+	t.Run("top referrers", func(t *testing.T) {
+		statisticsServiceMock.On("GetTopReferrers", mock.Anything, domain.StatisticsQuery{
+			ShortURL: "shortUrl",
+			Owner:    "user1",
+			Limit:    2,
+		}).Return([]domain.TopValue{
+			{Value: "https://google.com", Clicks: 7},
+			{Value: "", Clicks: 3},
+		}, nil).Once()
+
+		rr := newStatisticsRequest(t, handler, "/stats/shortUrl/referrers?limit=2", user)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{
+			"short_url": "shortUrl",
+			"values": [{"value": "https://google.com", "clicks": 7}, {"value": "", "clicks": 3}]
+		}`, rr.Body.String())
+	})
+
+	t.Run("top user agents", func(t *testing.T) {
+		statisticsServiceMock.On("GetTopUserAgents", mock.Anything, domain.StatisticsQuery{
+			ShortURL: "shortUrl",
+			Owner:    "user1",
+		}).Return([]domain.TopValue{}, nil).Once()
+
+		rr := newStatisticsRequest(t, handler, "/stats/shortUrl/user-agents", user)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"short_url": "shortUrl", "values": []}`, rr.Body.String())
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		rr := newStatisticsRequest(t, handler, "/stats/shortUrl/referrers?limit=-1", user)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
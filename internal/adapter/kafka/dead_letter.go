@@ -0,0 +1,257 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/IBM/sarama"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+	"time"
+)
+
+// Headers of the dead-letter messages describing why and where the message failed.
+const (
+	headerError     = "dlq-error"
+	headerStage     = "dlq-stage"
+	headerTopic     = "dlq-original-topic"
+	headerPartition = "dlq-original-partition"
+	headerOffset    = "dlq-original-offset"
+	headerAttempts  = "dlq-attempts"
+	headerFailedAt  = "dlq-failed-at"
+)
+
+// Stages of the consumption at which the message failed.
+const (
+	stageDecode = "decode"
+	stageStore  = "store"
+)
+
+// deadLetterMessage creates the message for the dead-letter topic from the failed message.
+func deadLetterMessage(
+	topic string,
+	msg *sarama.ConsumerMessage,
+	cause error,
+	stage string,
+	attempts int,
+) *sarama.ProducerMessage {
+	headers := []sarama.RecordHeader{
+		{Key: []byte(headerError), Value: []byte(cause.Error())},
+		{Key: []byte(headerStage), Value: []byte(stage)},
+		{Key: []byte(headerTopic), Value: []byte(msg.Topic)},
+		{Key: []byte(headerPartition), Value: []byte(strconv.FormatInt(int64(msg.Partition), 10))},
+		{Key: []byte(headerOffset), Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		{Key: []byte(headerAttempts), Value: []byte(strconv.Itoa(attempts))},
+		{Key: []byte(headerFailedAt), Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	}
+	for _, header := range msg.Headers {
+		headers = append(headers, *header)
+	}
+
+	return &sarama.ProducerMessage{
+		Topic:   topic,
+		Key:     sarama.ByteEncoder(msg.Key),
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	}
+}
+
+// redriveMessage creates the message for the original topic from the dead-letter message. The headers added
+// by deadLetterMessage are removed, the original headers are kept.
+func redriveMessage(msg *sarama.ConsumerMessage) (*sarama.ProducerMessage, error) {
+	redriven := &sarama.ProducerMessage{
+		Key:   sarama.ByteEncoder(msg.Key),
+		Value: sarama.ByteEncoder(msg.Value),
+	}
+
+	for _, header := range msg.Headers {
+		switch string(header.Key) {
+		case headerTopic:
+			redriven.Topic = string(header.Value)
+		case headerError, headerStage, headerPartition, headerOffset, headerAttempts, headerFailedAt:
+		default:
+			redriven.Headers = append(redriven.Headers, *header)
+		}
+	}
+
+	if redriven.Topic == "" {
+		return nil, fmt.Errorf("message at offset %d has no %s header", msg.Offset, headerTopic)
+	}
+
+	return redriven, nil
+}
+
+// messageHeader returns the value of the message header, empty string is returned if there is no such header.
+func messageHeader(msg *sarama.ConsumerMessage, key string) string {
+	for _, header := range msg.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
+
+// DeadLetterRedriver moves the messages of the dead-letter topic back to their original topics.
+type DeadLetterRedriver struct {
+	client   sarama.Client
+	producer sarama.SyncProducer
+	topic    string
+	group    string
+}
+
+// NewDeadLetterRedriver creates a redriver of the dead-letter topic. The progress of re-driving is committed
+// as the offsets of the consumer group, so an interrupted re-drive continues where it stopped.
+func NewDeadLetterRedriver(brokers []string, topic, group string) (*DeadLetterRedriver, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Retry.Max = 5
+
+	client, err := sarama.NewClient(brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+
+	return &DeadLetterRedriver{
+		client:   client,
+		producer: producer,
+		topic:    topic,
+		group:    group,
+	}, nil
+}
+
+// Redrive publishes the messages that are in the dead-letter topic at the moment of the call to their
+// original topics. At most limit messages are re-driven unless limit is zero. With dryRun the messages are
+// only logged. It returns the number of re-driven messages.
+func (r *DeadLetterRedriver) Redrive(ctx context.Context, limit int, dryRun bool) (int, error) {
+	partitions, err := r.client.Partitions(r.topic)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get partitions of %s: %w", r.topic, err)
+	}
+
+	offsets, err := sarama.NewOffsetManagerFromClient(r.group, r.client)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create offset manager: %w", err)
+	}
+	defer offsets.Close()
+
+	consumer, err := sarama.NewConsumerFromClient(r.client)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	var redriven int
+	for _, partition := range partitions {
+		if limit > 0 && redriven >= limit {
+			break
+		}
+
+		n, err := r.redrivePartition(ctx, offsets, consumer, partition, limit-redriven, dryRun)
+		redriven += n
+		if err != nil {
+			return redriven, err
+		}
+	}
+
+	offsets.Commit()
+	return redriven, nil
+}
+
+// redrivePartition re-drives the messages of the partition up to its high watermark at the moment of the call.
+func (r *DeadLetterRedriver) redrivePartition(
+	ctx context.Context,
+	offsets sarama.OffsetManager,
+	consumer sarama.Consumer,
+	partition int32,
+	limit int,
+	dryRun bool,
+) (int, error) {
+	pom, err := offsets.ManagePartition(r.topic, partition)
+	if err != nil {
+		return 0, fmt.Errorf("failed to manage offsets of partition %d: %w", partition, err)
+	}
+	defer pom.Close()
+
+	next, _ := pom.NextOffset()
+	end, err := r.client.GetOffset(r.topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get offset of partition %d: %w", partition, err)
+	}
+
+	if next >= end {
+		return 0, nil
+	}
+
+	pc, err := consumer.ConsumePartition(r.topic, partition, next)
+	if err != nil {
+		return 0, fmt.Errorf("failed to consume partition %d: %w", partition, err)
+	}
+	defer pc.Close()
+
+	var redriven int
+	for {
+		select {
+		case <-ctx.Done():
+			return redriven, ctx.Err()
+		case consumerErr, ok := <-pc.Errors():
+			if !ok {
+				return redriven, errors.New("partition consumer is closed")
+			}
+			return redriven, consumerErr
+		case msg := <-pc.Messages():
+			if err := r.redrive(msg, dryRun); err != nil {
+				return redriven, err
+			}
+
+			if !dryRun {
+				pom.MarkOffset(msg.Offset+1, "")
+			}
+			redriven++
+
+			if msg.Offset+1 >= end || (limit > 0 && redriven >= limit) {
+				return redriven, nil
+			}
+		}
+	}
+}
+
+// redrive publishes the dead-letter message to its original topic.
+func (r *DeadLetterRedriver) redrive(msg *sarama.ConsumerMessage, dryRun bool) error {
+	redriven, err := redriveMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	log.Infof(
+		"Re-driving message %d/%d to %s, it failed at %s stage after %s attempts: %s",
+		msg.Partition,
+		msg.Offset,
+		redriven.Topic,
+		messageHeader(msg, headerStage),
+		messageHeader(msg, headerAttempts),
+		messageHeader(msg, headerError),
+	)
+	if dryRun {
+		return nil
+	}
+
+	if _, _, err := r.producer.SendMessage(redriven); err != nil {
+		return fmt.Errorf("failed to re-drive message %d/%d: %w", msg.Partition, msg.Offset, err)
+	}
+
+	return nil
+}
+
+// Close closes the connections to Kafka.
+func (r *DeadLetterRedriver) Close() error {
+	return errors.Join(r.producer.Close(), r.client.Close())
+}
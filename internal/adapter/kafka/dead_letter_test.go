@@ -0,0 +1,50 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// consumed converts the published message into the message as it is consumed from the topic.
+func consumed(t *testing.T, msg *sarama.ProducerMessage, offset int64) *sarama.ConsumerMessage {
+	t.Helper()
+
+	value, err := msg.Value.Encode()
+	require.NoError(t, err)
+
+	consumedMsg := &sarama.ConsumerMessage{Topic: msg.Topic, Offset: offset, Value: value}
+	for _, h := range msg.Headers {
+		consumedMsg.Headers = append(consumedMsg.Headers, &sarama.RecordHeader{Key: h.Key, Value: h.Value})
+	}
+
+	return consumedMsg
+}
+
+func TestRedriveMessage(t *testing.T) {
+	t.Run("dead-letter message is re-driven to the original topic", func(t *testing.T) {
+		original := &sarama.ConsumerMessage{
+			Topic:     "events",
+			Partition: 2,
+			Offset:    10,
+			Value:     []byte(`{"short_url":"a"}`),
+			Headers:   []*sarama.RecordHeader{{Key: []byte("trace"), Value: []byte("abc")}},
+		}
+		deadLetter := deadLetterMessage("events-dlq", original, errors.New("failed"), stageStore, 3)
+
+		redriven, err := redriveMessage(consumed(t, deadLetter, 0))
+		require.NoError(t, err)
+
+		assert.Equal(t, "events", redriven.Topic)
+		assert.Equal(t, sarama.ByteEncoder(original.Value), redriven.Value)
+		assert.Equal(t, []sarama.RecordHeader{{Key: []byte("trace"), Value: []byte("abc")}}, redriven.Headers)
+	})
+
+	t.Run("message without the original topic", func(t *testing.T) {
+		_, err := redriveMessage(&sarama.ConsumerMessage{Value: []byte("{}")})
+		require.Error(t, err)
+	})
+}
@@ -2,20 +2,62 @@ package kafka
 
 import (
 	"context"
+	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/IBM/sarama"
 	log "github.com/sirupsen/logrus"
+	"io"
 	"min/internal/core/domain"
 	"min/internal/core/port"
+	"net"
+	"syscall"
+	"time"
 )
 
+// ConsumerConfig describes the batching of the consumed events and the handling of the failures.
+type ConsumerConfig struct {
+	// BatchSize is the number of events after which the batch of a partition is stored.
+	BatchSize int
+	// FlushInterval is the maximal time the events wait in the batch before they are stored.
+	FlushInterval time.Duration
+	// MaxAttempts is the number of attempts to store the batch.
+	MaxAttempts int
+	// InitialBackoff is the delay before the second attempt, it doubles with every next attempt up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// DeadLetterTopic receives the messages that can't be decoded or stored after all the attempts. The batches
+	// failing with connection errors and timeouts are never sent there, they are consumed again until they are
+	// stored. Without the topic the consumer does the same with every batch that fails all the attempts.
+	DeadLetterTopic string
+}
+
 type Consumer struct {
 	consumerGroup sarama.ConsumerGroup
+	deadLetters   sarama.SyncProducer
 	service       port.StatisticsService
+	cfg           ConsumerConfig
 }
 
-func NewKafkaConsumer(brokers []string, groupID string, service port.StatisticsService) (*Consumer, error) {
+func NewKafkaConsumer(
+	brokers []string,
+	groupID string,
+	service port.StatisticsService,
+	cfg ConsumerConfig,
+) (*Consumer, error) {
+	if cfg.BatchSize <= 0 || cfg.FlushInterval <= 0 {
+		return nil, errors.New("batch size and flush interval must be positive")
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+
+	if cfg.MaxBackoff < cfg.InitialBackoff {
+		cfg.MaxBackoff = cfg.InitialBackoff
+	}
+
 	config := sarama.NewConfig()
 	config.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRoundRobin()}
 	consumerGroup, err := sarama.NewConsumerGroup(brokers, groupID, config)
@@ -23,15 +65,41 @@ func NewKafkaConsumer(brokers []string, groupID string, service port.StatisticsS
 		return nil, fmt.Errorf("failed to create Kafka consumer group: %w", err)
 	}
 
+	var deadLetters sarama.SyncProducer
+	if cfg.DeadLetterTopic != "" {
+		producerConfig := sarama.NewConfig()
+		producerConfig.Producer.Return.Successes = true
+		producerConfig.Producer.RequiredAcks = sarama.WaitForAll
+		producerConfig.Producer.Retry.Max = 5
+
+		deadLetters, err = sarama.NewSyncProducer(brokers, producerConfig)
+		if err != nil {
+			_ = consumerGroup.Close()
+			return nil, fmt.Errorf("failed to create Kafka dead-letter producer: %w", err)
+		}
+	}
+
 	return &Consumer{
 		consumerGroup: consumerGroup,
+		deadLetters:   deadLetters,
 		service:       service,
+		cfg:           cfg,
 	}, nil
 }
 
+// Close leaves the consumer group and closes the dead-letter producer.
+func (kc *Consumer) Close() error {
+	err := kc.consumerGroup.Close()
+	if kc.deadLetters != nil {
+		err = errors.Join(err, kc.deadLetters.Close())
+	}
+
+	return err
+}
+
 func (kc *Consumer) Start(ctx context.Context, topics []string) error {
 	log.Infof("starting Kafka consumer for topics: %v", topics)
-	handler := &consumerGroupHandler{service: kc.service}
+	handler := &consumerGroupHandler{service: kc.service, deadLetters: kc.deadLetters, cfg: kc.cfg}
 
 	for {
 		if err := kc.consumerGroup.Consume(ctx, topics, handler); err != nil {
@@ -45,26 +113,181 @@ func (kc *Consumer) Start(ctx context.Context, topics []string) error {
 }
 
 type consumerGroupHandler struct {
-	service port.StatisticsService
+	service     port.StatisticsService
+	deadLetters sarama.SyncProducer
+	cfg         ConsumerConfig
 }
 
 func (h *consumerGroupHandler) Setup(sarama.ConsumerGroupSession) error   { return nil }
 func (h *consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+// ConsumeClaim accumulates the events of the partition and stores them when the batch is full or
+// the flush interval passes. The offsets are marked only after the batch is stored or sent to the dead-letter
+// topic. Otherwise the error ends the session and the events are consumed again from the last marked offset.
 func (h *consumerGroupHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for msg := range claim.Messages() {
-		log.Infof("received kafka message: %s", string(msg.Value))
-		var event domain.Event
-		if err := json.Unmarshal(msg.Value, &event); err != nil {
-			log.Errorf("failed to unmarshal message: %v", err)
-			continue
+	batch := make([]domain.Event, 0, h.cfg.BatchSize)
+	messages := make([]*sarama.ConsumerMessage, 0, h.cfg.BatchSize)
+	var last *sarama.ConsumerMessage
+
+	flush := func() error {
+		if last == nil {
+			return nil
 		}
 
-		if err := h.service.AddEvent(sess.Context(), event); err != nil {
-			log.Errorf("failed to add event: %v", err)
-			continue
+		if err := h.flush(sess.Context(), batch, messages); err != nil {
+			return fmt.Errorf("failed to flush %d events of partition %d: %w", len(batch), claim.Partition(), err)
 		}
 
-		sess.MarkMessage(msg, "")
+		sess.MarkMessage(last, "")
+		batch, messages, last = batch[:0], messages[:0], nil
+		return nil
+	}
+
+	ticker := time.NewTicker(h.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return flush()
+			}
+
+			last = msg
+			var event domain.Event
+			if err := json.Unmarshal(msg.Value, &event); err != nil {
+				log.Errorf("failed to unmarshal message: %v", err)
+				// Messages that can't be decoded never succeed, so they are not retried.
+				if err := h.deadLetter([]*sarama.ConsumerMessage{msg}, err, stageDecode, 1); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if event.ID == "" {
+				// Events produced before the IDs were introduced are identified by their offsets.
+				event.ID = domain.DeriveEventID(fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset))
+			}
+
+			batch = append(batch, event)
+			messages = append(messages, msg)
+			if len(batch) >= h.cfg.BatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		case <-sess.Context().Done():
+			// The events that are not flushed are consumed again by the next owner of the partition.
+			return nil
+		}
 	}
+}
+
+// flush stores the batch of events, the batch that fails all the attempts is sent to the dead-letter topic
+// unless the storage is unavailable.
+func (h *consumerGroupHandler) flush(
+	ctx context.Context,
+	batch []domain.Event,
+	messages []*sarama.ConsumerMessage,
+) error {
+	err := h.store(ctx, batch)
+	if err == nil {
+		return nil
+	}
+
+	// The session ends during a rebalance, the batch is consumed again by the next owner of the partition.
+	if ctx.Err() != nil {
+		return err
+	}
+
+	// The events are fine while the storage is down, so the session ends without marking the offsets and
+	// the batch is consumed again instead of filling the dead-letter topic with every event of the outage.
+	if isTransient(err) {
+		log.Errorf(
+			"failed to store %d events after %d attempts, storage is unavailable: %v",
+			len(batch),
+			h.cfg.MaxAttempts,
+			err,
+		)
+		return err
+	}
+
+	log.Errorf("failed to store %d events after %d attempts: %v", len(batch), h.cfg.MaxAttempts, err)
+	return h.deadLetter(messages, err, stageStore, h.cfg.MaxAttempts)
+}
+
+// store adds the events with retries, the delay between the attempts grows exponentially.
+func (h *consumerGroupHandler) store(ctx context.Context, batch []domain.Event) error {
+	backoff := h.cfg.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := h.service.AddEvents(ctx, batch)
+		if err == nil || attempt >= h.cfg.MaxAttempts {
+			return err
+		}
+
+		log.Warnf(
+			"failed to store events (attempt %d of %d), retrying in %s: %v",
+			attempt,
+			h.cfg.MaxAttempts,
+			backoff,
+			err,
+		)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, h.cfg.MaxBackoff)
+	}
+}
+
+// isTransient reports whether the error is a connection error or a timeout, which go away once the storage
+// is reachable again, as opposed to the errors of the events themselves.
+func isTransient(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// deadLetter publishes the messages to the dead-letter topic. Without the topic the failure is returned.
+func (h *consumerGroupHandler) deadLetter(
+	messages []*sarama.ConsumerMessage,
+	cause error,
+	stage string,
+	attempts int,
+) error {
+	if h.deadLetters == nil {
+		if stage == stageDecode {
+			// Without the dead-letter topic the messages that can't be decoded are skipped.
+			return nil
+		}
+		return cause
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	deadLetters := make([]*sarama.ProducerMessage, 0, len(messages))
+	for _, msg := range messages {
+		deadLetters = append(deadLetters, deadLetterMessage(h.cfg.DeadLetterTopic, msg, cause, stage, attempts))
+	}
+
+	if err := h.deadLetters.SendMessages(deadLetters); err != nil {
+		return fmt.Errorf("failed to publish %d messages to the dead-letter topic: %w", len(deadLetters), err)
+	}
+
+	log.Warnf("published %d messages to the dead-letter topic %s", len(deadLetters), h.cfg.DeadLetterTopic)
 	return nil
 }
@@ -0,0 +1,332 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"min/internal/core/domain"
+	"min/internal/mocks"
+)
+
+// fakeSession is a consumer group session that records the marked offsets.
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+	ctx    context.Context
+	mu     sync.Mutex
+	marked []int64
+}
+
+func (s *fakeSession) Context() context.Context { return s.ctx }
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.marked = append(s.marked, msg.Offset)
+}
+
+func (s *fakeSession) markedOffsets() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]int64(nil), s.marked...)
+}
+
+// fakeClaim is a claim of a partition with the messages sent by the test.
+type fakeClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Partition() int32                         { return 0 }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+// fakeSyncProducer is a dead-letter producer that records the published messages.
+type fakeSyncProducer struct {
+	sarama.SyncProducer
+	err      error
+	messages []*sarama.ProducerMessage
+}
+
+func (p *fakeSyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	p.messages = append(p.messages, msgs...)
+	return nil
+}
+
+// header returns the value of the header of the published message.
+func header(msg *sarama.ProducerMessage, key string) string {
+	for _, h := range msg.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+
+	return ""
+}
+
+// event returns the event for the short URL, the short URL is used as its ID as well.
+func event(short string) domain.Event {
+	return domain.Event{ID: short, ShortURL: short}
+}
+
+// eventMessage returns the message with the event for the short URL at the offset.
+func eventMessage(t *testing.T, offset int64, short string) *sarama.ConsumerMessage {
+	t.Helper()
+
+	value, err := json.Marshal(event(short))
+	require.NoError(t, err)
+	return &sarama.ConsumerMessage{Offset: offset, Value: value}
+}
+
+func TestConsumerGroupHandler_ConsumeClaim(t *testing.T) {
+	t.Run("flush on batch size", func(t *testing.T) {
+		serviceMock := new(mocks.StatisticsService)
+		handler := &consumerGroupHandler{service: serviceMock, cfg: ConsumerConfig{BatchSize: 2, FlushInterval: time.Hour}}
+		sess := &fakeSession{ctx: context.Background()}
+		claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 3)}
+
+		serviceMock.On("AddEvents", mock.Anything, []domain.Event{event("a"), event("b")}).Return(nil).Once()
+		serviceMock.On("AddEvents", mock.Anything, []domain.Event{event("c")}).Return(nil).Once()
+
+		claim.messages <- eventMessage(t, 1, "a")
+		claim.messages <- eventMessage(t, 2, "b")
+		claim.messages <- eventMessage(t, 3, "c")
+		close(claim.messages)
+
+		require.NoError(t, handler.ConsumeClaim(sess, claim))
+		// The last batch is flushed when the claim ends.
+		assert.Equal(t, []int64{2, 3}, sess.markedOffsets())
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("flush on interval", func(t *testing.T) {
+		serviceMock := new(mocks.StatisticsService)
+		handler := &consumerGroupHandler{
+			service: serviceMock,
+			cfg:     ConsumerConfig{BatchSize: 100, FlushInterval: 10 * time.Millisecond},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		sess := &fakeSession{ctx: ctx}
+		claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+
+		serviceMock.On("AddEvents", mock.Anything, []domain.Event{event("a")}).Return(nil).Once()
+
+		done := make(chan error)
+		go func() { done <- handler.ConsumeClaim(sess, claim) }()
+		claim.messages <- eventMessage(t, 7, "a")
+
+		require.Eventually(t, func() bool { return len(sess.markedOffsets()) == 1 }, time.Second, time.Millisecond)
+		assert.Equal(t, []int64{7}, sess.markedOffsets())
+
+		cancel()
+		require.NoError(t, <-done)
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("failed flush does not mark offsets", func(t *testing.T) {
+		serviceMock := new(mocks.StatisticsService)
+		handler := &consumerGroupHandler{service: serviceMock, cfg: ConsumerConfig{BatchSize: 1, FlushInterval: time.Hour}}
+		sess := &fakeSession{ctx: context.Background()}
+		claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+
+		serviceMock.On("AddEvents", mock.Anything, mock.Anything).Return(errors.New("clickhouse is down")).Once()
+
+		claim.messages <- eventMessage(t, 1, "a")
+
+		require.Error(t, handler.ConsumeClaim(sess, claim))
+		assert.Empty(t, sess.markedOffsets())
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("malformed message is skipped", func(t *testing.T) {
+		serviceMock := new(mocks.StatisticsService)
+		handler := &consumerGroupHandler{service: serviceMock, cfg: ConsumerConfig{BatchSize: 10, FlushInterval: time.Hour}}
+		sess := &fakeSession{ctx: context.Background()}
+		claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 2)}
+
+		serviceMock.On("AddEvents", mock.Anything, []domain.Event{event("a")}).Return(nil).Once()
+
+		claim.messages <- eventMessage(t, 1, "a")
+		claim.messages <- &sarama.ConsumerMessage{Offset: 2, Value: []byte("not json")}
+		close(claim.messages)
+
+		require.NoError(t, handler.ConsumeClaim(sess, claim))
+		assert.Equal(t, []int64{2}, sess.markedOffsets())
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("events without id are identified by offset", func(t *testing.T) {
+		serviceMock := new(mocks.StatisticsService)
+		handler := &consumerGroupHandler{service: serviceMock, cfg: ConsumerConfig{BatchSize: 10, FlushInterval: time.Hour}}
+		sess := &fakeSession{ctx: context.Background()}
+		claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 3)}
+
+		var stored []domain.Event
+		serviceMock.On("AddEvents", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { stored = args.Get(1).([]domain.Event) }).
+			Return(nil).Once()
+
+		for _, offset := range []int64{1, 2, 1} {
+			claim.messages <- &sarama.ConsumerMessage{Topic: "events", Offset: offset, Value: []byte(`{"short_url":"a"}`)}
+		}
+		close(claim.messages)
+
+		require.NoError(t, handler.ConsumeClaim(sess, claim))
+		require.Len(t, stored, 3)
+		assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, stored[0].ID)
+		assert.NotEqual(t, stored[0].ID, stored[1].ID)
+		assert.Equal(t, stored[0].ID, stored[2].ID)
+		serviceMock.AssertExpectations(t)
+	})
+}
+
+func TestConsumerGroupHandler_Failures(t *testing.T) {
+	cfg := ConsumerConfig{
+		BatchSize:       1,
+		FlushInterval:   time.Hour,
+		MaxAttempts:     3,
+		InitialBackoff:  time.Millisecond,
+		MaxBackoff:      time.Millisecond,
+		DeadLetterTopic: "events-dlq",
+	}
+
+	t.Run("transient error is retried", func(t *testing.T) {
+		serviceMock := new(mocks.StatisticsService)
+		deadLetters := &fakeSyncProducer{}
+		handler := &consumerGroupHandler{service: serviceMock, deadLetters: deadLetters, cfg: cfg}
+		sess := &fakeSession{ctx: context.Background()}
+		claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+
+		serviceMock.On("AddEvents", mock.Anything, mock.Anything).Return(errors.New("timeout")).Twice()
+		serviceMock.On("AddEvents", mock.Anything, mock.Anything).Return(nil).Once()
+
+		claim.messages <- eventMessage(t, 1, "a")
+		close(claim.messages)
+
+		require.NoError(t, handler.ConsumeClaim(sess, claim))
+		assert.Equal(t, []int64{1}, sess.markedOffsets())
+		assert.Empty(t, deadLetters.messages)
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("batch failing all attempts is dead-lettered", func(t *testing.T) {
+		serviceMock := new(mocks.StatisticsService)
+		deadLetters := &fakeSyncProducer{}
+		handler := &consumerGroupHandler{service: serviceMock, deadLetters: deadLetters, cfg: cfg}
+		sess := &fakeSession{ctx: context.Background()}
+		claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+
+		serviceMock.On("AddEvents", mock.Anything, mock.Anything).Return(errors.New("clickhouse is down")).Times(3)
+
+		msg := eventMessage(t, 5, "a")
+		msg.Topic = "events"
+		claim.messages <- msg
+		close(claim.messages)
+
+		require.NoError(t, handler.ConsumeClaim(sess, claim))
+		assert.Equal(t, []int64{5}, sess.markedOffsets())
+		require.Len(t, deadLetters.messages, 1)
+
+		deadLetter := deadLetters.messages[0]
+		assert.Equal(t, "events-dlq", deadLetter.Topic)
+		assert.Equal(t, sarama.ByteEncoder(msg.Value), deadLetter.Value)
+		assert.Equal(t, stageStore, header(deadLetter, headerStage))
+		assert.Equal(t, "3", header(deadLetter, headerAttempts))
+		assert.Equal(t, "events", header(deadLetter, headerTopic))
+		assert.Equal(t, "5", header(deadLetter, headerOffset))
+		assert.Contains(t, header(deadLetter, headerError), "clickhouse is down")
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("unavailable storage ends the session without dead-lettering", func(t *testing.T) {
+		for _, storeErr := range []error{
+			&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			context.DeadlineExceeded,
+		} {
+			serviceMock := new(mocks.StatisticsService)
+			deadLetters := &fakeSyncProducer{}
+			handler := &consumerGroupHandler{service: serviceMock, deadLetters: deadLetters, cfg: cfg}
+			sess := &fakeSession{ctx: context.Background()}
+			claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+
+			serviceMock.On("AddEvents", mock.Anything, mock.Anything).Return(storeErr).Times(3)
+
+			claim.messages <- eventMessage(t, 1, "a")
+
+			require.ErrorIs(t, handler.ConsumeClaim(sess, claim), storeErr)
+			assert.Empty(t, sess.markedOffsets())
+			assert.Empty(t, deadLetters.messages)
+			serviceMock.AssertExpectations(t)
+		}
+	})
+
+	t.Run("malformed message is dead-lettered without retries", func(t *testing.T) {
+		serviceMock := new(mocks.StatisticsService)
+		deadLetters := &fakeSyncProducer{}
+		handler := &consumerGroupHandler{service: serviceMock, deadLetters: deadLetters, cfg: cfg}
+		sess := &fakeSession{ctx: context.Background()}
+		claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+
+		serviceMock.On("AddEvents", mock.Anything, []domain.Event{}).Return(nil).Once()
+
+		claim.messages <- &sarama.ConsumerMessage{Offset: 3, Value: []byte("not json")}
+		close(claim.messages)
+
+		require.NoError(t, handler.ConsumeClaim(sess, claim))
+		assert.Equal(t, []int64{3}, sess.markedOffsets())
+		require.Len(t, deadLetters.messages, 1)
+		assert.Equal(t, stageDecode, header(deadLetters.messages[0], headerStage))
+		assert.Equal(t, "1", header(deadLetters.messages[0], headerAttempts))
+	})
+
+	t.Run("failed dead-letter publishing ends the session", func(t *testing.T) {
+		serviceMock := new(mocks.StatisticsService)
+		deadLetters := &fakeSyncProducer{err: errors.New("kafka is down")}
+		handler := &consumerGroupHandler{service: serviceMock, deadLetters: deadLetters, cfg: cfg}
+		sess := &fakeSession{ctx: context.Background()}
+		claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+
+		serviceMock.On("AddEvents", mock.Anything, mock.Anything).Return(errors.New("clickhouse is down")).Times(3)
+
+		claim.messages <- eventMessage(t, 1, "a")
+
+		require.Error(t, handler.ConsumeClaim(sess, claim))
+		assert.Empty(t, sess.markedOffsets())
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("rebalance stops retries without dead-lettering", func(t *testing.T) {
+		serviceMock := new(mocks.StatisticsService)
+		deadLetters := &fakeSyncProducer{}
+		slow := cfg
+		slow.InitialBackoff, slow.MaxBackoff = time.Hour, time.Hour
+		handler := &consumerGroupHandler{service: serviceMock, deadLetters: deadLetters, cfg: slow}
+		ctx, cancel := context.WithCancel(context.Background())
+		sess := &fakeSession{ctx: ctx}
+		claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+
+		serviceMock.On("AddEvents", mock.Anything, mock.Anything).
+			Run(func(mock.Arguments) { cancel() }).
+			Return(errors.New("clickhouse is down")).Once()
+
+		claim.messages <- eventMessage(t, 1, "a")
+
+		require.Error(t, handler.ConsumeClaim(sess, claim))
+		assert.Empty(t, sess.markedOffsets())
+		assert.Empty(t, deadLetters.messages)
+		serviceMock.AssertExpectations(t)
+	})
+}
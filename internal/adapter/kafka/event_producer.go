@@ -2,54 +2,362 @@ package kafka
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"min/internal/core/domain"
+	"sync"
+	"time"
 
 	"github.com/IBM/sarama"
 )
 
+// OverflowPolicy defines what happens to a new event when the buffer of the producer is full.
+type OverflowPolicy string
+
+const (
+	// DropNewest rejects the new event and keeps the buffered ones.
+	DropNewest OverflowPolicy = "drop_newest"
+	// DropOldest evicts the oldest buffered event to make room for the new one.
+	DropOldest OverflowPolicy = "drop_oldest"
+	// Spill writes the new event to the spool, it is replayed along with the undelivered events.
+	Spill OverflowPolicy = "spill"
+)
+
+const (
+	// replayBatchSize is the maximal number of spooled events sent to Kafka at once.
+	replayBatchSize = 100
+	// defaultReplayInterval is how often the replay is retried while Kafka is unavailable.
+	defaultReplayInterval = 5 * time.Second
+)
+
+// ProducerConfig describes the buffering of the events.
+type ProducerConfig struct {
+	// BufferSize is the maximal number of events waiting to be sent to Kafka.
+	BufferSize int
+	// Overflow is the policy applied when the buffer is full.
+	Overflow OverflowPolicy
+	// Spool keeps the events that could not be delivered, nil disables spooling.
+	Spool *Spool
+	// ReplayInterval is how often the spooled events are retried while Kafka is unavailable.
+	ReplayInterval time.Duration
+}
+
+// EventProducer publishes events to Kafka in the background. Produce never waits for the brokers,
+// the events are buffered in memory and dropped according to the overflow policy when Kafka can't keep up.
+// With a spool the events that Kafka failed to accept are written to the disk and replayed in order
+// once it is available again. While the spool is not empty, new events are appended to it as well,
+// the events that were already in flight when Kafka failed are spooled in the order their errors arrive.
 type EventProducer struct {
-	producer sarama.SyncProducer
-	topic    string
+	producer       sarama.AsyncProducer
+	topic          string
+	overflow       OverflowPolicy
+	events         chan *domain.Event
+	spool          *Spool
+	replayInterval time.Duration
+	// spooled wakes up the replay when events are added to the spool.
+	spooled chan struct{}
+	stop    chan struct{}
+
+	// mu guards closed, so that no event is buffered after the events channel is closed.
+	mu     sync.RWMutex
+	closed bool
+
+	sending   sync.WaitGroup
+	replaying sync.WaitGroup
+	handling  sync.WaitGroup
 }
 
-func NewEventProducer(brokers []string, topic string) (*EventProducer, error) {
+func NewEventProducer(brokers []string, topic string, cfg ProducerConfig) (*EventProducer, error) {
 	config := sarama.NewConfig()
+	config.Producer.Return.Errors = true
 	config.Producer.Return.Successes = true
 	config.Producer.RequiredAcks = sarama.WaitForAll
 	config.Producer.Retry.Max = 5
 
-	producer, err := sarama.NewSyncProducer(brokers, config)
+	producer, err := sarama.NewAsyncProducer(brokers, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
 	}
 
-	return &EventProducer{
-		producer: producer,
-		topic:    topic,
-	}, nil
+	return newEventProducer(producer, topic, cfg)
+}
+
+// newEventProducer starts publishing the events with the given producer.
+func newEventProducer(producer sarama.AsyncProducer, topic string, cfg ProducerConfig) (*EventProducer, error) {
+	if cfg.BufferSize <= 0 {
+		return nil, fmt.Errorf("event buffer size must be positive, got %d", cfg.BufferSize)
+	}
+
+	switch cfg.Overflow {
+	case "":
+		cfg.Overflow = DropNewest
+	case DropNewest, DropOldest:
+	case Spill:
+		if cfg.Spool == nil {
+			return nil, errors.New("spill overflow policy requires a spool")
+		}
+	default:
+		return nil, fmt.Errorf("unknown overflow policy %q", cfg.Overflow)
+	}
+
+	if cfg.ReplayInterval <= 0 {
+		cfg.ReplayInterval = defaultReplayInterval
+	}
+
+	ep := &EventProducer{
+		producer:       producer,
+		topic:          topic,
+		overflow:       cfg.Overflow,
+		events:         make(chan *domain.Event, cfg.BufferSize),
+		spool:          cfg.Spool,
+		replayInterval: cfg.ReplayInterval,
+		spooled:        make(chan struct{}, 1),
+		stop:           make(chan struct{}),
+	}
+
+	ep.sending.Add(1)
+	go ep.send()
+	ep.handling.Add(1)
+	go ep.handleResults()
+	if ep.spool != nil {
+		ep.replaying.Add(1)
+		go ep.replay()
+	}
+
+	return ep, nil
 }
 
+// Produce buffers the event to be sent to Kafka. It returns domain.ErrEventDropped if the event
+// is rejected because the buffer is full or the producer is closed.
 func (ep *EventProducer) Produce(event *domain.Event) error {
-	log.Infof("Producing event to Kafka: %v", event)
-	eventBytes, err := json.Marshal(event)
-	if err != nil {
-		log.Errorf("Failed to marshal event: %v", err)
-		return fmt.Errorf("failed to marshal event: %w", err)
+	ep.mu.RLock()
+	defer ep.mu.RUnlock()
+
+	if ep.closed {
+		return fmt.Errorf("%w: producer is closed", domain.ErrEventDropped)
+	}
+
+	select {
+	case ep.events <- event:
+		return nil
+	default:
+	}
+
+	switch ep.overflow {
+	case Spill:
+		eventBytes, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+
+		if err := ep.spill(eventBytes); err != nil {
+			return fmt.Errorf("%w: %w", domain.ErrEventDropped, err)
+		}
+		return nil
+	case DropOldest:
+		select {
+		case dropped := <-ep.events:
+			log.Warnf("Event buffer is full, dropping the oldest event: %v", dropped)
+		default:
+		}
+
+		select {
+		case ep.events <- event:
+			return nil
+		default:
+		}
+	case DropNewest:
+	}
+
+	return fmt.Errorf("%w: buffer is full", domain.ErrEventDropped)
+}
+
+// Close stops accepting events, sends the buffered ones and waits for the producer to shut down.
+// The events that are not delivered by then stay in the spool until the next start.
+func (ep *EventProducer) Close() error {
+	ep.mu.Lock()
+	if ep.closed {
+		ep.mu.Unlock()
+		return nil
+	}
+	ep.closed = true
+	close(ep.events)
+	ep.mu.Unlock()
+
+	ep.sending.Wait()
+	close(ep.stop)
+	ep.replaying.Wait()
+	ep.producer.AsyncClose()
+	ep.handling.Wait()
+
+	if ep.spool != nil {
+		return ep.spool.Close()
+	}
+
+	return nil
+}
+
+// send passes the buffered events to the Kafka producer until the buffer is closed. The events go to the spool
+// instead while it has events to replay, so that they are delivered in order.
+func (ep *EventProducer) send() {
+	defer ep.sending.Done()
+
+	for event := range ep.events {
+		eventBytes, err := json.Marshal(event)
+		if err != nil {
+			log.Errorf("Failed to marshal event: %v", err)
+			continue
+		}
+
+		if ep.spool != nil && ep.spool.Len() > 0 {
+			if err := ep.spill(eventBytes); err != nil {
+				log.Errorf("Failed to spool event: %v", err)
+			}
+			continue
+		}
+
+		ep.producer.Input() <- &sarama.ProducerMessage{
+			Topic: ep.topic,
+			Value: sarama.ByteEncoder(eventBytes),
+		}
+	}
+}
+
+// handleResults reports the delivery of the replayed events and spools the undelivered ones.
+func (ep *EventProducer) handleResults() {
+	defer ep.handling.Done()
+
+	successes, errs := ep.producer.Successes(), ep.producer.Errors()
+	for successes != nil || errs != nil {
+		select {
+		case msg, ok := <-successes:
+			if !ok {
+				successes = nil
+				continue
+			}
+			ep.delivered(msg, nil)
+		case perr, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			ep.delivered(perr.Msg, perr.Err)
+		}
+	}
+}
+
+// delivered handles the result of sending the message to Kafka.
+func (ep *EventProducer) delivered(msg *sarama.ProducerMessage, err error) {
+	if result, ok := msg.Metadata.(chan<- error); ok {
+		result <- err
+		return
+	}
+
+	if err == nil {
+		return
 	}
 
-	msg := &sarama.ProducerMessage{
-		Topic: ep.topic,
-		Value: sarama.ByteEncoder(eventBytes),
+	log.Errorf("Failed to send message: %v", err)
+	if ep.spool == nil {
+		return
 	}
 
-	partition, offset, err := ep.producer.SendMessage(msg)
+	eventBytes, err := msg.Value.Encode()
 	if err != nil {
-		log.Errorf("Failed to send message: %v", err)
-		return fmt.Errorf("failed to send message: %w", err)
+		log.Errorf("Failed to encode message: %v", err)
+		return
+	}
+
+	if err := ep.spill(eventBytes); err != nil {
+		log.Errorf("Failed to spool event: %v", err)
+	}
+}
+
+// spill appends the event to the spool and wakes up the replay.
+func (ep *EventProducer) spill(eventBytes []byte) error {
+	if err := ep.spool.Append(eventBytes); err != nil {
+		return fmt.Errorf("failed to spool event: %w", err)
+	}
+
+	select {
+	case ep.spooled <- struct{}{}:
+	default:
 	}
 
-	log.Infof("Message is stored in topic(%s)/partition(%d)/offset(%d)", ep.topic, partition, offset)
 	return nil
 }
+
+// replay sends the spooled events to Kafka in order. Failed batches are retried every replay interval.
+func (ep *EventProducer) replay() {
+	defer ep.replaying.Done()
+
+	ticker := time.NewTicker(ep.replayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ep.stop:
+			return
+		case <-ep.spooled:
+		case <-ticker.C:
+		}
+
+		for {
+			replayed, err := ep.replayBatch()
+			if err != nil {
+				log.Errorf("Failed to replay spooled events: %v", err)
+				break
+			}
+
+			if replayed == 0 {
+				break
+			}
+
+			stats := ep.spool.Stats()
+			log.Infof("Replayed %d spooled events, %d events left in the spool", replayed, stats.Records)
+		}
+	}
+}
+
+// replayBatch sends the oldest spooled events and removes them from the spool once all of them are delivered.
+// Events of a partially delivered batch are sent again, so the delivery is at least once.
+func (ep *EventProducer) replayBatch() (int, error) {
+	records, err := ep.spool.Peek(replayBatchSize)
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+
+	// The results channel is buffered, so that results arriving after the replay is stopped don't block.
+	results := make(chan error, len(records))
+	for _, record := range records {
+		ep.producer.Input() <- &sarama.ProducerMessage{
+			Topic:    ep.topic,
+			Value:    sarama.ByteEncoder(record),
+			Metadata: (chan<- error)(results),
+		}
+	}
+
+	var sendErr error
+	for range records {
+		select {
+		case <-ep.stop:
+			// The batch stays in the spool and is replayed after the restart.
+			return 0, nil
+		case err := <-results:
+			if err != nil {
+				sendErr = err
+			}
+		}
+	}
+
+	if sendErr != nil {
+		return 0, sendErr
+	}
+
+	if err := ep.spool.Ack(len(records)); err != nil {
+		return 0, err
+	}
+
+	return len(records), nil
+}
@@ -0,0 +1,282 @@
+package kafka
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"min/internal/core/domain"
+)
+
+// fakeAsyncProducer is an AsyncProducer that hands the messages over only when the test reads them,
+// or answers them by itself after serve is called.
+type fakeAsyncProducer struct {
+	sarama.AsyncProducer
+	input     chan *sarama.ProducerMessage
+	successes chan *sarama.ProducerMessage
+	errors    chan *sarama.ProducerError
+
+	served    bool
+	down      atomic.Bool
+	mu        sync.Mutex
+	delivered []string
+}
+
+func newFakeAsyncProducer() *fakeAsyncProducer {
+	return &fakeAsyncProducer{
+		input:     make(chan *sarama.ProducerMessage),
+		successes: make(chan *sarama.ProducerMessage),
+		errors:    make(chan *sarama.ProducerError),
+	}
+}
+
+func (p *fakeAsyncProducer) Input() chan<- *sarama.ProducerMessage     { return p.input }
+func (p *fakeAsyncProducer) Successes() <-chan *sarama.ProducerMessage { return p.successes }
+func (p *fakeAsyncProducer) Errors() <-chan *sarama.ProducerError      { return p.errors }
+
+func (p *fakeAsyncProducer) AsyncClose() {
+	if p.served {
+		close(p.input)
+		return
+	}
+
+	close(p.successes)
+	close(p.errors)
+}
+
+// serve answers the messages with errors while the broker is down and delivers them otherwise.
+func (p *fakeAsyncProducer) serve(t *testing.T) {
+	t.Helper()
+	p.served = true
+
+	go func() {
+		defer close(p.errors)
+		defer close(p.successes)
+
+		for msg := range p.input {
+			if p.down.Load() {
+				p.errors <- &sarama.ProducerError{Msg: msg, Err: sarama.ErrOutOfBrokers}
+				continue
+			}
+
+			p.mu.Lock()
+			p.delivered = append(p.delivered, shortURL(t, msg))
+			p.mu.Unlock()
+			p.successes <- msg
+		}
+	}()
+}
+
+// deliveredEvents returns the short URLs of the delivered events.
+func (p *fakeAsyncProducer) deliveredEvents() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.delivered)
+}
+
+// shortURL returns the short URL of the event in the message.
+func shortURL(t *testing.T, msg *sarama.ProducerMessage) string {
+	t.Helper()
+
+	value, err := msg.Value.Encode()
+	require.NoError(t, err)
+
+	var event domain.Event
+	require.NoError(t, json.Unmarshal(value, &event))
+	return event.ShortURL
+}
+
+// receive returns the short URL of the next event sent to Kafka.
+func (p *fakeAsyncProducer) receive(t *testing.T) string {
+	t.Helper()
+
+	select {
+	case msg := <-p.input:
+		return shortURL(t, msg)
+	case <-time.After(time.Second):
+		t.Fatal("no message was sent")
+		return ""
+	}
+}
+
+// fillBuffer produces the events, so that the first one is held by the sender and the rest fill the buffer.
+func fillBuffer(t *testing.T, ep *EventProducer, shorts ...string) {
+	t.Helper()
+
+	require.NoError(t, ep.Produce(&domain.Event{ShortURL: shorts[0]}))
+	require.Eventually(t, func() bool { return len(ep.events) == 0 }, time.Second, time.Millisecond)
+	for _, short := range shorts[1:] {
+		require.NoError(t, ep.Produce(&domain.Event{ShortURL: short}))
+	}
+}
+
+func TestEventProducer_Produce(t *testing.T) {
+	t.Run("events are sent in order", func(t *testing.T) {
+		producer := newFakeAsyncProducer()
+		ep, err := newEventProducer(producer, "events", ProducerConfig{BufferSize: 2})
+		require.NoError(t, err)
+
+		fillBuffer(t, ep, "first", "second")
+
+		assert.Equal(t, "first", producer.receive(t))
+		assert.Equal(t, "second", producer.receive(t))
+		require.NoError(t, ep.Close())
+	})
+
+	t.Run("drop newest", func(t *testing.T) {
+		producer := newFakeAsyncProducer()
+		ep, err := newEventProducer(producer, "events", ProducerConfig{BufferSize: 1, Overflow: DropNewest})
+		require.NoError(t, err)
+
+		fillBuffer(t, ep, "sending", "buffered")
+		err = ep.Produce(&domain.Event{ShortURL: "dropped"})
+		require.ErrorIs(t, err, domain.ErrEventDropped)
+
+		assert.Equal(t, "sending", producer.receive(t))
+		assert.Equal(t, "buffered", producer.receive(t))
+		require.NoError(t, ep.Close())
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		producer := newFakeAsyncProducer()
+		ep, err := newEventProducer(producer, "events", ProducerConfig{BufferSize: 1, Overflow: DropOldest})
+		require.NoError(t, err)
+
+		fillBuffer(t, ep, "sending", "dropped")
+		require.NoError(t, ep.Produce(&domain.Event{ShortURL: "newest"}))
+
+		assert.Equal(t, "sending", producer.receive(t))
+		assert.Equal(t, "newest", producer.receive(t))
+		require.NoError(t, ep.Close())
+	})
+
+	t.Run("closed producer", func(t *testing.T) {
+		producer := newFakeAsyncProducer()
+		ep, err := newEventProducer(producer, "events", ProducerConfig{BufferSize: 1})
+		require.NoError(t, err)
+		require.NoError(t, ep.Close())
+
+		err = ep.Produce(&domain.Event{ShortURL: "late"})
+		require.ErrorIs(t, err, domain.ErrEventDropped)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := newEventProducer(newFakeAsyncProducer(), "events", ProducerConfig{})
+		require.Error(t, err)
+
+		_, err = newEventProducer(newFakeAsyncProducer(), "events", ProducerConfig{BufferSize: 1, Overflow: "block"})
+		require.Error(t, err)
+
+		_, err = newEventProducer(newFakeAsyncProducer(), "events", ProducerConfig{BufferSize: 1, Overflow: Spill})
+		require.Error(t, err)
+	})
+}
+
+func TestEventProducer_Close(t *testing.T) {
+	producer := newFakeAsyncProducer()
+	ep, err := newEventProducer(producer, "events", ProducerConfig{BufferSize: 2})
+	require.NoError(t, err)
+
+	fillBuffer(t, ep, "first", "second")
+
+	closed := make(chan struct{})
+	go func() {
+		assert.NoError(t, ep.Close())
+		close(closed)
+	}()
+
+	// The buffered events are still sent after the producer is closed.
+	assert.Equal(t, "first", producer.receive(t))
+	assert.Equal(t, "second", producer.receive(t))
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("producer was not closed")
+	}
+}
+
+func TestEventProducer_Spool(t *testing.T) {
+	t.Run("undelivered events are replayed in order", func(t *testing.T) {
+		spool := newTestSpool(t, t.TempDir())
+		producer := newFakeAsyncProducer()
+		producer.down.Store(true)
+		producer.serve(t)
+		ep, err := newEventProducer(producer, "events", ProducerConfig{
+			BufferSize:     10,
+			Spool:          spool,
+			ReplayInterval: 10 * time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, ep.Produce(&domain.Event{ShortURL: "first"}))
+		require.Eventually(t, func() bool { return spool.Len() == 1 }, time.Second, time.Millisecond)
+
+		// The spool is not empty, so the new events are appended to it behind the undelivered one.
+		require.NoError(t, ep.Produce(&domain.Event{ShortURL: "second"}))
+		require.NoError(t, ep.Produce(&domain.Event{ShortURL: "third"}))
+		require.Eventually(t, func() bool { return spool.Len() == 3 }, time.Second, time.Millisecond)
+		assert.Empty(t, producer.deliveredEvents())
+
+		producer.down.Store(false)
+
+		require.Eventually(t, func() bool { return spool.Len() == 0 }, time.Second, time.Millisecond)
+		// A batch in flight when the broker came back may be delivered partially and is then sent again,
+		// the last replay delivers all the events in order.
+		delivered := producer.deliveredEvents()
+		require.GreaterOrEqual(t, len(delivered), 3)
+		assert.Equal(t, []string{"first", "second", "third"}, delivered[len(delivered)-3:])
+		assert.Equal(t, int64(3), spool.Stats().Replayed)
+		require.NoError(t, ep.Close())
+	})
+
+	t.Run("spooled events survive restart", func(t *testing.T) {
+		dir := t.TempDir()
+		producer := newFakeAsyncProducer()
+		producer.down.Store(true)
+		producer.serve(t)
+		ep, err := newEventProducer(producer, "events", ProducerConfig{BufferSize: 10, Spool: newTestSpool(t, dir)})
+		require.NoError(t, err)
+
+		require.NoError(t, ep.Produce(&domain.Event{ShortURL: "persisted"}))
+		require.Eventually(t, func() bool { return ep.spool.Len() == 1 }, time.Second, time.Millisecond)
+		require.NoError(t, ep.Close())
+
+		producer = newFakeAsyncProducer()
+		producer.serve(t)
+		ep, err = newEventProducer(producer, "events", ProducerConfig{
+			BufferSize:     10,
+			Spool:          newTestSpool(t, dir),
+			ReplayInterval: 10 * time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool { return ep.spool.Len() == 0 }, time.Second, time.Millisecond)
+		assert.Equal(t, []string{"persisted"}, producer.deliveredEvents())
+		require.NoError(t, ep.Close())
+	})
+
+	t.Run("spill overflow", func(t *testing.T) {
+		spool := newTestSpool(t, t.TempDir())
+		producer := newFakeAsyncProducer()
+		ep, err := newEventProducer(producer, "events", ProducerConfig{BufferSize: 1, Overflow: Spill, Spool: spool})
+		require.NoError(t, err)
+
+		fillBuffer(t, ep, "sending", "buffered")
+		require.NoError(t, ep.Produce(&domain.Event{ShortURL: "spilled"}))
+		assert.Equal(t, int64(1), spool.Stats().Appended)
+
+		go func() {
+			for range producer.input {
+			}
+		}()
+		require.NoError(t, ep.Close())
+	})
+}
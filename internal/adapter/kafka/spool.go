@@ -0,0 +1,645 @@
+package kafka
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyncPolicy defines when the records appended to the spool are flushed to the disk.
+type SyncPolicy string
+
+const (
+	// SyncAlways flushes every record before Append returns.
+	SyncAlways SyncPolicy = "always"
+	// SyncInterval flushes the records periodically, a crash loses at most one interval of records.
+	SyncInterval SyncPolicy = "interval"
+	// SyncNever leaves flushing to the operating system.
+	SyncNever SyncPolicy = "never"
+)
+
+const (
+	spoolSegmentExt = ".spool"
+	// spoolHeaderSize is the size of the record header: the payload length and its CRC-32 checksum.
+	spoolHeaderSize = 8
+	// spoolCheckpointFile keeps the acknowledged position: the first segment ID, the offset of its first
+	// unacknowledged record and their CRC-32 checksum.
+	spoolCheckpointFile = "checkpoint"
+	spoolCheckpointSize = 20
+)
+
+// errSpoolFull is returned when the record doesn't fit into the size cap of the spool.
+var errSpoolFull = errors.New("spool is full")
+
+// SpoolConfig describes the location and the limits of the spool.
+type SpoolConfig struct {
+	// Dir is the directory with the segment files of the spool.
+	Dir string
+	// MaxBytes caps the total size of the segment files.
+	MaxBytes int64
+	// SegmentBytes is the size after which a new segment file is started.
+	SegmentBytes int64
+	// Sync is the policy of flushing the records to the disk.
+	Sync SyncPolicy
+	// SyncInterval is how often the records are flushed with the SyncInterval policy.
+	SyncInterval time.Duration
+}
+
+// SpoolStats contains the state and the counters of the spool.
+type SpoolStats struct {
+	// Records and Bytes describe the records waiting to be replayed.
+	Records int64 `json:"records"`
+	Bytes   int64 `json:"bytes"`
+	// Appended, Replayed and Rejected are counted since the spool was opened.
+	Appended int64 `json:"appended"`
+	Replayed int64 `json:"replayed"`
+	Rejected int64 `json:"rejected"`
+}
+
+// spoolSegment is a file with the records of the spool.
+type spoolSegment struct {
+	id      int64
+	size    int64
+	records int64
+}
+
+// Spool is a disk-backed FIFO queue of records. The records are appended to the last segment file
+// and read from the first one, the segments are removed once all their records are acknowledged.
+// Every record is stored with its checksum, so a record torn by a crash is discarded on open.
+// The acknowledged position is saved to the checkpoint file and flushed with the same sync policy as the
+// records, so the records acknowledged before a restart are not replayed again.
+type Spool struct {
+	cfg SpoolConfig
+
+	mu       sync.Mutex
+	segments []spoolSegment
+	// nextID is the ID of the next segment, the IDs are never reused so a stale checkpoint can't match.
+	nextID     int64
+	writer     *os.File
+	reader     *os.File
+	checkpoint *os.File
+	// readOffset is the position of the first unacknowledged record in the first segment.
+	readOffset int64
+	// records and bytes count the unacknowledged records.
+	records int64
+	bytes   int64
+	// size is the total size of the segment files.
+	size  int64
+	stats SpoolStats
+	dirty bool
+	// checkpointDirty is set when the checkpoint is written but not flushed.
+	checkpointDirty bool
+
+	stop    chan struct{}
+	syncing sync.WaitGroup
+}
+
+// NewSpool opens the spool in the directory, creating it if needed. The records left by the previous run
+// are kept for replay.
+func NewSpool(cfg SpoolConfig) (*Spool, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("spool directory is required")
+	}
+
+	if cfg.MaxBytes <= 0 || cfg.SegmentBytes <= 0 {
+		return nil, errors.New("spool size limits must be positive")
+	}
+
+	switch cfg.Sync {
+	case "":
+		cfg.Sync = SyncInterval
+	case SyncAlways, SyncInterval, SyncNever:
+	default:
+		return nil, fmt.Errorf("unknown spool sync policy %q", cfg.Sync)
+	}
+
+	if cfg.Sync == SyncInterval && cfg.SyncInterval <= 0 {
+		cfg.SyncInterval = time.Second
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	s := &Spool{cfg: cfg, stop: make(chan struct{})}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Sync == SyncInterval {
+		s.syncing.Add(1)
+		go s.syncPeriodically()
+	}
+
+	return s, nil
+}
+
+// Append adds the record to the end of the spool.
+func (s *Spool) Append(record []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size := int64(spoolHeaderSize + len(record))
+	if s.size+size > s.cfg.MaxBytes {
+		s.stats.Rejected++
+		return errSpoolFull
+	}
+
+	if s.writer == nil || s.segments[len(s.segments)-1].size >= s.cfg.SegmentBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	buf := make([]byte, size)
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(record)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(record))
+	copy(buf[spoolHeaderSize:], record)
+	if _, err := s.writer.Write(buf); err != nil {
+		return fmt.Errorf("failed to write spool record: %w", err)
+	}
+
+	if s.cfg.Sync == SyncAlways {
+		if err := s.writer.Sync(); err != nil {
+			return fmt.Errorf("failed to sync spool: %w", err)
+		}
+	} else {
+		s.dirty = true
+	}
+
+	last := &s.segments[len(s.segments)-1]
+	last.size += size
+	last.records++
+	s.size += size
+	s.records++
+	s.bytes += size
+	s.stats.Appended++
+
+	return nil
+}
+
+// Peek returns up to n oldest records without removing them from the spool.
+func (s *Spool) Peek(n int) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([][]byte, 0, min(int64(n), s.records))
+	segment, offset := 0, s.readOffset
+	for len(records) < n && segment < len(s.segments) {
+		if offset >= s.segments[segment].size {
+			segment, offset = segment+1, 0
+			continue
+		}
+
+		record, err := s.readRecord(s.segments[segment].id, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+		offset += int64(spoolHeaderSize + len(record))
+	}
+
+	return records, nil
+}
+
+// Ack removes n oldest records from the spool. The segments without unacknowledged records are deleted.
+func (s *Spool) Ack(n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for acked := 0; acked < n; {
+		if s.records == 0 {
+			return errors.New("no records to acknowledge")
+		}
+
+		first := s.segments[0]
+		if s.readOffset >= first.size {
+			if err := s.removeFirst(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		header, err := s.readHeader(first.id, s.readOffset)
+		if err != nil {
+			return err
+		}
+
+		size := int64(spoolHeaderSize) + int64(binary.BigEndian.Uint32(header[0:4]))
+		s.readOffset += size
+		s.records--
+		s.bytes -= size
+		s.stats.Replayed++
+		acked++
+	}
+
+	// The fully acknowledged segments are removed right away to free the disk space.
+	for len(s.segments) > 0 && s.readOffset >= s.segments[0].size && (len(s.segments) > 1 || s.records == 0) {
+		if err := s.removeFirst(); err != nil {
+			return err
+		}
+	}
+
+	return s.saveCheckpoint()
+}
+
+// Len returns the number of records waiting in the spool.
+func (s *Spool) Len() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.records
+}
+
+// Stats returns the state and the counters of the spool.
+func (s *Spool) Stats() SpoolStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.Records = s.records
+	stats.Bytes = s.bytes
+	return stats
+}
+
+// Sync flushes the appended records to the disk.
+func (s *Spool) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sync()
+}
+
+// Close flushes the records and closes the segment files.
+func (s *Spool) Close() error {
+	close(s.stop)
+	s.syncing.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.sync()
+	if s.writer != nil {
+		err = errors.Join(err, s.writer.Close())
+		s.writer = nil
+	}
+	if s.reader != nil {
+		err = errors.Join(err, s.reader.Close())
+		s.reader = nil
+	}
+	if s.checkpoint != nil {
+		err = errors.Join(err, s.checkpoint.Close())
+		s.checkpoint = nil
+	}
+
+	return err
+}
+
+// load finds the segments left by the previous run and resumes from its checkpoint. A record torn by a crash
+// ends the segment, the rest of its file is truncated.
+func (s *Spool) load() error {
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, spoolSegmentExt) {
+			continue
+		}
+
+		id, err := strconv.ParseInt(strings.TrimSuffix(name, spoolSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		segment, err := s.scanSegment(id)
+		if err != nil {
+			return err
+		}
+
+		if segment.records == 0 {
+			if err := os.Remove(s.segmentPath(id)); err != nil {
+				return fmt.Errorf("failed to remove empty spool segment: %w", err)
+			}
+			continue
+		}
+
+		s.segments = append(s.segments, segment)
+		s.size += segment.size
+		s.records += segment.records
+		s.nextID = max(s.nextID, id+1)
+	}
+
+	slices.SortFunc(s.segments, func(a, b spoolSegment) int { return cmp.Compare(a.id, b.id) })
+	s.bytes = s.size
+
+	return s.loadCheckpoint()
+}
+
+// loadCheckpoint skips the records of the first segment acknowledged by the previous run. A torn checkpoint or
+// a checkpoint of a removed segment is ignored, the records are replayed then as before the checkpoint existed.
+func (s *Spool) loadCheckpoint() error {
+	f, err := os.OpenFile(filepath.Join(s.cfg.Dir, spoolCheckpointFile), os.O_CREATE|os.O_RDWR, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open spool checkpoint: %w", err)
+	}
+	s.checkpoint = f
+
+	buf := make([]byte, spoolCheckpointSize)
+	if _, err := f.ReadAt(buf, 0); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read spool checkpoint: %w", err)
+	}
+
+	id := int64(binary.BigEndian.Uint64(buf[0:8]))
+	offset := int64(binary.BigEndian.Uint64(buf[8:16]))
+	valid := crc32.ChecksumIEEE(buf[0:16]) == binary.BigEndian.Uint32(buf[16:20])
+	if valid {
+		s.nextID = max(s.nextID, id+1)
+	}
+
+	if valid && len(s.segments) > 0 && s.segments[0].id == id && offset > 0 && offset <= s.segments[0].size {
+		records, bytes, err := s.countRecords(id, offset)
+		if err != nil {
+			return err
+		}
+
+		if bytes == offset {
+			s.readOffset = offset
+			s.records -= records
+			s.bytes -= bytes
+		} else {
+			log.Warnf("Ignoring spool checkpoint at offset %d of segment %d between records", offset, id)
+		}
+	}
+
+	if len(s.segments) > 0 && s.readOffset >= s.segments[0].size {
+		if err := s.removeFirst(); err != nil {
+			return err
+		}
+	}
+
+	// The checkpoint is rewritten with the loaded position, so the next run doesn't depend on the old one.
+	if err := s.saveCheckpoint(); err != nil {
+		return err
+	}
+
+	return s.sync()
+}
+
+// countRecords counts the records of the segment before the offset. The returned size is the offset of the
+// first record at or after the offset.
+func (s *Spool) countRecords(id, offset int64) (int64, int64, error) {
+	var records, bytes int64
+	for bytes < offset {
+		header, err := s.readHeader(id, bytes)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		bytes += int64(spoolHeaderSize) + int64(binary.BigEndian.Uint32(header[0:4]))
+		records++
+	}
+
+	return records, bytes, nil
+}
+
+// saveCheckpoint writes the acknowledged position to the checkpoint file. It is flushed right away with the
+// SyncAlways policy and together with the records otherwise.
+func (s *Spool) saveCheckpoint() error {
+	var id int64
+	if len(s.segments) > 0 {
+		id = s.segments[0].id
+	}
+
+	buf := make([]byte, spoolCheckpointSize)
+	binary.BigEndian.PutUint64(buf[0:8], uint64(id))
+	binary.BigEndian.PutUint64(buf[8:16], uint64(s.readOffset))
+	binary.BigEndian.PutUint32(buf[16:20], crc32.ChecksumIEEE(buf[0:16]))
+	if _, err := s.checkpoint.WriteAt(buf, 0); err != nil {
+		return fmt.Errorf("failed to write spool checkpoint: %w", err)
+	}
+
+	if s.cfg.Sync == SyncAlways {
+		if err := s.checkpoint.Sync(); err != nil {
+			return fmt.Errorf("failed to sync spool checkpoint: %w", err)
+		}
+	} else {
+		s.checkpointDirty = true
+	}
+
+	return nil
+}
+
+// scanSegment counts the valid records of the segment and truncates the file after the last of them.
+func (s *Spool) scanSegment(id int64) (spoolSegment, error) {
+	segment := spoolSegment{id: id}
+	f, err := os.OpenFile(s.segmentPath(id), os.O_RDWR, 0)
+	if err != nil {
+		return segment, fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return segment, fmt.Errorf("failed to stat spool segment: %w", err)
+	}
+
+	header := make([]byte, spoolHeaderSize)
+	for {
+		if _, err := f.ReadAt(header, segment.size); err != nil {
+			break
+		}
+
+		// A corrupted length must not make the spool allocate more than the file holds.
+		length := int64(binary.BigEndian.Uint32(header[0:4]))
+		if segment.size+spoolHeaderSize+length > info.Size() {
+			break
+		}
+
+		record := make([]byte, length)
+		if _, err := f.ReadAt(record, segment.size+spoolHeaderSize); err != nil {
+			break
+		}
+
+		if crc32.ChecksumIEEE(record) != binary.BigEndian.Uint32(header[4:8]) {
+			break
+		}
+
+		segment.size += spoolHeaderSize + length
+		segment.records++
+	}
+
+	if info.Size() > segment.size {
+		log.Warnf("Truncating torn spool segment %d from %d to %d bytes", id, info.Size(), segment.size)
+		if err := f.Truncate(segment.size); err != nil {
+			return segment, fmt.Errorf("failed to truncate spool segment: %w", err)
+		}
+	}
+
+	return segment, nil
+}
+
+// rotate starts a new segment for the appended records.
+func (s *Spool) rotate() error {
+	if s.writer != nil {
+		if err := s.sync(); err != nil {
+			return err
+		}
+
+		if err := s.writer.Close(); err != nil {
+			return fmt.Errorf("failed to close spool segment: %w", err)
+		}
+		s.writer = nil
+	}
+
+	id := s.nextID
+	f, err := os.OpenFile(s.segmentPath(id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to create spool segment: %w", err)
+	}
+
+	s.writer = f
+	s.segments = append(s.segments, spoolSegment{id: id})
+	s.nextID++
+	return nil
+}
+
+// removeFirst deletes the first segment after all its records are acknowledged.
+func (s *Spool) removeFirst() error {
+	first := s.segments[0]
+	if s.reader != nil {
+		if err := s.reader.Close(); err != nil {
+			return fmt.Errorf("failed to close spool segment: %w", err)
+		}
+		s.reader = nil
+	}
+
+	if len(s.segments) == 1 && s.writer != nil {
+		if err := s.writer.Close(); err != nil {
+			return fmt.Errorf("failed to close spool segment: %w", err)
+		}
+		s.writer = nil
+		s.dirty = false
+	}
+
+	if err := os.Remove(s.segmentPath(first.id)); err != nil {
+		return fmt.Errorf("failed to remove spool segment: %w", err)
+	}
+
+	s.segments = s.segments[1:]
+	s.size -= first.size
+	s.readOffset = 0
+	return nil
+}
+
+// readHeader reads the header of the record at the offset of the segment.
+func (s *Spool) readHeader(id, offset int64) ([]byte, error) {
+	f, err := s.segmentReader(id)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, spoolHeaderSize)
+	if _, err := f.ReadAt(header, offset); err != nil {
+		return nil, fmt.Errorf("failed to read spool record: %w", err)
+	}
+
+	return header, nil
+}
+
+// readRecord reads the payload of the record at the offset of the segment.
+func (s *Spool) readRecord(id, offset int64) ([]byte, error) {
+	header, err := s.readHeader(id, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	record := make([]byte, binary.BigEndian.Uint32(header[0:4]))
+	if _, err := s.reader.ReadAt(record, offset+spoolHeaderSize); err != nil {
+		return nil, fmt.Errorf("failed to read spool record: %w", err)
+	}
+
+	return record, nil
+}
+
+// segmentReader returns the file of the segment opened for reading. The last read segment is kept open.
+func (s *Spool) segmentReader(id int64) (*os.File, error) {
+	path := s.segmentPath(id)
+	if s.reader != nil && s.reader.Name() == path {
+		return s.reader, nil
+	}
+
+	if s.reader != nil {
+		if err := s.reader.Close(); err != nil {
+			return nil, fmt.Errorf("failed to close spool segment: %w", err)
+		}
+		s.reader = nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spool segment: %w", err)
+	}
+
+	s.reader = f
+	return f, nil
+}
+
+// sync flushes the checkpoint and the last segment if they have unflushed changes.
+func (s *Spool) sync() error {
+	if s.checkpointDirty {
+		if err := s.checkpoint.Sync(); err != nil {
+			return fmt.Errorf("failed to sync spool checkpoint: %w", err)
+		}
+		s.checkpointDirty = false
+	}
+
+	if !s.dirty || s.writer == nil {
+		return nil
+	}
+
+	if err := s.writer.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool: %w", err)
+	}
+
+	s.dirty = false
+	return nil
+}
+
+// syncPeriodically flushes the records with the SyncInterval policy until the spool is closed.
+func (s *Spool) syncPeriodically() {
+	defer s.syncing.Done()
+
+	ticker := time.NewTicker(s.cfg.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.Sync(); err != nil {
+				log.Errorf("Failed to sync spool: %v", err)
+			}
+		}
+	}
+}
+
+// segmentPath returns the path of the segment file.
+func (s *Spool) segmentPath(id int64) string {
+	return filepath.Join(s.cfg.Dir, fmt.Sprintf("%020d%s", id, spoolSegmentExt))
+}
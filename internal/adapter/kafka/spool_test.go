@@ -0,0 +1,199 @@
+package kafka
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSpool opens a spool with tiny segments, so that every record starts a new one.
+func newTestSpool(t *testing.T, dir string) *Spool {
+	t.Helper()
+
+	spool, err := NewSpool(SpoolConfig{Dir: dir, MaxBytes: 1 << 20, SegmentBytes: 1, Sync: SyncAlways})
+	require.NoError(t, err)
+	return spool
+}
+
+// segmentFiles returns the names of the segment files in the directory.
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"+spoolSegmentExt))
+	require.NoError(t, err)
+	return files
+}
+
+func TestSpool_AppendPeekAck(t *testing.T) {
+	dir := t.TempDir()
+	spool := newTestSpool(t, dir)
+
+	for _, record := range []string{"first", "second", "third"} {
+		require.NoError(t, spool.Append([]byte(record)))
+	}
+	assert.Equal(t, int64(3), spool.Len())
+	assert.Len(t, segmentFiles(t, dir), 3)
+
+	records, err := spool.Peek(2)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("first"), []byte("second")}, records)
+
+	require.NoError(t, spool.Ack(2))
+	assert.Equal(t, int64(1), spool.Len())
+	assert.Len(t, segmentFiles(t, dir), 1)
+
+	records, err = spool.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("third")}, records)
+
+	require.NoError(t, spool.Ack(1))
+	assert.Empty(t, segmentFiles(t, dir))
+	require.Error(t, spool.Ack(1))
+
+	stats := spool.Stats()
+	assert.Equal(t, SpoolStats{Appended: 3, Replayed: 3}, stats)
+	require.NoError(t, spool.Close())
+}
+
+func TestSpool_Segments(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(SpoolConfig{Dir: dir, MaxBytes: 1 << 20, SegmentBytes: 1 << 10})
+	require.NoError(t, err)
+
+	for _, record := range []string{"first", "second", "third"} {
+		require.NoError(t, spool.Append([]byte(record)))
+	}
+	assert.Len(t, segmentFiles(t, dir), 1)
+
+	require.NoError(t, spool.Ack(1))
+	require.NoError(t, spool.Append([]byte("fourth")))
+
+	records, err := spool.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("second"), []byte("third"), []byte("fourth")}, records)
+	require.NoError(t, spool.Close())
+}
+
+func TestSpool_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	spool := newTestSpool(t, dir)
+	for _, record := range []string{"first", "second", "third"} {
+		require.NoError(t, spool.Append([]byte(record)))
+	}
+	require.NoError(t, spool.Ack(1))
+	require.NoError(t, spool.Close())
+
+	spool = newTestSpool(t, dir)
+	records, err := spool.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("second"), []byte("third")}, records)
+
+	require.NoError(t, spool.Append([]byte("fourth")))
+	records, err = spool.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("second"), []byte("third"), []byte("fourth")}, records)
+	require.NoError(t, spool.Close())
+}
+
+func TestSpool_ReopenPartlyAcknowledgedSegment(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(SpoolConfig{Dir: dir, MaxBytes: 1 << 20, SegmentBytes: 1 << 10, Sync: SyncNever})
+	require.NoError(t, err)
+	for _, record := range []string{"first", "second", "third"} {
+		require.NoError(t, spool.Append([]byte(record)))
+	}
+	require.NoError(t, spool.Ack(2))
+	require.NoError(t, spool.Close())
+	require.Len(t, segmentFiles(t, dir), 1)
+
+	spool = newTestSpool(t, dir)
+	assert.Equal(t, int64(1), spool.Len())
+	assert.Equal(t, int64(spoolHeaderSize+len("third")), spool.Stats().Bytes)
+	records, err := spool.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("third")}, records)
+
+	require.NoError(t, spool.Ack(1))
+	assert.Empty(t, segmentFiles(t, dir))
+	require.NoError(t, spool.Append([]byte("fourth")))
+	require.NoError(t, spool.Close())
+
+	// The checkpoint of the removed segment doesn't apply to the new one.
+	spool = newTestSpool(t, dir)
+	records, err = spool.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("fourth")}, records)
+	require.NoError(t, spool.Close())
+}
+
+func TestSpool_TornCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(SpoolConfig{Dir: dir, MaxBytes: 1 << 20, SegmentBytes: 1 << 10, Sync: SyncAlways})
+	require.NoError(t, err)
+	for _, record := range []string{"first", "second"} {
+		require.NoError(t, spool.Append([]byte(record)))
+	}
+	require.NoError(t, spool.Ack(1))
+	require.NoError(t, spool.Close())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, spoolCheckpointFile), []byte{1, 2, 3}, 0o640))
+
+	// The acknowledged record is replayed again rather than lost.
+	spool = newTestSpool(t, dir)
+	records, err := spool.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("first"), []byte("second")}, records)
+	require.NoError(t, spool.Close())
+}
+
+func TestSpool_TornRecord(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(SpoolConfig{Dir: dir, MaxBytes: 1 << 20, SegmentBytes: 1 << 10, Sync: SyncNever})
+	require.NoError(t, err)
+	require.NoError(t, spool.Append([]byte("complete")))
+	require.NoError(t, spool.Close())
+
+	files := segmentFiles(t, dir)
+	require.Len(t, files, 1)
+	f, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	// The header of a record that was not fully written before the crash.
+	_, err = f.Write([]byte{0, 0, 0, 100, 1, 2, 3, 4, 'x'})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	spool = newTestSpool(t, dir)
+	records, err := spool.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("complete")}, records)
+
+	info, err := os.Stat(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, int64(spoolHeaderSize+len("complete")), info.Size())
+	require.NoError(t, spool.Close())
+}
+
+func TestSpool_MaxBytes(t *testing.T) {
+	spool, err := NewSpool(SpoolConfig{Dir: t.TempDir(), MaxBytes: 2 * (spoolHeaderSize + 5), SegmentBytes: 1 << 10})
+	require.NoError(t, err)
+
+	require.NoError(t, spool.Append([]byte("first")))
+	require.NoError(t, spool.Append([]byte("other")))
+	require.ErrorIs(t, spool.Append([]byte("third")), errSpoolFull)
+	assert.Equal(t, int64(1), spool.Stats().Rejected)
+	require.NoError(t, spool.Close())
+}
+
+func TestNewSpool_InvalidConfig(t *testing.T) {
+	_, err := NewSpool(SpoolConfig{MaxBytes: 1, SegmentBytes: 1})
+	require.Error(t, err)
+
+	_, err = NewSpool(SpoolConfig{Dir: t.TempDir()})
+	require.Error(t, err)
+
+	_, err = NewSpool(SpoolConfig{Dir: t.TempDir(), MaxBytes: 1, SegmentBytes: 1, Sync: "sometimes"})
+	require.Error(t, err)
+}
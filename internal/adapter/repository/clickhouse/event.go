@@ -5,8 +5,31 @@ import (
 	"database/sql"
 	"fmt"
 	"min/internal/core/domain"
+	"strings"
 )
 
+// rollup is a table with the clicks and visitors of the short URLs aggregated by the time buckets.
+type rollup struct {
+	table string
+	// bucket is the column with the start of the bucket.
+	bucket string
+	// start is the function returning the start of the bucket of the time.
+	start string
+}
+
+// Rollups are filled by the materialized views on every insert into the events table and are kept as long as
+// the raw events. Their rows hold uniqExact states of the event IDs and IPs, so the clicks and visitors computed
+// from them are exact.
+var (
+	hourly = rollup{table: "events_hourly", bucket: "hour", start: "toStartOfHour"}
+	daily  = rollup{table: "events_daily", bucket: "day", start: "toStartOfDay"}
+)
+
+// uniqueEvents is the source of the statistics queries that are not covered by the rollups. The duplicates of
+// the events are removed in the background, so FINAL is used to count every event exactly once regardless of
+// whether its parts are merged yet.
+const uniqueEvents = "events FINAL"
+
 type EventRepository struct {
 	db *sql.DB
 }
@@ -15,15 +38,69 @@ func NewEventRepository(db *sql.DB) *EventRepository {
 	return &EventRepository{db: db}
 }
 
-func (r *EventRepository) AddEvent(ctx context.Context, event domain.Event) error {
-	tx, err := r.db.Begin()
+// SetRetention sets the number of days the raw events and the rollups are kept for, zero days keeps them
+// forever. A table is altered only if its retention differs, since the alteration rewrites the existing parts.
+func (r *EventRepository) SetRetention(ctx context.Context, days int) error {
+	tables := []struct{ name, column string }{
+		{name: "events", column: "timestamp"},
+		{name: hourly.table, column: hourly.bucket},
+		{name: daily.table, column: daily.bucket},
+	}
+	for _, table := range tables {
+		if err := r.setTableRetention(ctx, table.name, table.column, days); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setTableRetention sets the TTL of the table by its time column.
+func (r *EventRepository) setTableRetention(ctx context.Context, table, column string, days int) error {
+	var engine string
+	err := r.db.QueryRowContext(
+		ctx,
+		"SELECT engine_full FROM system.tables WHERE database = currentDatabase() AND name = ?",
+		table,
+	).Scan(&engine)
+	if err != nil {
+		return fmt.Errorf("failed to query %s table: %w", table, err)
+	}
+
+	var alter string
+	switch {
+	case days > 0 && !strings.Contains(engine, fmt.Sprintf("TTL %s + toIntervalDay(%d)", column, days)):
+		alter = fmt.Sprintf("ALTER TABLE %s MODIFY TTL %s + INTERVAL %d DAY", table, column, days)
+	case days <= 0 && strings.Contains(engine, " TTL "):
+		alter = "ALTER TABLE " + table + " REMOVE TTL"
+	default:
+		return nil
+	}
+
+	if _, err := r.db.ExecContext(ctx, alter); err != nil {
+		return fmt.Errorf("failed to set %s retention: %w", table, err)
+	}
+
+	return nil
+}
+
+// AddEvents inserts the events in one transaction, the driver sends them to ClickHouse as a single block.
+// The events table keeps one row per event ID, so inserting the redelivered events again is harmless.
+func (r *EventRepository) AddEvents(ctx context.Context, events []domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
 	stmt, err := tx.PrepareContext(
 		ctx,
-		`INSERT INTO events (short_url, original_url, timestamp, user_agent, ip) VALUES (?, ?, ?, ?, ?)`,
+		`INSERT INTO events (id, short_url, original_url, owner_username, timestamp, user_agent, referrer, ip,
+			language, browser, os, device, bot, country, city)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 	)
 	if err != nil {
 		_ = tx.Rollback()
@@ -31,16 +108,28 @@ func (r *EventRepository) AddEvent(ctx context.Context, event domain.Event) erro
 	}
 	defer stmt.Close()
 
-	if _, err := stmt.ExecContext(
-		ctx,
-		event.ShortURL,
-		event.OriginalURL,
-		event.Timestamp,
-		event.UserAgent,
-		event.IP,
-	); err != nil {
-		_ = tx.Rollback()
-		return fmt.Errorf("failed to execute insert statement: %w", err)
+	for _, event := range events {
+		if _, err := stmt.ExecContext(
+			ctx,
+			event.ID,
+			event.ShortURL,
+			event.OriginalURL,
+			event.Owner,
+			event.Timestamp,
+			event.UserAgent,
+			event.Referrer,
+			event.IP,
+			event.Language,
+			event.Browser,
+			event.OS,
+			string(event.Device),
+			event.Bot,
+			event.Country,
+			event.City,
+		); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("failed to execute insert statement: %w", err)
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -49,3 +138,168 @@ func (r *EventRepository) AddEvent(ctx context.Context, event domain.Event) erro
 
 	return nil
 }
+
+// GetSummary returns the total number of clicks and unique visitors (by IP) for the short URL. It is computed
+// from the hourly rollup, so the range starts at the beginning of the hour of query.From.
+func (r *EventRepository) GetSummary(
+	ctx context.Context,
+	query domain.StatisticsQuery,
+) (*domain.ClickSummary, error) {
+	where, args := rollupFilter(query, hourly)
+	var summary domain.ClickSummary
+	err := r.db.QueryRowContext(
+		ctx,
+		"SELECT uniqExactMerge(clicks), uniqExactMerge(visitors) FROM "+hourly.table+" WHERE "+where,
+		args...,
+	).Scan(&summary.Clicks, &summary.UniqueVisitors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query summary: %w", err)
+	}
+
+	return &summary, nil
+}
+
+// GetTimeSeries returns the number of clicks on the short URL bucketed by hour or day. It is computed from the
+// rollup of the interval, so the first bucket is the one query.From falls into.
+func (r *EventRepository) GetTimeSeries(
+	ctx context.Context,
+	query domain.StatisticsQuery,
+) ([]domain.ClickBucket, error) {
+	rollup := daily
+	if query.Interval == domain.HOUR {
+		rollup = hourly
+	}
+
+	where, args := rollupFilter(query, rollup)
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+rollup.bucket+" AS bucket, uniqExactMerge(clicks) FROM "+rollup.table+" WHERE "+where+
+			" GROUP BY bucket ORDER BY bucket",
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query time series: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []domain.ClickBucket
+	for rows.Next() {
+		var b domain.ClickBucket
+		if err := rows.Scan(&b.Time, &b.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan time series: %w", err)
+		}
+		buckets = append(buckets, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read time series: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// GetTopReferrers returns the referrers with the most clicks on the short URL.
+func (r *EventRepository) GetTopReferrers(
+	ctx context.Context,
+	query domain.StatisticsQuery,
+) ([]domain.TopValue, error) {
+	return r.top(ctx, "referrer", query)
+}
+
+// GetTopUserAgents returns the user agents with the most clicks on the short URL.
+func (r *EventRepository) GetTopUserAgents(
+	ctx context.Context,
+	query domain.StatisticsQuery,
+) ([]domain.TopValue, error) {
+	return r.top(ctx, "user_agent", query)
+}
+
+// top returns the values of the column with the most clicks on the short URL.
+func (r *EventRepository) top(
+	ctx context.Context,
+	column string,
+	query domain.StatisticsQuery,
+) ([]domain.TopValue, error) {
+	where, args := filter(query)
+	args = append(args, query.Limit)
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+column+" AS value, count() AS clicks FROM "+uniqueEvents+" WHERE "+where+
+			" GROUP BY value ORDER BY clicks DESC, value LIMIT ?",
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top %s: %w", column, err)
+	}
+	defer rows.Close()
+
+	var values []domain.TopValue
+	for rows.Next() {
+		var v domain.TopValue
+		if err := rows.Scan(&v.Value, &v.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan top %s: %w", column, err)
+		}
+		values = append(values, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read top %s: %w", column, err)
+	}
+
+	return values, nil
+}
+
+// GetOwners returns the owners of the short URL, the owner of the latest events first. They are read from the
+// hourly rollup, which keeps a row per owner and hour instead of every event.
+func (r *EventRepository) GetOwners(ctx context.Context, short string) ([]string, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT owner_username FROM "+hourly.table+" WHERE short_url = ? GROUP BY owner_username ORDER BY max("+
+			hourly.bucket+") DESC",
+		short,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query owners: %w", err)
+	}
+	defer rows.Close()
+
+	var owners []string
+	for rows.Next() {
+		var owner string
+		if err := rows.Scan(&owner); err != nil {
+			return nil, fmt.Errorf("failed to scan owner: %w", err)
+		}
+		owners = append(owners, owner)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read owners: %w", err)
+	}
+
+	return owners, nil
+}
+
+// filter builds the WHERE clause selecting the events of the short URL in the query time range.
+func filter(query domain.StatisticsQuery) (string, []any) {
+	where := "short_url = ? AND timestamp >= ? AND timestamp < ?"
+	args := []any{query.ShortURL, query.From, query.To}
+	if query.Owner != "" {
+		where += " AND owner_username = ?"
+		args = append(args, query.Owner)
+	}
+
+	return where, args
+}
+
+// rollupFilter builds the WHERE clause selecting the rollup rows of the short URL whose buckets overlap with
+// the query time range.
+func rollupFilter(query domain.StatisticsQuery, rollup rollup) (string, []any) {
+	where := "short_url = ? AND " + rollup.bucket + " >= " + rollup.start + "(?) AND " + rollup.bucket + " < ?"
+	args := []any{query.ShortURL, query.From, query.To}
+	if query.Owner != "" {
+		where += " AND owner_username = ?"
+		args = append(args, query.Owner)
+	}
+
+	return where, args
+}
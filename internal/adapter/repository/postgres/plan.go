@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"min/internal/core/domain"
+	"time"
+)
+
+const selectPlans = `SELECT name, link_quota, max_link_lifetime, custom_aliases, rate_limit, rate_burst,
+	analytics_retention, is_default FROM plans`
+
+type PlanRepository struct {
+	db *sql.DB
+}
+
+func NewPlanRepository(db *sql.DB) *PlanRepository {
+	return &PlanRepository{db: db}
+}
+
+func (r *PlanRepository) Save(ctx context.Context, plan *domain.Plan) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // The error is irrelevant after a successful commit.
+
+	if plan.Default {
+		if _, err = tx.ExecContext(ctx, "UPDATE plans SET is_default = FALSE WHERE is_default"); err != nil {
+			return fmt.Errorf("error resetting default plan: %w", err)
+		}
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO plans (name, link_quota, max_link_lifetime, custom_aliases, rate_limit, rate_burst,
+			analytics_retention, is_default)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (name) DO NOTHING`,
+		plan.Name,
+		plan.Limits.LinkQuota,
+		int64(plan.Limits.MaxLinkLifetime/time.Second),
+		plan.Limits.CustomAliases,
+		plan.Limits.RateLimit,
+		plan.Limits.RateBurst,
+		int64(plan.Limits.AnalyticsRetention/time.Second),
+		plan.Default,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving plan: %w", err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting affected rows: %w", err)
+	}
+
+	if inserted == 0 {
+		return domain.ErrPlanExists
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing plan: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PlanRepository) Get(ctx context.Context, name domain.PlanName) (*domain.Plan, error) {
+	plan, err := scanPlan(r.db.QueryRowContext(ctx, selectPlans+" WHERE name = $1", name))
+	if err != nil {
+		return nil, fmt.Errorf("error getting plan: %w", err)
+	}
+
+	return plan, nil
+}
+
+func (r *PlanRepository) GetDefault(ctx context.Context) (*domain.Plan, error) {
+	plan, err := scanPlan(r.db.QueryRowContext(ctx, selectPlans+" WHERE is_default"))
+	if err != nil {
+		return nil, fmt.Errorf("error getting default plan: %w", err)
+	}
+
+	return plan, nil
+}
+
+func (r *PlanRepository) List(ctx context.Context) ([]*domain.Plan, error) {
+	rows, err := r.db.QueryContext(ctx, selectPlans+" ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("error listing plans: %w", err)
+	}
+	defer rows.Close()
+
+	var plans []*domain.Plan
+	for rows.Next() {
+		plan, err := scanPlan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning plan: %w", err)
+		}
+		plans = append(plans, plan)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating plans: %w", err)
+	}
+
+	return plans, nil
+}
+
+// scanPlan scans the plan selected with selectPlans. It returns nil if there are no rows.
+func scanPlan(row interface{ Scan(dest ...any) error }) (*domain.Plan, error) {
+	var plan domain.Plan
+	var maxLinkLifetime, analyticsRetention int64
+	err := row.Scan(
+		&plan.Name,
+		&plan.Limits.LinkQuota,
+		&maxLinkLifetime,
+		&plan.Limits.CustomAliases,
+		&plan.Limits.RateLimit,
+		&plan.Limits.RateBurst,
+		&analyticsRetention,
+		&plan.Default,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	plan.Limits.MaxLinkLifetime = time.Duration(maxLinkLifetime) * time.Second
+	plan.Limits.AnalyticsRetention = time.Duration(analyticsRetention) * time.Second
+
+	return &plan, nil
+}
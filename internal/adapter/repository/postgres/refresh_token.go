@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"min/internal/core/domain"
+)
+
+type RefreshTokenRepository struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenRepository(db *sql.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+func (r *RefreshTokenRepository) Save(ctx context.Context, token *domain.RefreshToken) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO refresh_tokens (token_hash, username, expires_at) VALUES ($1, $2, $3)",
+		token.Hash,
+		token.Username,
+		token.ExpiresAt.UTC(),
+	)
+
+	if err != nil {
+		return fmt.Errorf("error saving refresh token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepository) Take(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		"DELETE FROM refresh_tokens WHERE token_hash = $1 RETURNING token_hash, username, expires_at",
+		hash,
+	)
+	var token domain.RefreshToken
+	err := row.Scan(&token.Hash, &token.Username, &token.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error taking refresh token: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *RefreshTokenRepository) RemoveByUsername(ctx context.Context, username string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE username = $1", username)
+	if err != nil {
+		return fmt.Errorf("error removing refresh tokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type SequenceRepository struct {
+	db   *sql.DB
+	name string
+}
+
+// NewSequenceRepository creates a repository returning the numbers of the Postgres sequence with the given name.
+func NewSequenceRepository(db *sql.DB, name string) *SequenceRepository {
+	return &SequenceRepository{db: db, name: name}
+}
+
+func (r *SequenceRepository) Next(ctx context.Context) (int64, error) {
+	var n int64
+	if err := r.db.QueryRowContext(ctx, "SELECT nextval($1::regclass)", r.name).Scan(&n); err != nil {
+		return 0, fmt.Errorf("error getting next value of sequence %s: %w", r.name, err)
+	}
+
+	return n, nil
+}
@@ -3,9 +3,19 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"min/internal/core/domain"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// uniqueViolation is the code of the error returned by Postgres when a unique index is violated.
+const uniqueViolation = "23505"
+
 type URLRepository struct {
 	db *sql.DB
 }
@@ -16,29 +26,108 @@ func NewURLRepository(db *sql.DB) *URLRepository {
 	}
 }
 
-func (r *URLRepository) GetOriginal(ctx context.Context, short string) (string, error) {
-	var original string
-	err := r.db.QueryRowContext(ctx, "SELECT original_url FROM url WHERE short_url = $1", short).Scan(&original)
+func (r *URLRepository) Get(ctx context.Context, short string) (*domain.URL, error) {
+	var url domain.URL
+	var expiresAt sql.NullTime
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT short_url, original_url, owner_username, created_at, expires_at, redirect_code, forward_query
+		FROM url WHERE short_url = $1`,
+		short,
+	).Scan(&url.Short, &url.Original, &url.Owner, &url.CreatedAt, &expiresAt, &url.RedirectCode, &url.ForwardQuery)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return "", nil
+			return nil, nil
 		}
 
-		return "", err
+		return nil, err
 	}
 
-	return original, nil
+	url.ExpiresAt = expiresAt.Time
+	return &url, nil
 }
 
-func (r *URLRepository) Add(ctx context.Context, short, original string) error {
-	_, err := r.db.ExecContext(ctx, "INSERT INTO url (short_url, original_url) VALUES ($1, $2)", short, original)
+func (r *URLRepository) Add(ctx context.Context, url *domain.URL) error {
+	var expiresAt sql.NullTime
+	if !url.ExpiresAt.IsZero() {
+		expiresAt = sql.NullTime{Time: url.ExpiresAt.UTC(), Valid: true}
+	}
+
+	res, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO url (short_url, original_url, normalized_url, owner_username, expires_at, redirect_code, forward_query)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (short_url) DO NOTHING`,
+		url.Short,
+		url.Original,
+		url.Normalized,
+		url.Owner,
+		expiresAt,
+		url.RedirectCode,
+		url.ForwardQuery,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return domain.ErrShortURLExists
+		}
+
+		return err
+	}
+
+	inserted, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
 
+	if inserted == 0 {
+		return domain.ErrShortURLExists
+	}
+
 	return nil
 }
 
+func (r *URLRepository) FindByNormalized(
+	ctx context.Context,
+	match *domain.URL,
+	now time.Time,
+) (*domain.URL, error) {
+	var url domain.URL
+	var expiresAt sql.NullTime
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT short_url, original_url, normalized_url, owner_username, created_at, expires_at,
+		redirect_code, forward_query FROM url
+		WHERE owner_username = $1 AND normalized_url = $2 AND redirect_code = $3 AND forward_query = $4
+		AND (expires_at IS NULL OR expires_at > $5)
+		ORDER BY created_at DESC LIMIT 1`,
+		match.Owner,
+		match.Normalized,
+		match.RedirectCode,
+		match.ForwardQuery,
+		now.UTC(),
+	).Scan(
+		&url.Short,
+		&url.Original,
+		&url.Normalized,
+		&url.Owner,
+		&url.CreatedAt,
+		&expiresAt,
+		&url.RedirectCode,
+		&url.ForwardQuery,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	url.ExpiresAt = expiresAt.Time
+	return &url, nil
+}
+
 func (r *URLRepository) Remove(ctx context.Context, short string) error {
 	_, err := r.db.ExecContext(ctx, "DELETE FROM url WHERE short_url = $1", short)
 	if err != nil {
@@ -47,3 +136,122 @@ func (r *URLRepository) Remove(ctx context.Context, short string) error {
 
 	return nil
 }
+
+func (r *URLRepository) RemoveExpired(ctx context.Context, now time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM url WHERE expires_at <= $1", now.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// ListByOwner returns a page of URLs created by query.Owner. The pagination is keyset based:
+// the cursor holds the creation time and the id of the last URL of the previous page.
+func (r *URLRepository) ListByOwner(ctx context.Context, query domain.LinksQuery) (*domain.LinksPage, error) {
+	order, cmp := "ASC", ">"
+	if query.Desc {
+		order, cmp = "DESC", "<"
+	}
+
+	stmt := `SELECT id, short_url, original_url, owner_username, created_at, expires_at,
+	redirect_code, forward_query FROM url
+	WHERE owner_username = $1`
+	args := []any{query.Owner}
+
+	if query.Search != "" {
+		args = append(args, "%"+escapeLike(query.Search)+"%")
+		stmt += fmt.Sprintf(" AND original_url ILIKE $%d", len(args))
+	}
+
+	if query.Cursor != "" {
+		createdAt, id, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, createdAt, id)
+		stmt += fmt.Sprintf(" AND (created_at, id) %s ($%d, $%d)", cmp, len(args)-1, len(args))
+	}
+
+	// One extra row is fetched to find out whether there is a next page.
+	args = append(args, query.Limit+1)
+	stmt += fmt.Sprintf(" ORDER BY created_at %s, id %s LIMIT $%d", order, order, len(args))
+
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &domain.LinksPage{}
+	var lastID int64
+	for rows.Next() {
+		if len(page.Links) == query.Limit {
+			last := page.Links[len(page.Links)-1]
+			page.NextCursor = encodeCursor(last.CreatedAt, lastID)
+			break
+		}
+
+		var url domain.URL
+		var expiresAt sql.NullTime
+		err := rows.Scan(
+			&lastID,
+			&url.Short,
+			&url.Original,
+			&url.Owner,
+			&url.CreatedAt,
+			&expiresAt,
+			&url.RedirectCode,
+			&url.ForwardQuery,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		url.ExpiresAt = expiresAt.Time
+		page.Links = append(page.Links, &url)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// escapeLike escapes the special characters of the LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// encodeCursor encodes the position of the URL in the listing into an opaque cursor.
+func encodeCursor(createdAt time.Time, id int64) string {
+	raw := strconv.FormatInt(createdAt.UnixNano(), 10) + ":" + strconv.FormatInt(id, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor decodes the cursor created by encodeCursor.
+func decodeCursor(cursor string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, domain.ErrInvalidCursor
+	}
+
+	nanos, id, found := strings.Cut(string(raw), ":")
+	if !found {
+		return time.Time{}, 0, domain.ErrInvalidCursor
+	}
+
+	createdAt, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, domain.ErrInvalidCursor
+	}
+
+	lastID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, domain.ErrInvalidCursor
+	}
+
+	return time.Unix(0, createdAt).UTC(), lastID, nil
+}
@@ -37,11 +37,11 @@ func (r *UserRepository) Save(ctx context.Context, user *domain.User) error {
 func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
 	row := r.db.QueryRowContext(
 		ctx,
-		"SELECT username, password, role, plan_name, links_remaining FROM users WHERE username = $1",
+		"SELECT username, password, role, plan_name, links_remaining, token_version FROM users WHERE username = $1",
 		username,
 	)
 	var user domain.User
-	err := row.Scan(&user.Username, &user.Password, &user.Role, &user.Plan, &user.LinksRemaining)
+	err := row.Scan(&user.Username, &user.Password, &user.Role, &user.Plan, &user.LinksRemaining, &user.TokenVersion)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -67,3 +67,89 @@ func (r *UserRepository) ChangeLinksRemaining(ctx context.Context, username stri
 
 	return nil
 }
+
+func (r *UserRepository) ConsumeLinkQuota(ctx context.Context, username string) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE users SET links_remaining = links_remaining - 1 WHERE username = $1 AND links_remaining > 0",
+		username,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error consuming link quota: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting affected rows: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+func (r *UserRepository) RefundLinkQuota(ctx context.Context, username string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE users SET links_remaining = links_remaining + 1 WHERE username = $1",
+		username,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error refunding link quota: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) IncrementTokenVersion(ctx context.Context, username string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE users SET token_version = token_version + 1 WHERE username = $1",
+		username,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error incrementing token version: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) ChangePassword(ctx context.Context, username, password string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE users SET password = $2, token_version = token_version + 1 WHERE username = $1",
+		username,
+		password,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error changing password: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) ChangePlan(ctx context.Context, username string, plan *domain.Plan) (bool, error) {
+	// The right-hand sides see the old row, so the subquery returns the quota of the previous plan.
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE users SET
+			links_remaining = GREATEST(0, links_remaining + $2 -
+				COALESCE((SELECT link_quota FROM plans WHERE name = users.plan_name), 0)),
+			plan_name = $3
+		WHERE username = $1`,
+		username,
+		plan.Limits.LinkQuota,
+		plan.Name,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error changing plan: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting affected rows: %w", err)
+	}
+
+	return affected == 1, nil
+}
@@ -2,10 +2,25 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"github.com/redis/go-redis/v9"
+	"min/internal/core/domain"
+	"time"
 )
 
+// cachedURL is a representation of the URL stored in the cache.
+type cachedURL struct {
+	Original  string    `json:"original"`
+	Owner     string    `json:"owner"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// RedirectCode and ForwardQuery are missing in the entries cached before they were added,
+	// such links redirect with the default settings.
+	RedirectCode int  `json:"redirect_code,omitempty"`
+	ForwardQuery bool `json:"forward_query,omitempty"`
+}
+
 type URLRepository struct {
 	client *redis.Client
 }
@@ -16,21 +31,56 @@ func NewURLRepository(client *redis.Client) *URLRepository {
 	}
 }
 
-func (r *URLRepository) GetOriginal(ctx context.Context, short string) (string, error) {
-	original, err := r.client.Get(ctx, short).Result()
+// Get returns the cached URL. Entries that can not be decoded are treated as missing.
+func (r *URLRepository) Get(ctx context.Context, short string) (*domain.URL, error) {
+	data, err := r.client.Get(ctx, short).Bytes()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			return "", nil
+			return nil, nil
 		}
 
-		return "", err
+		return nil, err
+	}
+
+	var cached cachedURL
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, nil //nolint:nilerr // Entries written in the old format are refreshed from the repository.
 	}
 
-	return original, nil
+	return &domain.URL{
+		Short:        short,
+		Original:     cached.Original,
+		Owner:        cached.Owner,
+		CreatedAt:    cached.CreatedAt,
+		ExpiresAt:    cached.ExpiresAt,
+		RedirectCode: cached.RedirectCode,
+		ForwardQuery: cached.ForwardQuery,
+	}, nil
 }
 
-func (r *URLRepository) Add(ctx context.Context, short, original string) error {
-	_, err := r.client.Set(ctx, short, original, 0).Result()
+// Add caches the URL until the link expires. Links without expiration are cached without TTL.
+func (r *URLRepository) Add(ctx context.Context, url *domain.URL) error {
+	var ttl time.Duration
+	if !url.ExpiresAt.IsZero() {
+		ttl = time.Until(url.ExpiresAt)
+		if ttl <= 0 {
+			return nil
+		}
+	}
+
+	data, err := json.Marshal(cachedURL{
+		Original:     url.Original,
+		Owner:        url.Owner,
+		CreatedAt:    url.CreatedAt,
+		ExpiresAt:    url.ExpiresAt,
+		RedirectCode: url.RedirectCode,
+		ForwardQuery: url.ForwardQuery,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.Set(ctx, url.Short, data, ttl).Result()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,44 @@
+package domain
+
+import "errors"
+
+var (
+	// ErrShortURLExists is returned when the requested short URL is already taken.
+	ErrShortURLExists = errors.New("short URL already exists")
+	// ErrShortURLNotFound is returned when the requested short URL does not exist.
+	ErrShortURLNotFound = errors.New("short URL not found")
+	// ErrForbidden is returned when the user is not allowed to perform the action.
+	ErrForbidden = errors.New("forbidden")
+	// ErrInvalidURL is returned when the original URL is not an absolute URL.
+	ErrInvalidURL = errors.New("invalid URL")
+	// ErrInvalidAlias is returned when the requested alias is malformed or reserved.
+	ErrInvalidAlias = errors.New("invalid alias")
+	// ErrURLExpired is returned when the requested short URL has expired.
+	ErrURLExpired = errors.New("short URL has expired")
+	// ErrInvalidCursor is returned when the pagination cursor is malformed.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidStatisticsQuery is returned when the statistics query parameters are malformed.
+	ErrInvalidStatisticsQuery = errors.New("invalid statistics query")
+	// ErrInvalidRefreshToken is returned when the refresh token is unknown, expired or already used.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrInvalidPassword is returned when the password of the user doesn't match.
+	ErrInvalidPassword = errors.New("invalid password")
+	// ErrTokenRevoked is returned when the access token was issued before the user logged out.
+	ErrTokenRevoked = errors.New("token has been revoked")
+	// ErrLinkQuotaExceeded is returned when the user has no links remaining.
+	ErrLinkQuotaExceeded = errors.New("no links remaining, please upgrade your account or remove some existing links")
+	// ErrInvalidExpiration is returned when the requested expiration time is not in the future.
+	ErrInvalidExpiration = errors.New("invalid expiration time")
+	// ErrInvalidRedirectCode is returned when the requested redirect status code is not supported.
+	ErrInvalidRedirectCode = errors.New("invalid redirect code")
+	// ErrEventDropped is returned when the event can't be accepted for publishing.
+	ErrEventDropped = errors.New("event dropped")
+	// ErrPlanNotFound is returned when the requested billing plan does not exist.
+	ErrPlanNotFound = errors.New("plan not found")
+	// ErrPlanExists is returned when a billing plan with the same name already exists.
+	ErrPlanExists = errors.New("plan already exists")
+	// ErrInvalidPlan is returned when the billing plan limits are malformed.
+	ErrInvalidPlan = errors.New("invalid plan")
+	// ErrUserNotFound is returned when the requested user does not exist.
+	ErrUserNotFound = errors.New("user not found")
+)
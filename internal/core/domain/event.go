@@ -1,23 +1,91 @@
 package domain
 
-import "time"
+import (
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // Name-based UUIDs are defined with SHA-1, it is not used for security.
+	"fmt"
+	"time"
+)
 
 // Event represents an income request that occurred in the redirecting system.
 type Event struct {
+	// ID is assigned once when the event is created, so the redeliveries of the event are stored only once.
+	ID          string    `json:"id"`
 	ShortURL    string    `json:"short_url"`
 	OriginalURL string    `json:"original_url"`
+	Owner       string    `json:"owner"`
 	Timestamp   time.Time `json:"timestamp"`
 	UserAgent   string    `json:"user_agent"`
-	IP          string    `json:"ip"`
+	Referrer    string    `json:"referrer"`
+	// Language is the Accept-Language header of the request, it is reduced to the preferred language by the
+	// enrichment.
+	Language string `json:"language"`
+	IP       string `json:"ip"`
+
+	// The fields below are filled by the enrichment before the event is stored.
+	Browser string `json:"browser,omitempty"`
+	OS      string `json:"os,omitempty"`
+	Device  Device `json:"device,omitempty"`
+	Bot     bool   `json:"bot,omitempty"`
+	Country string `json:"country,omitempty"`
+	City    string `json:"city,omitempty"`
 }
 
-// NewEvent creates a new event with the given short URL, original URL, user agent, and IP.
-func NewEvent(shortURL, originalURL, userAgent, ip string) *Event {
+// Device is the family of the device the request was made from.
+type Device string
+
+const (
+	DESKTOP Device = "desktop"
+	MOBILE  Device = "mobile"
+	TABLET  Device = "tablet"
+	OTHER   Device = "other"
+)
+
+// NewEvent creates a new event with a random ID for the redirect to the given URL with the given user agent,
+// referrer, accepted languages, and IP.
+func NewEvent(url *URL, userAgent, referrer, language, ip string) (*Event, error) {
+	id, err := newEventID()
+	if err != nil {
+		return nil, err
+	}
+
 	return &Event{
-		ShortURL:    shortURL,
-		OriginalURL: originalURL,
+		ID:          id,
+		ShortURL:    url.Short,
+		OriginalURL: url.Original,
+		Owner:       url.Owner,
 		Timestamp:   time.Now(),
 		UserAgent:   userAgent,
+		Referrer:    referrer,
+		Language:    language,
 		IP:          ip,
+	}, nil
+}
+
+// newEventID generates a random (version 4) UUID.
+func newEventID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", fmt.Errorf("failed to generate event id: %w", err)
 	}
+
+	return formatUUID(id, 4), nil
+}
+
+// DeriveEventID returns the name-based (version 5 like) UUID of the key. It identifies the events that were
+// produced without an ID by their position in the log, so their redeliveries get the same ID too.
+func DeriveEventID(key string) string {
+	var id [16]byte
+	sum := sha1.Sum([]byte(key))
+	copy(id[:], sum[:])
+
+	return formatUUID(id, 5)
+}
+
+// formatUUID sets the version and the RFC 4122 variant bits of the UUID and returns its canonical string form.
+func formatUUID(id [16]byte, version byte) string {
+	id[6] = id[6]&0x0f | version<<4
+	id[8] = id[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16])
 }
@@ -0,0 +1,20 @@
+package domain
+
+// JSONWebKey represents a public key used to verify tokens as defined in RFC 7517.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// N and E are the modulus and the exponent of an RSA public key.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Curve and X describe an Ed25519 public key.
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet represents a set of public keys used to verify tokens.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+type PlanName string
+
+const (
+	FREE    PlanName = "free"
+	PREMIUM PlanName = "premium"
+)
+
+// Plan represents a billing plan. It defines what the users of the plan are allowed to do.
+type Plan struct {
+	Name   PlanName
+	Limits PlanLimits
+	// Default marks the plan assigned to the newly registered users. There is at most one default plan.
+	Default bool
+}
+
+// PlanLimits represents the limits of a billing plan.
+type PlanLimits struct {
+	// LinkQuota is the number of links the users of the plan can have.
+	LinkQuota int64
+	// MaxLinkLifetime is the maximal lifetime of the links. Zero means the links can live forever.
+	MaxLinkLifetime time.Duration
+	// CustomAliases allows the users of the plan to choose the short URLs of their links.
+	CustomAliases bool
+	// RateLimit is the number of requests per second the users of the plan can make.
+	RateLimit int64
+	// RateBurst is the maximal number of requests the users of the plan can make at once.
+	RateBurst int64
+	// AnalyticsRetention is how long the click statistics of the links are kept. Zero means forever.
+	AnalyticsRetention time.Duration
+}
@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+// Interval is the size of the time bucket used to aggregate clicks.
+type Interval string
+
+const (
+	HOUR Interval = "hour"
+	DAY  Interval = "day"
+)
+
+// StatisticsQuery describes the parameters of the statistics request for a short URL.
+type StatisticsQuery struct {
+	ShortURL string
+	// Owner is the user the short URL must belong to, the statistics of the links of other users are
+	// forbidden. Empty owner means any user.
+	Owner    string
+	From     time.Time
+	To       time.Time
+	Interval Interval
+	Limit    int
+}
+
+// ClickSummary represents the total number of clicks on a short URL.
+type ClickSummary struct {
+	Clicks         int64
+	UniqueVisitors int64
+}
+
+// ClickBucket represents the number of clicks in the time bucket starting at Time.
+type ClickBucket struct {
+	Time   time.Time
+	Clicks int64
+}
+
+// TopValue represents the number of clicks with the given value of an event attribute.
+type TopValue struct {
+	Value  string
+	Clicks int64
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// Tokens represents a pair of a short-lived access token and a refresh token used to obtain new access tokens.
+type Tokens struct {
+	Access  string
+	Refresh string
+}
+
+// RefreshToken represents a refresh token stored on the server. Only the hash of the token is stored.
+type RefreshToken struct {
+	Hash      string
+	Username  string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"net/http"
+	"time"
+)
+
+// URL represents a shortened link.
+type URL struct {
+	Short    string
+	Original string
+	// Normalized is the canonical form of the original URL used to find the links to the same page.
+	Normalized string
+	// Owner is the username of the user who created the link.
+	Owner     string
+	CreatedAt time.Time
+	// ExpiresAt is the moment after which the link can no longer be resolved.
+	// Zero value means that the link never expires.
+	ExpiresAt time.Time
+	// RedirectCode is the HTTP status code the link redirects with. Zero means the default one.
+	RedirectCode int
+	// ForwardQuery appends the query parameters of the request for the short URL to the original URL.
+	ForwardQuery bool
+}
+
+// redirectCodes contains the HTTP status codes the links can redirect with.
+var redirectCodes = map[int]struct{}{
+	http.StatusMovedPermanently:  {},
+	http.StatusFound:             {},
+	http.StatusTemporaryRedirect: {},
+	http.StatusPermanentRedirect: {},
+}
+
+// IsRedirectCode reports whether the links can redirect with the HTTP status code.
+func IsRedirectCode(code int) bool {
+	_, ok := redirectCodes[code]
+	return ok
+}
+
+// NewURL creates a new URL with the given short URL, original URL, and expiration time.
+func NewURL(short, original string, expiresAt time.Time) *URL {
+	return &URL{
+		Short:     short,
+		Original:  original,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// IsExpired reports whether the link is expired at the given moment.
+func (u *URL) IsExpired(now time.Time) bool {
+	return !u.ExpiresAt.IsZero() && !now.Before(u.ExpiresAt)
+}
+
+// ShortenOptions describes how a link is shortened.
+type ShortenOptions struct {
+	// Deduplicate returns the existing link of the author to the same normalized URL with the same redirect
+	// options instead of creating a new one. It is ignored when a custom alias is requested, and can't be
+	// combined with an expiration.
+	Deduplicate bool
+}
+
+// LinksQuery describes the parameters for listing the links of a user.
+type LinksQuery struct {
+	Owner string
+	// Search filters links by a substring of the original URL.
+	Search string
+	// Desc sorts links from the newest to the oldest.
+	Desc bool
+	// Cursor points to the position after which the page starts. Empty cursor means the first page.
+	Cursor string
+	Limit  int
+}
+
+// LinksPage represents a page of links. NextCursor is empty for the last page.
+type LinksPage struct {
+	Links      []*URL
+	NextCursor string
+}
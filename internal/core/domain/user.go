@@ -8,24 +8,19 @@ const (
 	ADMIN     Role = "admin"
 )
 
-type Plan string
-
-const (
-	FREE    Plan = "free"
-	PREMIUM Role = "premium"
-)
-
 // User represents a user of the system.
 type User struct {
 	Username       string
 	Password       string
 	Role           Role
-	Plan           Plan
+	Plan           PlanName
 	LinksRemaining int64
+	// TokenVersion is incremented on logout. Tokens issued with an older version are rejected.
+	TokenVersion int64
 }
 
 // NewUser creates a new user with the given username, password, role, plan, and links remaining.
-func NewUser(username, password string, role Role, plan Plan, linksRemaining int64) *User {
+func NewUser(username, password string, role Role, plan PlanName, linksRemaining int64) *User {
 	return &User{
 		Username:       username,
 		Password:       password,
@@ -34,3 +29,24 @@ func NewUser(username, password string, role Role, plan Plan, linksRemaining int
 		LinksRemaining: linksRemaining,
 	}
 }
+
+// Principal represents an authenticated user. Unlike User it carries no credentials,
+// so it is safe to send it to other services and keep it in the request context.
+type Principal struct {
+	Username       string
+	Role           Role
+	Plan           PlanName
+	LinksRemaining int64
+	// Limits are the limits of the plan of the user.
+	Limits PlanLimits
+}
+
+// Principal returns the principal of the user without the credentials. The limits of the plan are left empty.
+func (u *User) Principal() *Principal {
+	return &Principal{
+		Username:       u.Username,
+		Role:           u.Role,
+		Plan:           u.Plan,
+		LinksRemaining: u.LinksRemaining,
+	}
+}
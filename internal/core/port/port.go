@@ -3,24 +3,46 @@ package port
 import (
 	"context"
 	"min/internal/core/domain"
+	"time"
 )
 
 // ShortenerRepository is an interface that defines the methods for the repository storing the shortened URLs.
 type ShortenerRepository interface {
-	// GetOriginal returns the original URL for the given short URL.
-	GetOriginal(ctx context.Context, short string) (string, error)
-	// Add stores the original URL and returns the shortened URL.
-	Add(ctx context.Context, short, original string) error
+	// Get returns the URL for the given short URL or nil if it does not exist.
+	Get(ctx context.Context, short string) (*domain.URL, error)
+	// Add stores the given URL. It returns domain.ErrShortURLExists if the
+	// short URL is already taken.
+	Add(ctx context.Context, url *domain.URL) error
 	// Remove deletes the shortened URL.
 	Remove(ctx context.Context, short string) error
+	// RemoveExpired deletes the URLs expired at the given moment and returns their number.
+	RemoveExpired(ctx context.Context, now time.Time) (int64, error)
+	// ListByOwner returns a page of URLs created by query.Owner.
+	ListByOwner(ctx context.Context, query domain.LinksQuery) (*domain.LinksPage, error)
+	// FindByNormalized returns the newest URL of url.Owner with the normalized original URL and the redirect
+	// options of the url that is not expired at the given moment or nil if there is none.
+	FindByNormalized(ctx context.Context, url *domain.URL, now time.Time) (*domain.URL, error)
+}
+
+// ShortCodeGenerator is an interface that defines the method for generating the short codes of the links.
+type ShortCodeGenerator interface {
+	// Generate returns a new short code. The code may already be taken, e.g. by a custom alias,
+	// so the caller has to generate another one if storing the URL fails with domain.ErrShortURLExists.
+	Generate(ctx context.Context) (string, error)
+}
+
+// SequenceRepository is an interface that defines the method for the source of unique increasing numbers.
+type SequenceRepository interface {
+	// Next returns the next number of the sequence. The numbers are never returned twice.
+	Next(ctx context.Context) (int64, error)
 }
 
 // ShortenerCache is an interface that defines the methods for the cache storing the shortened URLs.
 type ShortenerCache interface {
-	// GetOriginal returns the original URL for the given short URL.
-	GetOriginal(ctx context.Context, short string) (string, error)
-	// Add stores the original URL for the given short URL.
-	Add(ctx context.Context, short, original string) error
+	// Get returns the URL for the given short URL or nil if it is not cached.
+	Get(ctx context.Context, short string) (*domain.URL, error)
+	// Add stores the given URL until it expires.
+	Add(ctx context.Context, url *domain.URL) error
 	// Remove deletes the shortened URL.
 	Remove(ctx context.Context, short string) error
 }
@@ -28,12 +50,18 @@ type ShortenerCache interface {
 // ShortenerService is an interface that defines the methods for the shortener
 // service. It is responsible for shortening and resolving URLs.
 type ShortenerService interface {
-	// Resolve returns the original URL for the given short URL.
-	Resolve(ctx context.Context, short string) (string, error)
-	// Shorten returns the shortened URL for the given original URL.
-	Shorten(ctx context.Context, url string, author *domain.User) (string, error)
-	// Remove deletes the shortened URL.
-	Remove(ctx context.Context, short string) error
+	// Resolve returns the URL for the given short URL.
+	Resolve(ctx context.Context, short string) (*domain.URL, error)
+	// Shorten stores the given URL and returns its short URL. If url.Short is
+	// not empty, it is used as a custom alias instead of a generated one.
+	Shorten(ctx context.Context, url *domain.URL, author *domain.Principal, opts domain.ShortenOptions) (string, error)
+	// Remove deletes the shortened URL on behalf of the caller. Only the owner
+	// of the URL or an admin can remove it.
+	Remove(ctx context.Context, short string, caller *domain.Principal) error
+	// RemoveExpired deletes all expired URLs and returns their number.
+	RemoveExpired(ctx context.Context) (int64, error)
+	// List returns a page of URLs created by query.Owner.
+	List(ctx context.Context, query domain.LinksQuery) (*domain.LinksPage, error)
 }
 
 // UserRepository defines the interface for the user repository. It is used to store and retrieve user data.
@@ -41,32 +69,137 @@ type UserRepository interface {
 	Save(ctx context.Context, user *domain.User) error
 	GetByUsername(ctx context.Context, username string) (*domain.User, error)
 	ChangeLinksRemaining(ctx context.Context, username string, linksRemaining int64) error
+	// ConsumeLinkQuota atomically decrements the remaining links of the user if there are any left.
+	// It returns false if the quota is exhausted.
+	ConsumeLinkQuota(ctx context.Context, username string) (bool, error)
+	// RefundLinkQuota atomically increments the remaining links of the user.
+	RefundLinkQuota(ctx context.Context, username string) error
+	// IncrementTokenVersion invalidates all the tokens issued to the user so far.
+	IncrementTokenVersion(ctx context.Context, username string) error
+	// ChangePassword replaces the password hash of the user and invalidates all the tokens issued so far.
+	ChangePassword(ctx context.Context, username, password string) error
+	// ChangePlan moves the user to the plan and adjusts the remaining links by the difference between the link
+	// quotas of the plans, so that the links already created still count. It returns false if the user does not exist.
+	ChangePlan(ctx context.Context, username string, plan *domain.Plan) (bool, error)
+}
+
+// PlanRepository defines the interface for the repository storing the billing plans.
+type PlanRepository interface {
+	// Save stores the new plan. It returns domain.ErrPlanExists if the plan name is taken.
+	// If the plan is default, it replaces the previous default plan.
+	Save(ctx context.Context, plan *domain.Plan) error
+	// Get returns the plan with the given name or nil if it does not exist.
+	Get(ctx context.Context, name domain.PlanName) (*domain.Plan, error)
+	// GetDefault returns the plan assigned to the newly registered users or nil if there is none.
+	GetDefault(ctx context.Context) (*domain.Plan, error)
+	// List returns all the plans ordered by name.
+	List(ctx context.Context) ([]*domain.Plan, error)
+}
+
+// RefreshTokenRepository defines the interface for the repository storing the refresh tokens.
+type RefreshTokenRepository interface {
+	Save(ctx context.Context, token *domain.RefreshToken) error
+	// Take removes the refresh token with the given hash and returns it or nil if it does not exist.
+	// Each refresh token can be taken only once, even by concurrent requests.
+	Take(ctx context.Context, hash string) (*domain.RefreshToken, error)
+	// RemoveByUsername removes all the refresh tokens of the user.
+	RemoveByUsername(ctx context.Context, username string) error
 }
 
 // AuthService defines the interface for the auth service.
 type AuthService interface {
-	Login(ctx context.Context, username, password string) (string, error)
+	Login(ctx context.Context, username, password string) (*domain.Tokens, error)
 	Register(ctx context.Context, newUser *domain.User) error
-	ValidateToken(ctx context.Context, tokenString string) (*domain.User, error)
+	ValidateToken(ctx context.Context, tokenString string) (*domain.Principal, error)
 	ChangeLinksRemaining(ctx context.Context, username string, linksRemaining int64) error
+	// ConsumeLinkQuota uses up one link of the user. It returns domain.ErrLinkQuotaExceeded if there are none left.
+	ConsumeLinkQuota(ctx context.Context, username string) error
+	// RefundLinkQuota gives one link back to the user.
+	RefundLinkQuota(ctx context.Context, username string) error
+	// Refresh exchanges the refresh token for a new pair of tokens. The refresh token can be used only once.
+	Refresh(ctx context.Context, refreshToken string) (*domain.Tokens, error)
+	// Logout revokes all the tokens of the user the access token belongs to.
+	Logout(ctx context.Context, tokenString string) error
+	// ChangePassword changes the password of the user the access token belongs to and revokes all their tokens.
+	// It returns domain.ErrInvalidPassword if the old password doesn't match.
+	ChangePassword(ctx context.Context, tokenString, oldPassword, newPassword string) error
+	// CreatePlan creates a new billing plan.
+	CreatePlan(ctx context.Context, plan *domain.Plan) error
+	// ListPlans returns all the billing plans.
+	ListPlans(ctx context.Context) ([]*domain.Plan, error)
+	// ChangePlan upgrades or downgrades the user to the plan.
+	ChangePlan(ctx context.Context, username string, plan domain.PlanName) error
+	// GetPlanLimits returns the limits of the user's plan. It returns domain.ErrUserNotFound if there is no user.
+	GetPlanLimits(ctx context.Context, username string) (*domain.PlanLimits, error)
+}
+
+// KeyProvider defines the interface for the source of the public keys used to verify tokens.
+type KeyProvider interface {
+	// JWKS returns the public keys as a JSON Web Key Set.
+	JWKS() domain.JSONWebKeySet
 }
 
 // AuthClient defines the interface for the auth client. It is used to communicate with the auth server.
 type AuthClient interface {
 	Register(ctx context.Context, username, password string, role domain.Role) error
-	Login(ctx context.Context, username, password string) (string, error)
-	ValidateToken(ctx context.Context, token string) (*domain.User, error)
+	Login(ctx context.Context, username, password string) (*domain.Tokens, error)
+	ValidateToken(ctx context.Context, token string) (*domain.Principal, error)
 	ChangeLinksRemaining(ctx context.Context, username string, linksRemaining int64) error
+	// ConsumeLinkQuota uses up one link of the user. It returns domain.ErrLinkQuotaExceeded if there are none left.
+	ConsumeLinkQuota(ctx context.Context, username string) error
+	// RefundLinkQuota gives one link back to the user.
+	RefundLinkQuota(ctx context.Context, username string) error
+	Refresh(ctx context.Context, refreshToken string) (*domain.Tokens, error)
+	Logout(ctx context.Context, token string) error
+	// ChangePassword changes the password of the user the token belongs to and revokes all their tokens.
+	// It returns domain.ErrInvalidPassword if the old password doesn't match.
+	ChangePassword(ctx context.Context, token, oldPassword, newPassword string) error
+	CreatePlan(ctx context.Context, plan *domain.Plan) error
+	ListPlans(ctx context.Context) ([]*domain.Plan, error)
+	// ChangePlan upgrades or downgrades the user to the plan.
+	ChangePlan(ctx context.Context, username string, plan domain.PlanName) error
+	// GetPlanLimits returns the limits of the user's plan. It returns domain.ErrUserNotFound if there is no user.
+	GetPlanLimits(ctx context.Context, username string) (*domain.PlanLimits, error)
 }
 
+// StatisticsRepository defines the interface for the repository storing the redirect events.
 type StatisticsRepository interface {
-	AddEvent(ctx context.Context, event domain.Event) error
+	// AddEvents stores the events in a single insert.
+	AddEvents(ctx context.Context, events []domain.Event) error
+	// GetSummary returns the total number of clicks and unique visitors.
+	GetSummary(ctx context.Context, query domain.StatisticsQuery) (*domain.ClickSummary, error)
+	// GetTimeSeries returns the number of clicks bucketed by query.Interval.
+	GetTimeSeries(ctx context.Context, query domain.StatisticsQuery) ([]domain.ClickBucket, error)
+	// GetTopReferrers returns at most query.Limit referrers with the most clicks.
+	GetTopReferrers(ctx context.Context, query domain.StatisticsQuery) ([]domain.TopValue, error)
+	// GetTopUserAgents returns at most query.Limit user agents with the most clicks.
+	GetTopUserAgents(ctx context.Context, query domain.StatisticsQuery) ([]domain.TopValue, error)
+	// GetOwners returns the owners of the short URL recorded in its events, the owner of the latest one first.
+	GetOwners(ctx context.Context, short string) ([]string, error)
+}
+
+// PlanLimitsProvider defines the interface for looking up the plan limits of the owners of the links.
+type PlanLimitsProvider interface {
+	// GetPlanLimits returns the limits of the user's plan. It returns domain.ErrUserNotFound if there is no user.
+	GetPlanLimits(ctx context.Context, username string) (*domain.PlanLimits, error)
+}
+
+// EventEnricher defines the interface for deriving the browser, device, bot flag, and location of the event
+// from its raw request attributes.
+type EventEnricher interface {
+	Enrich(event *domain.Event)
 }
 
+// StatisticsService defines the interface for the service collecting and querying the redirect statistics.
 type StatisticsService interface {
-	AddEvent(ctx context.Context, event domain.Event) error
+	AddEvents(ctx context.Context, events []domain.Event) error
+	GetSummary(ctx context.Context, query domain.StatisticsQuery) (*domain.ClickSummary, error)
+	GetTimeSeries(ctx context.Context, query domain.StatisticsQuery) ([]domain.ClickBucket, error)
+	GetTopReferrers(ctx context.Context, query domain.StatisticsQuery) ([]domain.TopValue, error)
+	GetTopUserAgents(ctx context.Context, query domain.StatisticsQuery) ([]domain.TopValue, error)
 }
 
+// EventProducer defines the interface for publishing the redirect events. Produce must not wait for the delivery.
 type EventProducer interface {
 	Produce(event *domain.Event) error
 }
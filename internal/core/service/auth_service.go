@@ -2,6 +2,10 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"min/internal/core/domain"
@@ -14,48 +18,194 @@ import (
 
 // AuthService is the service for authentication.
 type AuthService struct {
-	authRep      port.UserRepository
-	tokenMaxTime time.Duration
+	authRep             port.UserRepository
+	tokensRep           port.RefreshTokenRepository
+	plansRep            port.PlanRepository
+	keys                *KeySet
+	tokenMaxTime        time.Duration
+	refreshTokenMaxTime time.Duration
 }
 
-// NewAuthService creates a new AuthService.
-func NewAuthService(authRep port.UserRepository, tokenMaxTime time.Duration) *AuthService {
+// refreshTokenLength is the number of random bytes in a refresh token.
+const refreshTokenLength = 32
+
+// NewAuthService creates a new AuthService. Tokens are signed and verified with the given keys.
+// Access tokens are valid for tokenMaxTime and refresh tokens are valid for refreshTokenMaxTime.
+func NewAuthService(
+	authRep port.UserRepository,
+	tokensRep port.RefreshTokenRepository,
+	plansRep port.PlanRepository,
+	keys *KeySet,
+	tokenMaxTime time.Duration,
+	refreshTokenMaxTime time.Duration,
+) *AuthService {
 	return &AuthService{
-		authRep:      authRep,
-		tokenMaxTime: tokenMaxTime,
+		authRep:             authRep,
+		tokensRep:           tokensRep,
+		plansRep:            plansRep,
+		keys:                keys,
+		tokenMaxTime:        tokenMaxTime,
+		refreshTokenMaxTime: refreshTokenMaxTime,
 	}
 }
 
-// Login logs in the user and returns a token.
-func (a *AuthService) Login(ctx context.Context, username, password string) (string, error) {
+// Login logs in the user and returns an access token and a refresh token.
+func (a *AuthService) Login(ctx context.Context, username, password string) (*domain.Tokens, error) {
 	user, err := a.authRep.GetByUsername(ctx, username)
 	if err != nil {
-		return "", fmt.Errorf("failed to get user: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	if user == nil {
-		return "", errors.New("user not found")
+		return nil, errors.New("user not found")
 	}
 
 	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", errors.New("invalid password")
+		return nil, errors.New("invalid password")
+	}
+
+	return a.issueTokens(ctx, user)
+}
+
+// Refresh exchanges the refresh token for a new pair of tokens. The refresh token can be used only once.
+func (a *AuthService) Refresh(ctx context.Context, refreshToken string) (*domain.Tokens, error) {
+	token, err := a.tokensRep.Take(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if token == nil || !time.Now().Before(token.ExpiresAt) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	user, err := a.authRep.GetByUsername(ctx, token.Username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	return a.issueTokens(ctx, user)
+}
+
+// Logout revokes all the refresh tokens of the user and invalidates the access tokens issued so far.
+func (a *AuthService) Logout(ctx context.Context, tokenString string) error {
+	user, err := a.ValidateToken(ctx, tokenString)
+	if err != nil {
+		return err
+	}
+
+	if err = a.tokensRep.RemoveByUsername(ctx, user.Username); err != nil {
+		return fmt.Errorf("failed to remove refresh tokens: %w", err)
+	}
+
+	if err = a.authRep.IncrementTokenVersion(ctx, user.Username); err != nil {
+		return fmt.Errorf("failed to revoke tokens: %w", err)
+	}
+
+	return nil
+}
+
+// ChangePassword changes the password of the user the access token belongs to after checking the old one.
+// The refresh tokens of the user are revoked and the access tokens issued so far are invalidated, as on logout.
+func (a *AuthService) ChangePassword(ctx context.Context, tokenString, oldPassword, newPassword string) error {
+	principal, err := a.ValidateToken(ctx, tokenString)
+	if err != nil {
+		return err
+	}
+
+	user, err := a.authRep.GetByUsername(ctx, principal.Username)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		return domain.ErrUserNotFound
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"username": username,
-		"exp":      time.Now().Add(a.tokenMaxTime).Unix(),
+	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword)); err != nil {
+		return domain.ErrInvalidPassword
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err = a.tokensRep.RemoveByUsername(ctx, user.Username); err != nil {
+		return fmt.Errorf("failed to remove refresh tokens: %w", err)
+	}
+
+	if err = a.authRep.ChangePassword(ctx, user.Username, string(hashedPassword)); err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+
+	return nil
+}
+
+// issueTokens signs a new access token for the user and stores a new refresh token.
+func (a *AuthService) issueTokens(ctx context.Context, user *domain.User) (*domain.Tokens, error) {
+	now := time.Now()
+	access, err := a.keys.Sign(jwt.MapClaims{
+		"username": user.Username,
+		"ver":      user.TokenVersion,
+		"iat":      now.Unix(),
+		"exp":      now.Add(a.tokenMaxTime).Unix(),
 	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	refreshBytes := make([]byte, refreshTokenLength)
+	if _, err = rand.Read(refreshBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(refreshBytes)
 
-	tokenString, err := token.SignedString([]byte("secret")) // For demonstration purposes only. Should be hidden.
+	err = a.tokensRep.Save(ctx, &domain.RefreshToken{
+		Hash:      hashRefreshToken(refresh),
+		Username:  user.Username,
+		ExpiresAt: now.Add(a.refreshTokenMaxTime),
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to sign token: %w", err)
+		return nil, fmt.Errorf("failed to save refresh token: %w", err)
 	}
 
-	return tokenString, nil
+	return &domain.Tokens{Access: access, Refresh: refresh}, nil
+}
+
+// hashRefreshToken returns the hash the refresh token is stored by, so that a leaked database can't be used
+// to obtain tokens.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
-// Register registers a new user.
+// Register registers a new user. Users without a plan are registered with the default plan.
+// Unless the remaining links are given explicitly, the user gets the link quota of the plan.
 func (a *AuthService) Register(ctx context.Context, newUser *domain.User) error {
+	var plan *domain.Plan
+	var err error
+	if newUser.Plan == "" {
+		plan, err = a.plansRep.GetDefault(ctx)
+	} else {
+		plan, err = a.plansRep.Get(ctx, newUser.Plan)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	if plan == nil {
+		return domain.ErrPlanNotFound
+	}
+
+	newUser.Plan = plan.Name
+	if newUser.LinksRemaining == 0 {
+		newUser.LinksRemaining = plan.Limits.LinkQuota
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newUser.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
@@ -71,15 +221,9 @@ func (a *AuthService) Register(ctx context.Context, newUser *domain.User) error
 	return nil
 }
 
-// ValidateToken validates the token and returns the user associated with it.
-func (a *AuthService) ValidateToken(ctx context.Context, tokenString string) (*domain.User, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte("secret"), nil // For demonstration purposes only. Should be hidden.
-	})
-
+// ValidateToken validates the token and returns the principal of the user associated with it.
+func (a *AuthService) ValidateToken(ctx context.Context, tokenString string) (*domain.Principal, error) {
+	token, err := jwt.Parse(tokenString, a.keys.Keyfunc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
@@ -108,7 +252,23 @@ func (a *AuthService) ValidateToken(ctx context.Context, tokenString string) (*d
 		return nil, errors.New("user not found")
 	}
 
-	return user, nil
+	// Tokens issued before the version was introduced have no version claim and belong to version 0.
+	version, _ := claims["ver"].(float64)
+	if int64(version) != user.TokenVersion {
+		return nil, domain.ErrTokenRevoked
+	}
+
+	plan, err := a.plansRep.Get(ctx, user.Plan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	principal := user.Principal()
+	if plan != nil {
+		principal.Limits = plan.Limits
+	}
+
+	return principal, nil
 }
 
 // ChangeLinksRemaining changes the remaining links for the specified user.
@@ -120,3 +280,114 @@ func (a *AuthService) ChangeLinksRemaining(ctx context.Context, username string,
 
 	return nil
 }
+
+// ConsumeLinkQuota uses up one link of the specified user.
+func (a *AuthService) ConsumeLinkQuota(ctx context.Context, username string) error {
+	consumed, err := a.authRep.ConsumeLinkQuota(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to consume link quota: %w", err)
+	}
+
+	if !consumed {
+		return domain.ErrLinkQuotaExceeded
+	}
+
+	return nil
+}
+
+// RefundLinkQuota gives one link back to the specified user.
+func (a *AuthService) RefundLinkQuota(ctx context.Context, username string) error {
+	err := a.authRep.RefundLinkQuota(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to refund link quota: %w", err)
+	}
+
+	return nil
+}
+
+// CreatePlan creates a new billing plan.
+func (a *AuthService) CreatePlan(ctx context.Context, plan *domain.Plan) error {
+	if err := validatePlan(plan); err != nil {
+		return err
+	}
+
+	if err := a.plansRep.Save(ctx, plan); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	return nil
+}
+
+// ListPlans returns all the billing plans.
+func (a *AuthService) ListPlans(ctx context.Context) ([]*domain.Plan, error) {
+	plans, err := a.plansRep.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plans: %w", err)
+	}
+
+	return plans, nil
+}
+
+// ChangePlan upgrades or downgrades the user to the plan. The remaining links of the user change by the
+// difference between the link quotas of the plans, but never drop below zero.
+func (a *AuthService) ChangePlan(ctx context.Context, username string, name domain.PlanName) error {
+	plan, err := a.plansRep.Get(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	if plan == nil {
+		return domain.ErrPlanNotFound
+	}
+
+	changed, err := a.authRep.ChangePlan(ctx, username, plan)
+	if err != nil {
+		return fmt.Errorf("failed to change plan: %w", err)
+	}
+
+	if !changed {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// GetPlanLimits returns the limits of the user's plan. The limits are zero if the plan no longer exists,
+// the same as in the principal returned by ValidateToken.
+func (a *AuthService) GetPlanLimits(ctx context.Context, username string) (*domain.PlanLimits, error) {
+	user, err := a.authRep.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	plan, err := a.plansRep.Get(ctx, user.Plan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	var limits domain.PlanLimits
+	if plan != nil {
+		limits = plan.Limits
+	}
+
+	return &limits, nil
+}
+
+// validatePlan checks that the plan has a name and none of its limits are negative.
+func validatePlan(plan *domain.Plan) error {
+	limits := plan.Limits
+	switch {
+	case plan.Name == "":
+		return fmt.Errorf("%w: name is required", domain.ErrInvalidPlan)
+	case limits.LinkQuota < 0, limits.MaxLinkLifetime < 0, limits.AnalyticsRetention < 0:
+		return fmt.Errorf("%w: limits must not be negative", domain.ErrInvalidPlan)
+	case limits.RateLimit <= 0, limits.RateBurst <= 0:
+		return fmt.Errorf("%w: rate limit and burst must be positive", domain.ErrInvalidPlan)
+	default:
+		return nil
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 	"min/internal/core/domain"
 	"min/internal/mocks"
 	"testing"
@@ -14,29 +15,60 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// newTestKeySet creates a key set with the HS256 "secret" key used to sign tokens in the tests.
+func newTestKeySet(t *testing.T) *KeySet {
+	t.Helper()
+
+	key, err := NewSigningKey("test", "HS256", []byte("secret"))
+	require.NoError(t, err)
+	keys, err := NewKeySet("test", key)
+	require.NoError(t, err)
+
+	return keys
+}
+
+// testFreePlan is the default plan used in the tests.
+var testFreePlan = &domain.Plan{
+	Name: domain.FREE,
+	Limits: domain.PlanLimits{
+		LinkQuota:       100,
+		MaxLinkLifetime: 30 * 24 * time.Hour,
+		RateLimit:       10,
+		RateBurst:       100,
+	},
+	Default: true,
+}
+
 func TestAuthService_Login(t *testing.T) {
 	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
 	userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(
 		&domain.User{Password: "$2a$10$/md3ztppcKhB9sjDb/GMZuYlb9o3bxvPnwO2v3up3/KlHCjMOskcG"},
 		nil,
 	).Once()
+	tokensRepo.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
 
-	authService := NewAuthService(userRepo, 3600)
-	token, err := authService.Login(context.Background(), "valid_user", "password")
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), 3600, time.Hour)
+	tokens, err := authService.Login(context.Background(), "valid_user", "password")
 
 	require.NoError(t, err)
-	assert.NotEmpty(t, token)
+	assert.NotEmpty(t, tokens.Access)
+	assert.NotEmpty(t, tokens.Refresh)
 	userRepo.AssertExpectations(t)
+	tokensRepo.AssertExpectations(t)
 }
 
 func TestAuthService_LoginInvalidPassword(t *testing.T) {
 	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
 	userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(
 		&domain.User{Password: "$2a$10$N9qo8uLOickgx2ZMRZoHKuGnK.y39JZjiujDtJZN.gR7Oy.fXx8aG"},
 		nil,
 	).Once()
 
-	authService := NewAuthService(userRepo, 3600)
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), 3600, time.Hour)
 	_, err := authService.Login(context.Background(), "valid_user", "invalid_password")
 
 	require.Error(t, err)
@@ -45,9 +77,14 @@ func TestAuthService_LoginInvalidPassword(t *testing.T) {
 
 func TestAuthService_Register(t *testing.T) {
 	userRepo := new(mocks.UserRepository)
-	userRepo.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
+	plansRepo.On("GetDefault", mock.Anything).Return(testFreePlan, nil).Once()
+	userRepo.On("Save", mock.Anything, mock.MatchedBy(func(user *domain.User) bool {
+		return user.Plan == domain.FREE && user.LinksRemaining == testFreePlan.Limits.LinkQuota
+	})).Return(nil).Once()
 
-	authService := NewAuthService(userRepo, 3600)
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), 3600, time.Hour)
 	err := authService.Register(context.Background(), &domain.User{
 		Username: "new_user",
 		Password: "password",
@@ -56,18 +93,61 @@ func TestAuthService_Register(t *testing.T) {
 
 	require.NoError(t, err)
 	userRepo.AssertExpectations(t)
+	plansRepo.AssertExpectations(t)
+}
+
+func TestAuthService_RegisterWithPlan(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), time.Hour, time.Hour)
+
+	t.Run("explicit links remaining are kept", func(t *testing.T) {
+		plansRepo.On("Get", mock.Anything, domain.FREE).Return(testFreePlan, nil).Once()
+		userRepo.On("Save", mock.Anything, mock.MatchedBy(func(user *domain.User) bool {
+			return user.Plan == domain.FREE && user.LinksRemaining == 5
+		})).Return(nil).Once()
+
+		err := authService.Register(context.Background(), &domain.User{
+			Username:       "new_user",
+			Password:       "password",
+			Plan:           domain.FREE,
+			LinksRemaining: 5,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("unknown plan", func(t *testing.T) {
+		plansRepo.On("Get", mock.Anything, domain.PlanName("gold")).Return(nil, nil).Once()
+
+		err := authService.Register(context.Background(), &domain.User{
+			Username: "new_user",
+			Password: "password",
+			Plan:     "gold",
+		})
+		require.ErrorIs(t, err, domain.ErrPlanNotFound)
+	})
+
+	userRepo.AssertExpectations(t)
+	plansRepo.AssertExpectations(t)
 }
 
 func TestAuthService_ValidateToken(t *testing.T) {
 	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
+	plansRepo.On("GetDefault", mock.Anything).Return(testFreePlan, nil).Once()
 	userRepo.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
 	userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(&domain.User{
 		Username: "valid_user",
 		Password: "$2a$10$/md3ztppcKhB9sjDb/GMZuYlb9o3bxvPnwO2v3up3/KlHCjMOskcG",
 		Role:     domain.USER,
+		Plan:     domain.FREE,
 	}, nil).Twice()
+	plansRepo.On("Get", mock.Anything, domain.FREE).Return(testFreePlan, nil).Once()
+	tokensRepo.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
 
-	authService := NewAuthService(userRepo, 1*time.Hour)
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), 1*time.Hour, time.Hour)
 	err := authService.Register(context.Background(), &domain.User{
 		Username: "valid_user",
 		Password: "password",
@@ -75,20 +155,24 @@ func TestAuthService_ValidateToken(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	tokenString, err := authService.Login(context.Background(), "valid_user", "password")
+	tokens, err := authService.Login(context.Background(), "valid_user", "password")
 	require.NoError(t, err)
 
-	user, err := authService.ValidateToken(context.Background(), tokenString)
+	user, err := authService.ValidateToken(context.Background(), tokens.Access)
 
 	require.NoError(t, err)
 	assert.NotNil(t, user)
+	assert.Equal(t, testFreePlan.Limits, user.Limits)
 	userRepo.AssertExpectations(t)
+	plansRepo.AssertExpectations(t)
 }
 
 func TestAuthService_ValidateTokenInvalidFormat(t *testing.T) {
 	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
 
-	authService := NewAuthService(userRepo, 3600)
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), 3600, time.Hour)
 	_, err := authService.ValidateToken(context.Background(), "invalid_token")
 
 	require.Error(t, err)
@@ -96,9 +180,11 @@ func TestAuthService_ValidateTokenInvalidFormat(t *testing.T) {
 
 func TestAuthService_ValidateUserNotFound(t *testing.T) {
 	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
 	userRepo.On("GetByUsername", mock.Anything, mock.Anything).Return(nil, nil)
 
-	authService := NewAuthService(userRepo, 3600)
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), 3600, time.Hour)
 	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"username": "valid_user",
 		"exp":      time.Now().Add(time.Hour).Unix(),
@@ -111,8 +197,10 @@ func TestAuthService_ValidateUserNotFound(t *testing.T) {
 
 func TestAuthService_ValidateTokenInvalidClaims(t *testing.T) {
 	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
 
-	authService := NewAuthService(userRepo, 3600*time.Second)
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), 3600*time.Second, time.Hour)
 	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"exp": time.Now().Add(time.Hour).Unix(),
 	}).SignedString([]byte("secret"))
@@ -125,13 +213,15 @@ func TestAuthService_ValidateTokenInvalidClaims(t *testing.T) {
 
 func TestAuthService_ValidateTokenGetByUsernameError(t *testing.T) {
 	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
 	userRepo.On(
 		"GetByUsername",
 		mock.Anything,
 		"valid_user",
 	).Return(nil, errors.New("db error")).Once()
 
-	authService := NewAuthService(userRepo, 3600*time.Second)
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), 3600*time.Second, time.Hour)
 	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"username": "valid_user",
 		"exp":      time.Now().Add(time.Hour).Unix(),
@@ -144,3 +234,254 @@ func TestAuthService_ValidateTokenGetByUsernameError(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to get user")
 	userRepo.AssertExpectations(t)
 }
+
+func TestAuthService_ValidateTokenRevoked(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
+	userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(&domain.User{
+		Username:     "valid_user",
+		TokenVersion: 1,
+	}, nil).Once()
+
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), time.Hour, time.Hour)
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"username": "valid_user",
+		"ver":      0,
+		"exp":      time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = authService.ValidateToken(context.Background(), tokenString)
+
+	require.ErrorIs(t, err, domain.ErrTokenRevoked)
+	userRepo.AssertExpectations(t)
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), time.Hour, time.Hour)
+
+	t.Run("successful refresh", func(t *testing.T) {
+		tokensRepo.On("Take", mock.Anything, hashRefreshToken("refresh")).Return(&domain.RefreshToken{
+			Hash:      hashRefreshToken("refresh"),
+			Username:  "valid_user",
+			ExpiresAt: time.Now().Add(time.Hour),
+		}, nil).Once()
+		userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(&domain.User{
+			Username:     "valid_user",
+			TokenVersion: 2,
+			Plan:         domain.FREE,
+		}, nil).Twice()
+		plansRepo.On("Get", mock.Anything, domain.FREE).Return(testFreePlan, nil).Once()
+		tokensRepo.On("Save", mock.Anything, mock.MatchedBy(func(token *domain.RefreshToken) bool {
+			return token.Username == "valid_user" && token.Hash != hashRefreshToken("refresh")
+		})).Return(nil).Once()
+
+		tokens, err := authService.Refresh(context.Background(), "refresh")
+		require.NoError(t, err)
+		assert.NotEqual(t, "refresh", tokens.Refresh)
+
+		user, err := authService.ValidateToken(context.Background(), tokens.Access)
+		require.NoError(t, err)
+		assert.Equal(t, "valid_user", user.Username)
+	})
+
+	t.Run("unknown or already used refresh token", func(t *testing.T) {
+		tokensRepo.On("Take", mock.Anything, hashRefreshToken("used")).Return(nil, nil).Once()
+
+		_, err := authService.Refresh(context.Background(), "used")
+		require.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+	})
+
+	t.Run("expired refresh token", func(t *testing.T) {
+		tokensRepo.On("Take", mock.Anything, hashRefreshToken("expired")).Return(&domain.RefreshToken{
+			Hash:      hashRefreshToken("expired"),
+			Username:  "valid_user",
+			ExpiresAt: time.Now().Add(-time.Minute),
+		}, nil).Once()
+
+		_, err := authService.Refresh(context.Background(), "expired")
+		require.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+	})
+
+	userRepo.AssertExpectations(t)
+	tokensRepo.AssertExpectations(t)
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), time.Hour, time.Hour)
+
+	userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(&domain.User{
+		Username: "valid_user",
+		Plan:     domain.FREE,
+	}, nil).Once()
+	plansRepo.On("Get", mock.Anything, domain.FREE).Return(testFreePlan, nil).Once()
+	tokensRepo.On("RemoveByUsername", mock.Anything, "valid_user").Return(nil).Once()
+	userRepo.On("IncrementTokenVersion", mock.Anything, "valid_user").Return(nil).Once()
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"username": "valid_user",
+		"exp":      time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	err = authService.Logout(context.Background(), tokenString)
+
+	require.NoError(t, err)
+	userRepo.AssertExpectations(t)
+	tokensRepo.AssertExpectations(t)
+}
+
+func TestAuthService_ChangePassword(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), time.Hour, time.Hour)
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("old_password"), bcrypt.DefaultCost)
+	require.NoError(t, err)
+	userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(&domain.User{
+		Username: "valid_user",
+		Password: string(hashedPassword),
+		Plan:     domain.FREE,
+	}, nil)
+	plansRepo.On("Get", mock.Anything, domain.FREE).Return(testFreePlan, nil)
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"username": "valid_user",
+		"exp":      time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	t.Run("successful change", func(t *testing.T) {
+		tokensRepo.On("RemoveByUsername", mock.Anything, "valid_user").Return(nil).Once()
+		userRepo.On("ChangePassword", mock.Anything, "valid_user", mock.MatchedBy(func(hash string) bool {
+			return bcrypt.CompareHashAndPassword([]byte(hash), []byte("new_password")) == nil
+		})).Return(nil).Once()
+
+		err := authService.ChangePassword(context.Background(), tokenString, "old_password", "new_password")
+
+		require.NoError(t, err)
+	})
+
+	t.Run("wrong old password", func(t *testing.T) {
+		err := authService.ChangePassword(context.Background(), tokenString, "wrong_password", "new_password")
+
+		require.ErrorIs(t, err, domain.ErrInvalidPassword)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		err := authService.ChangePassword(context.Background(), "invalid", "old_password", "new_password")
+
+		require.Error(t, err)
+	})
+
+	userRepo.AssertExpectations(t)
+	tokensRepo.AssertExpectations(t)
+}
+
+func TestAuthService_ConsumeLinkQuota(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
+	userRepo.On("ConsumeLinkQuota", mock.Anything, "rich_user").Return(true, nil).Once()
+	userRepo.On("ConsumeLinkQuota", mock.Anything, "poor_user").Return(false, nil).Once()
+
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), time.Hour, time.Hour)
+
+	require.NoError(t, authService.ConsumeLinkQuota(context.Background(), "rich_user"))
+	require.ErrorIs(t, authService.ConsumeLinkQuota(context.Background(), "poor_user"), domain.ErrLinkQuotaExceeded)
+	userRepo.AssertExpectations(t)
+}
+
+func TestAuthService_CreatePlan(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), time.Hour, time.Hour)
+
+	t.Run("successful create", func(t *testing.T) {
+		plansRepo.On("Save", mock.Anything, testFreePlan).Return(nil).Once()
+
+		require.NoError(t, authService.CreatePlan(context.Background(), testFreePlan))
+	})
+
+	t.Run("negative limits", func(t *testing.T) {
+		plan := &domain.Plan{Name: "broken", Limits: domain.PlanLimits{LinkQuota: -1, RateLimit: 1, RateBurst: 1}}
+
+		require.ErrorIs(t, authService.CreatePlan(context.Background(), plan), domain.ErrInvalidPlan)
+	})
+
+	t.Run("no rate limit", func(t *testing.T) {
+		plan := &domain.Plan{Name: "unlimited", Limits: domain.PlanLimits{LinkQuota: 1}}
+
+		require.ErrorIs(t, authService.CreatePlan(context.Background(), plan), domain.ErrInvalidPlan)
+	})
+
+	plansRepo.AssertExpectations(t)
+}
+
+func TestAuthService_ChangePlan(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), time.Hour, time.Hour)
+	premium := &domain.Plan{Name: domain.PREMIUM, Limits: domain.PlanLimits{LinkQuota: 1000}}
+
+	t.Run("successful upgrade", func(t *testing.T) {
+		plansRepo.On("Get", mock.Anything, domain.PREMIUM).Return(premium, nil).Once()
+		userRepo.On("ChangePlan", mock.Anything, "user", premium).Return(true, nil).Once()
+
+		require.NoError(t, authService.ChangePlan(context.Background(), "user", domain.PREMIUM))
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		plansRepo.On("Get", mock.Anything, domain.PREMIUM).Return(premium, nil).Once()
+		userRepo.On("ChangePlan", mock.Anything, "ghost", premium).Return(false, nil).Once()
+
+		require.ErrorIs(t, authService.ChangePlan(context.Background(), "ghost", domain.PREMIUM), domain.ErrUserNotFound)
+	})
+
+	t.Run("unknown plan", func(t *testing.T) {
+		plansRepo.On("Get", mock.Anything, domain.PlanName("gold")).Return(nil, nil).Once()
+
+		require.ErrorIs(t, authService.ChangePlan(context.Background(), "user", "gold"), domain.ErrPlanNotFound)
+	})
+
+	userRepo.AssertExpectations(t)
+	plansRepo.AssertExpectations(t)
+}
+
+func TestAuthService_GetPlanLimits(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	tokensRepo := new(mocks.RefreshTokenRepository)
+	plansRepo := new(mocks.PlanRepository)
+	authService := NewAuthService(userRepo, tokensRepo, plansRepo, newTestKeySet(t), time.Hour, time.Hour)
+	premium := &domain.Plan{Name: domain.PREMIUM, Limits: domain.PlanLimits{AnalyticsRetention: time.Hour}}
+
+	t.Run("limits of the plan", func(t *testing.T) {
+		user := &domain.User{Username: "user", Plan: domain.PREMIUM}
+		userRepo.On("GetByUsername", mock.Anything, "user").Return(user, nil).Once()
+		plansRepo.On("Get", mock.Anything, domain.PREMIUM).Return(premium, nil).Once()
+
+		limits, err := authService.GetPlanLimits(context.Background(), "user")
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, limits.AnalyticsRetention)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		userRepo.On("GetByUsername", mock.Anything, "ghost").Return(nil, nil).Once()
+
+		_, err := authService.GetPlanLimits(context.Background(), "ghost")
+		require.ErrorIs(t, err, domain.ErrUserNotFound)
+	})
+
+	userRepo.AssertExpectations(t)
+	plansRepo.AssertExpectations(t)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"fmt"
+	"min/internal/core/domain"
+	"net/url"
+	"strings"
+)
+
+// trackingParams contains the query parameters that only identify the source of the visit.
+var trackingParams = map[string]struct{}{
+	"fbclid":  {},
+	"gclid":   {},
+	"dclid":   {},
+	"msclkid": {},
+	"yclid":   {},
+	"igshid":  {},
+	"mc_cid":  {},
+	"mc_eid":  {},
+	"_ga":     {},
+}
+
+// defaultPorts maps the schemes to the ports that can be omitted.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// normalizeURL returns the canonical form of the absolute URL, so that the URLs pointing to the same page
+// are equal. The scheme and the host are lowercased, the default port is dropped, the tracking parameters
+// are removed and the remaining query parameters are sorted.
+func normalizeURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", domain.ErrInvalidURL, err)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("%w: must be absolute", domain.ErrInvalidURL)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if strings.Contains(host, ":") {
+		// Hostname strips the brackets of IPv6 addresses.
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" && port != defaultPorts[u.Scheme] {
+		host += ":" + port
+	}
+	u.Host = host
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	query := u.Query()
+	for param := range query {
+		name := strings.ToLower(param)
+		if _, ok := trackingParams[name]; ok || strings.HasPrefix(name, "utm_") {
+			query.Del(param)
+		}
+	}
+	// Encode sorts the parameters by key.
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"min/internal/core/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected string
+	}{
+		{name: "lowercase scheme and host", raw: "HTTPS://Example.COM/Path", expected: "https://example.com/Path"},
+		{name: "default http port", raw: "http://example.com:80/", expected: "http://example.com/"},
+		{name: "default https port", raw: "https://example.com:443/a", expected: "https://example.com/a"},
+		{name: "custom port is kept", raw: "https://example.com:8443/a", expected: "https://example.com:8443/a"},
+		{name: "empty path", raw: "https://example.com", expected: "https://example.com/"},
+		{name: "sorted query", raw: "https://example.com/?b=2&a=1", expected: "https://example.com/?a=1&b=2"},
+		{
+			name:     "tracking parameters",
+			raw:      "https://example.com/?utm_source=mail&UTM_Campaign=q3&id=7&fbclid=abc&gclid=def",
+			expected: "https://example.com/?id=7",
+		},
+		{name: "fragment is kept", raw: "https://example.com/#/app", expected: "https://example.com/#/app"},
+		{name: "IPv6 host", raw: "http://[::1]:80/", expected: "http://[::1]/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized, err := normalizeURL(tt.raw)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, normalized)
+		})
+	}
+}
+
+func TestNormalizeURLInvalid(t *testing.T) {
+	for _, raw := range []string{"example.com/path", "/relative", "http://%zz"} {
+		_, err := normalizeURL(raw)
+		require.ErrorIs(t, err, domain.ErrInvalidURL, raw)
+	}
+}
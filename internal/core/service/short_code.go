@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"min/internal/core/port"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Base62Alphabet contains the characters that can be used in URLs without escaping or padding.
+const Base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// validateAlphabet checks that the alphabet has at least minLength characters, none of them are repeated and
+// all of them are allowed in the custom aliases (letters, digits, '-' and '_'), so the codes never need escaping.
+func validateAlphabet(alphabet string, minLength int) error {
+	if len(alphabet) < minLength {
+		return fmt.Errorf("alphabet must contain at least %d characters", minLength)
+	}
+
+	seen := make(map[rune]struct{}, len(alphabet))
+	for _, c := range alphabet {
+		if !isCodeChar(c) {
+			return fmt.Errorf("alphabet must contain only letters, digits, '-' and '_', got %q", c)
+		}
+		if _, ok := seen[c]; ok {
+			return fmt.Errorf("alphabet contains %q more than once", c)
+		}
+		seen[c] = struct{}{}
+	}
+
+	return nil
+}
+
+// isCodeChar reports whether the character is one of [A-Za-z0-9_-], the same as in aliasPattern.
+func isCodeChar(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+// encodeNumber encodes the number in the positional numeral system with the alphabet as digits.
+func encodeNumber(n uint64, alphabet string) string {
+	base := uint64(len(alphabet))
+	var code []byte
+	for {
+		code = append(code, alphabet[n%base])
+		n /= base
+		if n == 0 {
+			break
+		}
+	}
+
+	for i, j := 0, len(code)-1; i < j; i, j = i+1, j-1 {
+		code[i], code[j] = code[j], code[i]
+	}
+
+	return string(code)
+}
+
+// RandomCodeGenerator generates random codes of the fixed length. The codes may collide,
+// so the caller has to retry when the code is already taken.
+type RandomCodeGenerator struct {
+	length   int
+	alphabet string
+}
+
+// NewRandomCodeGenerator creates a generator of random codes with the given length and characters.
+func NewRandomCodeGenerator(length int, alphabet string) (*RandomCodeGenerator, error) {
+	if length <= 0 {
+		return nil, errors.New("code length must be positive")
+	}
+
+	if err := validateAlphabet(alphabet, 2); err != nil {
+		return nil, err
+	}
+
+	return &RandomCodeGenerator{length: length, alphabet: alphabet}, nil
+}
+
+// Generate returns a new random code.
+func (g *RandomCodeGenerator) Generate(_ context.Context) (string, error) {
+	code := make([]byte, g.length)
+	base := big.NewInt(int64(len(g.alphabet)))
+	for i := range code {
+		// rand.Int picks the index uniformly, unlike taking the random byte modulo the alphabet size.
+		index, err := rand.Int(rand.Reader, base)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random index: %w", err)
+		}
+		code[i] = g.alphabet[index.Int64()]
+	}
+
+	return string(code), nil
+}
+
+// CounterCodeGenerator encodes the numbers of the sequence, so the codes never collide with each other.
+// The codes are predictable, so they should not be used for the links that must not be guessed.
+type CounterCodeGenerator struct {
+	sequence port.SequenceRepository
+	alphabet string
+	// offset is added to the numbers, so that the codes have at least the minimal length.
+	offset uint64
+}
+
+// NewCounterCodeGenerator creates a generator encoding the numbers of the sequence with the alphabet.
+// The codes are at least minLength characters long.
+func NewCounterCodeGenerator(
+	sequence port.SequenceRepository,
+	minLength int,
+	alphabet string,
+) (*CounterCodeGenerator, error) {
+	if err := validateAlphabet(alphabet, 2); err != nil {
+		return nil, err
+	}
+
+	offset := uint64(1)
+	for range minLength - 1 {
+		offset *= uint64(len(alphabet))
+	}
+
+	return &CounterCodeGenerator{sequence: sequence, alphabet: alphabet, offset: offset}, nil
+}
+
+// Generate returns the code of the next number of the sequence.
+func (g *CounterCodeGenerator) Generate(ctx context.Context) (string, error) {
+	n, err := g.sequence.Next(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get next number: %w", err)
+	}
+
+	return encodeNumber(uint64(n)+g.offset, g.alphabet), nil
+}
+
+const (
+	snowflakeNodeBits     = 10
+	snowflakeSequenceBits = 12
+	snowflakeMaxNodeID    = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSequence  = 1<<snowflakeSequenceBits - 1
+)
+
+// snowflakeEpoch is the moment the timestamps of the snowflake IDs are counted from.
+var snowflakeEpoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// SnowflakeCodeGenerator encodes Snowflake-style IDs made of the milliseconds since the epoch, the node id
+// and a per-millisecond sequence. The codes of the replicas with different node ids never collide
+// and no coordination is required.
+type SnowflakeCodeGenerator struct {
+	mu         sync.Mutex
+	nodeID     int64
+	alphabet   string
+	now        func() time.Time
+	lastMillis int64
+	sequence   int64
+}
+
+// NewSnowflakeCodeGenerator creates a generator of Snowflake-style codes for the node. Every replica
+// must have a unique node id between 0 and 1023.
+func NewSnowflakeCodeGenerator(nodeID int64, alphabet string, now func() time.Time) (*SnowflakeCodeGenerator, error) {
+	if nodeID < 0 || nodeID > snowflakeMaxNodeID {
+		return nil, fmt.Errorf("node id must be between 0 and %d", snowflakeMaxNodeID)
+	}
+
+	if err := validateAlphabet(alphabet, 2); err != nil {
+		return nil, err
+	}
+
+	return &SnowflakeCodeGenerator{nodeID: nodeID, alphabet: alphabet, now: now}, nil
+}
+
+// Generate returns the code of the next ID.
+func (g *SnowflakeCodeGenerator) Generate(_ context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	millis := g.now().Sub(snowflakeEpoch).Milliseconds()
+	// The timestamps never go back, even if the clock does, so that the IDs stay unique.
+	if millis < g.lastMillis {
+		millis = g.lastMillis
+	}
+
+	if millis == g.lastMillis {
+		g.sequence = (g.sequence + 1) & snowflakeMaxSequence
+		// The sequence of the millisecond is exhausted, the next millisecond is borrowed instead of waiting.
+		if g.sequence == 0 {
+			millis++
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastMillis = millis
+
+	id := millis<<(snowflakeNodeBits+snowflakeSequenceBits) | g.nodeID<<snowflakeSequenceBits | g.sequence
+
+	return encodeNumber(uint64(id), g.alphabet), nil
+}
+
+// HashidsCodeGenerator encodes the numbers of the sequence with the Hashids algorithm. Unlike
+// CounterCodeGenerator, the codes of the consecutive numbers don't look alike without knowing the salt.
+type HashidsCodeGenerator struct {
+	sequence port.SequenceRepository
+	hashids  *hashids
+}
+
+// NewHashidsCodeGenerator creates a generator of Hashids codes with the given salt, minimal length
+// and alphabet of at least 16 characters.
+func NewHashidsCodeGenerator(
+	sequence port.SequenceRepository,
+	salt string,
+	minLength int,
+	alphabet string,
+) (*HashidsCodeGenerator, error) {
+	h, err := newHashids(salt, minLength, alphabet)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HashidsCodeGenerator{sequence: sequence, hashids: h}, nil
+}
+
+// Generate returns the code of the next number of the sequence.
+func (g *HashidsCodeGenerator) Generate(ctx context.Context) (string, error) {
+	n, err := g.sequence.Next(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get next number: %w", err)
+	}
+
+	return g.hashids.encode(n), nil
+}
+
+const (
+	hashidsMinAlphabetLength = 16
+	hashidsSeparators        = "cfhistuCFHISTU"
+	hashidsSeparatorDiv      = 3.5
+	hashidsGuardDiv          = 12
+)
+
+// hashids implements the encoding of a single number of the Hashids algorithm (https://hashids.org),
+// so the codes match the ones of the other implementations with the same parameters.
+type hashids struct {
+	salt      []byte
+	minLength int
+	alphabet  []byte
+	seps      []byte
+	guards    []byte
+}
+
+func newHashids(salt string, minLength int, alphabet string) (*hashids, error) {
+	if err := validateAlphabet(alphabet, hashidsMinAlphabetLength); err != nil {
+		return nil, err
+	}
+
+	h := &hashids{salt: []byte(salt), minLength: minLength}
+
+	// The separators are taken out of the alphabet.
+	for _, c := range []byte(hashidsSeparators) {
+		if strings.IndexByte(alphabet, c) >= 0 {
+			h.seps = append(h.seps, c)
+		}
+	}
+	for _, c := range []byte(alphabet) {
+		if strings.IndexByte(hashidsSeparators, c) < 0 {
+			h.alphabet = append(h.alphabet, c)
+		}
+	}
+	consistentShuffle(h.seps, h.salt)
+
+	if len(h.seps) == 0 || float64(len(h.alphabet))/float64(len(h.seps)) > hashidsSeparatorDiv {
+		sepsLength := int(math.Ceil(float64(len(h.alphabet)) / hashidsSeparatorDiv))
+		if sepsLength == 1 {
+			sepsLength++
+		}
+
+		if sepsLength > len(h.seps) {
+			diff := sepsLength - len(h.seps)
+			h.seps = append(h.seps, h.alphabet[:diff]...)
+			h.alphabet = h.alphabet[diff:]
+		} else {
+			h.seps = h.seps[:sepsLength]
+		}
+	}
+
+	consistentShuffle(h.alphabet, h.salt)
+
+	guardCount := (len(h.alphabet) + hashidsGuardDiv - 1) / hashidsGuardDiv
+	if len(h.alphabet) < 3 {
+		h.guards = h.seps[:guardCount]
+		h.seps = h.seps[guardCount:]
+	} else {
+		h.guards = h.alphabet[:guardCount]
+		h.alphabet = h.alphabet[guardCount:]
+	}
+
+	return h, nil
+}
+
+func (h *hashids) encode(n int64) string {
+	alphabet := append([]byte(nil), h.alphabet...)
+	numbersHash := n % 100
+
+	lottery := alphabet[numbersHash%int64(len(alphabet))]
+	code := []byte{lottery}
+
+	buffer := append(append([]byte{lottery}, h.salt...), alphabet...)
+	consistentShuffle(alphabet, buffer[:len(alphabet)])
+	code = append(code, hashidsHash(n, alphabet)...)
+
+	if len(code) < h.minLength {
+		guardIndex := (numbersHash + int64(code[0])) % int64(len(h.guards))
+		code = append([]byte{h.guards[guardIndex]}, code...)
+
+		if len(code) < h.minLength {
+			guardIndex = (numbersHash + int64(code[2])) % int64(len(h.guards))
+			code = append(code, h.guards[guardIndex])
+		}
+	}
+
+	halfLength := len(alphabet) / 2
+	for len(code) < h.minLength {
+		consistentShuffle(alphabet, append([]byte(nil), alphabet...))
+		code = append(append(append([]byte(nil), alphabet[halfLength:]...), code...), alphabet[:halfLength]...)
+
+		if excess := len(code) - h.minLength; excess > 0 {
+			code = code[excess/2 : excess/2+h.minLength]
+		}
+	}
+
+	return string(code)
+}
+
+// hashidsHash encodes the number with the alphabet as digits.
+func hashidsHash(n int64, alphabet []byte) []byte {
+	var hash []byte
+	for {
+		hash = append([]byte{alphabet[n%int64(len(alphabet))]}, hash...)
+		n /= int64(len(alphabet))
+		if n == 0 {
+			return hash
+		}
+	}
+}
+
+// consistentShuffle shuffles the alphabet in place deterministically for the given salt.
+func consistentShuffle(alphabet, salt []byte) {
+	if len(salt) == 0 {
+		return
+	}
+
+	for i, v, p := len(alphabet)-1, 0, 0; i > 0; i, v = i-1, v+1 {
+		v %= len(salt)
+		integer := int(salt[v])
+		p += integer
+		j := (integer + v + p) % i
+		alphabet[i], alphabet[j] = alphabet[j], alphabet[i]
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"min/internal/mocks"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandomCodeGenerator(t *testing.T) {
+	generator, err := NewRandomCodeGenerator(7, Base62Alphabet)
+	require.NoError(t, err)
+
+	for range 100 {
+		code, err := generator.Generate(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, code, 7)
+		assert.False(t, strings.ContainsAny(code, "-_="), code)
+	}
+}
+
+func TestRandomCodeGeneratorInvalid(t *testing.T) {
+	_, err := NewRandomCodeGenerator(0, Base62Alphabet)
+	require.Error(t, err)
+
+	_, err = NewRandomCodeGenerator(7, "aab")
+	require.Error(t, err)
+
+	for _, alphabet := range []string{"ab/", "ab?", "ab#", "ab%", "ab\t", "ab\x00", "ab ", "abé"} {
+		_, err = NewRandomCodeGenerator(7, alphabet)
+		require.Error(t, err, alphabet)
+	}
+
+	_, err = NewRandomCodeGenerator(7, Base62Alphabet+"-_")
+	require.NoError(t, err)
+}
+
+func TestCounterCodeGenerator(t *testing.T) {
+	sequence := new(mocks.SequenceRepository)
+	sequence.On("Next", mock.Anything).Return(int64(1), nil).Once()
+	sequence.On("Next", mock.Anything).Return(int64(2), nil).Once()
+
+	generator, err := NewCounterCodeGenerator(sequence, 4, Base62Alphabet)
+	require.NoError(t, err)
+
+	first, err := generator.Generate(context.Background())
+	require.NoError(t, err)
+	second, err := generator.Generate(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "1001", first)
+	assert.Equal(t, "1002", second)
+	sequence.AssertExpectations(t)
+}
+
+func TestSnowflakeCodeGenerator(t *testing.T) {
+	now := snowflakeEpoch.Add(time.Hour)
+	clock := func() time.Time { return now }
+
+	generator, err := NewSnowflakeCodeGenerator(1, Base62Alphabet, clock)
+	require.NoError(t, err)
+	other, err := NewSnowflakeCodeGenerator(2, Base62Alphabet, clock)
+	require.NoError(t, err)
+
+	seen := make(map[string]struct{})
+	for range snowflakeMaxSequence + 10 {
+		for _, g := range []*SnowflakeCodeGenerator{generator, other} {
+			code, err := g.Generate(context.Background())
+			require.NoError(t, err)
+			_, duplicate := seen[code]
+			require.False(t, duplicate, code)
+			seen[code] = struct{}{}
+		}
+	}
+
+	// The codes stay unique when the clock goes back.
+	now = now.Add(-time.Minute)
+	code, err := generator.Generate(context.Background())
+	require.NoError(t, err)
+	_, duplicate := seen[code]
+	assert.False(t, duplicate, code)
+}
+
+func TestSnowflakeCodeGeneratorInvalidNode(t *testing.T) {
+	_, err := NewSnowflakeCodeGenerator(snowflakeMaxNodeID+1, Base62Alphabet, time.Now)
+	require.Error(t, err)
+}
+
+func TestHashids(t *testing.T) {
+	const defaultAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890"
+
+	// The codes match the reference implementation.
+	h, err := newHashids("this is my salt", 0, defaultAlphabet)
+	require.NoError(t, err)
+	assert.Equal(t, "NkK9", h.encode(12345))
+
+	h, err = newHashids("this is my salt", 8, defaultAlphabet)
+	require.NoError(t, err)
+	assert.Equal(t, "gB0NV05e", h.encode(1))
+}
+
+func TestHashidsCodeGenerator(t *testing.T) {
+	sequence := new(mocks.SequenceRepository)
+	sequence.On("Next", mock.Anything).Return(int64(1), nil).Once()
+	sequence.On("Next", mock.Anything).Return(int64(2), nil).Once()
+
+	generator, err := NewHashidsCodeGenerator(sequence, "salt", 6, Base62Alphabet)
+	require.NoError(t, err)
+
+	first, err := generator.Generate(context.Background())
+	require.NoError(t, err)
+	second, err := generator.Generate(context.Background())
+	require.NoError(t, err)
+
+	assert.Len(t, first, 6)
+	assert.Len(t, second, 6)
+	assert.NotEqual(t, first, second)
+	sequence.AssertExpectations(t)
+}
+
+func TestHashidsShortAlphabet(t *testing.T) {
+	_, err := NewHashidsCodeGenerator(nil, "salt", 6, "0123456789")
+	require.Error(t, err)
+}
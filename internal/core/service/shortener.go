@@ -2,102 +2,281 @@ package service
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"min/internal/core/domain"
 	"min/internal/core/port"
+	"regexp"
+	"strings"
+	"time"
 )
 
+const (
+	// maxGenerateAttempts is the number of short codes tried before giving up if the generated codes are taken.
+	maxGenerateAttempts = 5
+	// defaultLinksLimit is the number of links returned per page if the limit is not specified.
+	defaultLinksLimit = 20
+	// maxLinksLimit is the maximal number of links returned per page.
+	maxLinksLimit = 100
+)
+
+// aliasPattern describes the characters and length allowed for custom aliases.
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,64}$`)
+
+// reservedAliases contains the aliases that clash with the routes of the shortener.
+var reservedAliases = map[string]struct{}{
+	"admin":    {},
+	"api":      {},
+	"links":    {},
+	"login":    {},
+	"logout":   {},
+	"plans":    {},
+	"refresh":  {},
+	"register": {},
+	"remove":   {},
+	"shorten":  {},
+	"static":   {},
+	"users":    {},
+}
+
 type Shortener struct {
-	repository    port.ShortenerRepository
-	cache         port.ShortenerCache
-	authClient    port.AuthClient
-	shortenLength int
+	repository port.ShortenerRepository
+	cache      port.ShortenerCache
+	generator  port.ShortCodeGenerator
+	authClient port.AuthClient
 }
 
 func NewShortener(
 	repository port.ShortenerRepository,
 	cache port.ShortenerCache,
-	shortenLength int,
+	generator port.ShortCodeGenerator,
 	authClient port.AuthClient,
 ) *Shortener {
 	return &Shortener{
-		repository:    repository,
-		cache:         cache,
-		shortenLength: shortenLength,
-		authClient:    authClient,
+		repository: repository,
+		cache:      cache,
+		generator:  generator,
+		authClient: authClient,
 	}
 }
 
-func (s *Shortener) Resolve(ctx context.Context, short string) (string, error) {
-	original, err := s.cache.GetOriginal(ctx, short)
+func (s *Shortener) Resolve(ctx context.Context, short string) (*domain.URL, error) {
+	url, err := s.cache.Get(ctx, short)
 	if err != nil {
-		return "", fmt.Errorf("failed to get short url from cache: %w", err)
+		return nil, fmt.Errorf("failed to get short url from cache: %w", err)
 	}
 
-	if original == "" {
-		original, err = s.repository.GetOriginal(ctx, short)
-		if err != nil {
-			return "", fmt.Errorf("failed to get original url from repository: %w", err)
-		}
+	if url != nil {
+		return url, nil
+	}
+
+	url, err = s.repository.Get(ctx, short)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get original url from repository: %w", err)
 	}
 
-	if original == "" {
-		return "", errors.New("short URL not found")
+	if url == nil {
+		return nil, domain.ErrShortURLNotFound
 	}
 
-	return original, nil
+	if url.IsExpired(time.Now()) {
+		return nil, domain.ErrURLExpired
+	}
+
+	if err := s.cache.Add(ctx, url); err != nil {
+		log.Warnf("Failed to add short URL to cache: %v", err)
+	}
+
+	return url, nil
 }
 
-func (s *Shortener) Shorten(ctx context.Context, url string, author *domain.User) (string, error) {
+func (s *Shortener) Shorten(
+	ctx context.Context,
+	url *domain.URL,
+	author *domain.Principal,
+	opts domain.ShortenOptions,
+) (string, error) {
+	normalized, err := normalizeURL(url.Original)
+	if err != nil {
+		return "", err
+	}
+	url.Normalized = normalized
+	url.Owner = author.Username
+
+	now := time.Now()
+	// Reusing an existing link is free, so it is looked up before the quota is checked. Only the links with
+	// the same redirect options are reused, and the expiration of the existing link can't be changed.
+	if opts.Deduplicate && url.Short == "" {
+		if !url.ExpiresAt.IsZero() {
+			return "", fmt.Errorf("%w: can't be set for deduplicated links", domain.ErrInvalidExpiration)
+		}
+
+		existing, err := s.repository.FindByNormalized(ctx, url, now)
+		if err != nil {
+			return "", fmt.Errorf("failed to find existing short URL: %w", err)
+		}
+
+		if existing != nil {
+			return existing.Short, nil
+		}
+	}
+
+	// The remaining links of the principal may be stale, the quota is enforced atomically after storing the URL.
 	if author.LinksRemaining <= 0 {
-		return "", errors.New("no links remaining, please upgrade your account or remove some existing links")
+		return "", domain.ErrLinkQuotaExceeded
 	}
 
-	shorten, err := s.generateShortURL()
-	if err != nil {
-		return "", fmt.Errorf("failed to generate short URL: %w", err)
+	if url.IsExpired(now) {
+		return "", fmt.Errorf("%w: must be in the future", domain.ErrInvalidExpiration)
 	}
 
-	if err := s.repository.Add(ctx, shorten, url); err != nil {
-		return "", fmt.Errorf("failed to add short URL to repository: %w", err)
+	if url.RedirectCode != 0 && !domain.IsRedirectCode(url.RedirectCode) {
+		return "", fmt.Errorf("%w: must be 301, 302, 307 or 308", domain.ErrInvalidRedirectCode)
 	}
 
-	if err := s.cache.Add(ctx, shorten, url); err != nil {
-		return "", fmt.Errorf("failed to add short URL to cache: %w", err)
+	// Links of the plans with a limited lifetime expire after the maximal lifetime unless they expire earlier.
+	if maxLifetime := author.Limits.MaxLinkLifetime; maxLifetime > 0 {
+		maxExpiresAt := now.Add(maxLifetime)
+		switch {
+		case url.ExpiresAt.IsZero():
+			url.ExpiresAt = maxExpiresAt
+		case url.ExpiresAt.After(maxExpiresAt):
+			return "", fmt.Errorf("%w: the plan allows links to live at most %s", domain.ErrInvalidExpiration, maxLifetime)
+		}
 	}
 
-	err = s.authClient.ChangeLinksRemaining(ctx, author.Username, author.LinksRemaining-1)
-	if err != nil {
-		log.Infof("Failed to change links remaining: %v", err)
-		return "", fmt.Errorf("failed to change links remaining: %w", err)
+	if url.Short != "" {
+		if !author.Limits.CustomAliases {
+			return "", fmt.Errorf("%w: custom aliases are not available on the %s plan", domain.ErrForbidden, author.Plan)
+		}
+
+		if err := validateAlias(url.Short); err != nil {
+			return "", err
+		}
+
+		if err := s.repository.Add(ctx, url); err != nil {
+			return "", fmt.Errorf("failed to add short URL to repository: %w", err)
+		}
+	} else if err := s.addWithGeneratedCode(ctx, url); err != nil {
+		return "", err
+	}
+
+	// The stored URL is removed if the link can't be paid for, so that it is never available for free.
+	if err := s.authClient.ConsumeLinkQuota(ctx, author.Username); err != nil {
+		if removeErr := s.repository.Remove(ctx, url.Short); removeErr != nil {
+			log.Errorf("Failed to remove short URL %s after quota failure: %v", url.Short, removeErr)
+		}
+		return "", fmt.Errorf("failed to consume link quota: %w", err)
+	}
+
+	// The link is already stored and paid for, it will be cached on the first resolve.
+	if err := s.cache.Add(ctx, url); err != nil {
+		log.Warnf("Failed to add short URL to cache: %v", err)
 	}
 
-	return shorten, nil
+	return url.Short, nil
 }
 
-func (s *Shortener) Remove(ctx context.Context, short string) error {
+// Remove deletes the short URL on behalf of its owner or an admin. The link quota is credited to the owner
+// in both cases, so an admin removing a link doesn't use up the owner's plan.
+func (s *Shortener) Remove(ctx context.Context, short string, caller *domain.Principal) error {
+	url, err := s.repository.Get(ctx, short)
+	if err != nil {
+		return fmt.Errorf("failed to get short URL from repository: %w", err)
+	}
+
+	if url == nil {
+		return domain.ErrShortURLNotFound
+	}
+
+	isOwner := url.Owner == caller.Username
+	if !isOwner && caller.Role != domain.ADMIN {
+		return fmt.Errorf("%w: only the owner or an admin can remove the short URL", domain.ErrForbidden)
+	}
+
 	if err := s.repository.Remove(ctx, short); err != nil {
 		return fmt.Errorf("failed to remove short URL from repository: %w", err)
 	}
 
+	// Links without expiration are cached without TTL, so a cached copy would keep redirecting forever.
+	// The failure is returned and the quota is not refunded for the link that still works.
 	if err := s.cache.Remove(ctx, short); err != nil {
 		return fmt.Errorf("failed to remove short URL from cache: %w", err)
 	}
 
+	if err = s.authClient.RefundLinkQuota(ctx, url.Owner); err != nil {
+		return fmt.Errorf("failed to refund link quota: %w", err)
+	}
+
 	return nil
 }
 
-// generateShortURL generates a random short URL.
-func (s *Shortener) generateShortURL() (string, error) {
-	// Generate shortenLength random bytes
-	bytes := make([]byte, s.shortenLength)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+// RemoveExpired deletes expired URLs from the repository. Cached URLs expire on their own.
+func (s *Shortener) RemoveExpired(ctx context.Context) (int64, error) {
+	removed, err := s.repository.RemoveExpired(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove expired URLs from repository: %w", err)
 	}
-	// Encode the bytes to a base64 URL safe string
-	return base64.URLEncoding.EncodeToString(bytes), nil
+
+	return removed, nil
+}
+
+// List returns a page of URLs created by query.Owner. The limit is clamped to the allowed range.
+func (s *Shortener) List(ctx context.Context, query domain.LinksQuery) (*domain.LinksPage, error) {
+	if query.Limit <= 0 {
+		query.Limit = defaultLinksLimit
+	}
+	query.Limit = min(query.Limit, maxLinksLimit)
+
+	page, err := s.repository.ListByOwner(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list URLs from repository: %w", err)
+	}
+
+	return page, nil
+}
+
+// addWithGeneratedCode stores the URL with a generated short code. Another code is generated
+// if the previous one is already taken, e.g. by a custom alias or after a collision of random codes.
+func (s *Shortener) addWithGeneratedCode(ctx context.Context, url *domain.URL) error {
+	for attempt := 1; ; attempt++ {
+		code, err := s.generator.Generate(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to generate short URL: %w", err)
+		}
+		url.Short = code
+
+		err = s.repository.Add(ctx, url)
+		if err == nil {
+			return nil
+		}
+
+		if !errors.Is(err, domain.ErrShortURLExists) {
+			return fmt.Errorf("failed to add short URL to repository: %w", err)
+		}
+
+		// The conflict is not the caller's fault, so it is not reported as domain.ErrShortURLExists.
+		if attempt == maxGenerateAttempts {
+			return fmt.Errorf("failed to generate a free short URL in %d attempts", maxGenerateAttempts)
+		}
+
+		log.Warnf("Generated short URL %s is already taken, generating another one", code)
+	}
+}
+
+// validateAlias checks that the alias contains only allowed characters and is not reserved.
+func validateAlias(alias string) error {
+	if !aliasPattern.MatchString(alias) {
+		return fmt.Errorf(
+			"%w: must be 3-64 characters long and contain only letters, digits, '-' or '_'",
+			domain.ErrInvalidAlias,
+		)
+	}
+
+	if _, ok := reservedAliases[strings.ToLower(alias)]; ok {
+		return fmt.Errorf("%w: %q is reserved", domain.ErrInvalidAlias, alias)
+	}
+
+	return nil
 }
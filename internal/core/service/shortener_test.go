@@ -3,7 +3,9 @@ package service_test
 import (
 	"context"
 	"errors"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -13,136 +15,575 @@ import (
 	"min/internal/mocks"
 )
 
+// newTestGenerator creates a generator of random 8 character codes.
+func newTestGenerator(t *testing.T) *service.RandomCodeGenerator {
+	t.Helper()
+
+	generator, err := service.NewRandomCodeGenerator(8, service.Base62Alphabet)
+	require.NoError(t, err)
+
+	return generator
+}
+
 func TestShortener_Resolve(t *testing.T) {
 	repoMock := new(mocks.ShortenerRepository)
 	cacheMock := new(mocks.ShortenerCache)
 	authClientMock := new(mocks.AuthClient)
-	shortener := service.NewShortener(repoMock, cacheMock, 8, authClientMock)
+	shortener := service.NewShortener(repoMock, cacheMock, newTestGenerator(t), authClientMock)
 
 	t.Run("resolve from cache", func(t *testing.T) {
 		cacheMock.On(
-			"GetOriginal",
+			"Get",
 			mock.Anything,
 			"shortUrl",
-		).Return("http://original.url", nil).Once()
+		).Return(&domain.URL{Short: "shortUrl", Original: "http://original.url", Owner: "user"}, nil).Once()
 
-		original, err := shortener.Resolve(context.Background(), "shortUrl")
+		resolved, err := shortener.Resolve(context.Background(), "shortUrl")
 		require.NoError(t, err)
-		assert.Equal(t, "http://original.url", original)
-		cacheMock.AssertCalled(t, "GetOriginal", mock.Anything, "shortUrl")
+		assert.Equal(t, "http://original.url", resolved.Original)
+		assert.Equal(t, "user", resolved.Owner)
+		cacheMock.AssertCalled(t, "Get", mock.Anything, "shortUrl")
 	})
 
 	t.Run("resolve from repository", func(t *testing.T) {
-		cacheMock.On("GetOriginal", mock.Anything, "shortUrl").Return("", nil).Once()
-		repoMock.On(
-			"GetOriginal",
-			mock.Anything,
-			"shortUrl",
-		).Return("http://original.url", nil).Once()
+		url := &domain.URL{Short: "shortUrl", Original: "http://original.url", Owner: "user"}
+		cacheMock.On("Get", mock.Anything, "shortUrl").Return(nil, nil).Once()
+		repoMock.On("Get", mock.Anything, "shortUrl").Return(url, nil).Once()
+		cacheMock.On("Add", mock.Anything, url).Return(nil).Once()
 
-		original, err := shortener.Resolve(context.Background(), "shortUrl")
+		resolved, err := shortener.Resolve(context.Background(), "shortUrl")
 		require.NoError(t, err)
-		assert.Equal(t, "http://original.url", original)
-		cacheMock.AssertCalled(t, "GetOriginal", mock.Anything, "shortUrl")
-		repoMock.AssertCalled(t, "GetOriginal", mock.Anything, "shortUrl")
+		assert.Equal(t, "http://original.url", resolved.Original)
+		cacheMock.AssertCalled(t, "Get", mock.Anything, "shortUrl")
+		cacheMock.AssertCalled(t, "Add", mock.Anything, url)
+		repoMock.AssertCalled(t, "Get", mock.Anything, "shortUrl")
+	})
+
+	t.Run("cache population error is ignored", func(t *testing.T) {
+		url := &domain.URL{Short: "shortUrl", Original: "http://original.url"}
+		cacheMock.On("Get", mock.Anything, "shortUrl").Return(nil, nil).Once()
+		repoMock.On("Get", mock.Anything, "shortUrl").Return(url, nil).Once()
+		cacheMock.On("Add", mock.Anything, url).Return(errors.New("cache error")).Once()
+
+		resolved, err := shortener.Resolve(context.Background(), "shortUrl")
+		require.NoError(t, err)
+		assert.Equal(t, "http://original.url", resolved.Original)
 	})
 
 	t.Run("short URL not found", func(t *testing.T) {
-		cacheMock.On("GetOriginal", mock.Anything, "shortUrl").Return("", nil).Once()
-		repoMock.On("GetOriginal", mock.Anything, "shortUrl").Return("", nil).Once()
+		cacheMock.On("Get", mock.Anything, "shortUrl").Return(nil, nil).Once()
+		repoMock.On("Get", mock.Anything, "shortUrl").Return(nil, nil).Once()
 
-		original, err := shortener.Resolve(context.Background(), "shortUrl")
+		resolved, err := shortener.Resolve(context.Background(), "shortUrl")
 		require.Error(t, err)
-		assert.Empty(t, original)
+		assert.Nil(t, resolved)
 		assert.Equal(t, "short URL not found", err.Error())
 	})
+
+	t.Run("short URL has expired", func(t *testing.T) {
+		cacheMock.On("Get", mock.Anything, "shortUrl").Return(nil, nil).Once()
+		repoMock.On("Get", mock.Anything, "shortUrl").Return(&domain.URL{
+			Short:     "shortUrl",
+			Original:  "http://original.url",
+			ExpiresAt: time.Now().Add(-time.Minute),
+		}, nil).Once()
+
+		resolved, err := shortener.Resolve(context.Background(), "shortUrl")
+		require.ErrorIs(t, err, domain.ErrURLExpired)
+		assert.Nil(t, resolved)
+	})
 }
 
 func TestShortener_Shorten(t *testing.T) {
 	repoMock := new(mocks.ShortenerRepository)
 	cacheMock := new(mocks.ShortenerCache)
 	authClientMock := new(mocks.AuthClient)
-	shortener := service.NewShortener(repoMock, cacheMock, 8, authClientMock)
+	shortener := service.NewShortener(repoMock, cacheMock, newTestGenerator(t), authClientMock)
 
 	t.Run("successful shorten", func(t *testing.T) {
-		user := &domain.User{Username: "user", LinksRemaining: 5}
-		repoMock.On("Add", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
-		cacheMock.On(
-			"Add",
-			mock.Anything,
-			mock.Anything,
-			mock.Anything,
-		).Return(nil).Once()
-		authClientMock.On(
-			"ChangeLinksRemaining",
-			mock.Anything,
-			user.Username,
-			int64(4),
-		).Return(nil).Once()
+		user := &domain.Principal{Username: "user", LinksRemaining: 5}
+		repoMock.On("Add", mock.Anything, mock.MatchedBy(func(url *domain.URL) bool {
+			return url.Owner == "user"
+		})).Return(nil).Once()
+		cacheMock.On("Add", mock.Anything, mock.Anything).Return(nil).Once()
+		authClientMock.On("ConsumeLinkQuota", mock.Anything, user.Username).Return(nil).Once()
 
-		short, err := shortener.Shorten(context.Background(), "http://original.url", user)
+		url := domain.NewURL("", "http://original.url", time.Time{})
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
 		require.NoError(t, err)
 		assert.NotEmpty(t, short)
-		repoMock.AssertCalled(t, "Add", mock.Anything, mock.Anything, "http://original.url")
-		cacheMock.AssertCalled(t, "Add", mock.Anything, mock.Anything, "http://original.url")
-		authClientMock.AssertCalled(t, "ChangeLinksRemaining", mock.Anything, user.Username, int64(4))
+		repoMock.AssertCalled(t, "Add", mock.Anything, url)
+		cacheMock.AssertCalled(t, "Add", mock.Anything, url)
+		authClientMock.AssertCalled(t, "ConsumeLinkQuota", mock.Anything, user.Username)
 	})
 
 	t.Run("no links remaining", func(t *testing.T) {
-		user := &domain.User{Username: "user", LinksRemaining: 0}
+		user := &domain.Principal{Username: "user", LinksRemaining: 0}
 
-		short, err := shortener.Shorten(context.Background(), "http://original.url", user)
+		url := domain.NewURL("", "http://original.url", time.Time{})
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
 		require.Error(t, err)
 		assert.Empty(t, short)
 		assert.Equal(t, "no links remaining, please upgrade your account or remove some existing links", err.Error())
 	})
 
+	t.Run("quota exhausted concurrently", func(t *testing.T) {
+		user := &domain.Principal{Username: "racer", LinksRemaining: 1}
+		url := domain.NewURL("", "http://original.url", time.Time{})
+		repoMock.On("Add", mock.Anything, url).Return(nil).Once()
+		authClientMock.On("ConsumeLinkQuota", mock.Anything, "racer").Return(domain.ErrLinkQuotaExceeded).Once()
+		repoMock.On("Remove", mock.Anything, mock.Anything).Return(nil).Once()
+
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.ErrorIs(t, err, domain.ErrLinkQuotaExceeded)
+		assert.Empty(t, short)
+		repoMock.AssertCalled(t, "Remove", mock.Anything, url.Short)
+		cacheMock.AssertNotCalled(t, "Add", mock.Anything, url)
+	})
+
+	t.Run("cache error does not fail stored link", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", LinksRemaining: 5}
+		url := domain.NewURL("", "http://original.url/cached", time.Time{})
+		repoMock.On("Add", mock.Anything, url).Return(nil).Once()
+		authClientMock.On("ConsumeLinkQuota", mock.Anything, "user").Return(nil).Once()
+		cacheMock.On("Add", mock.Anything, url).Return(errors.New("cache error")).Once()
+
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.NoError(t, err)
+		assert.NotEmpty(t, short)
+	})
+
 	t.Run("failed to add to repository", func(t *testing.T) {
-		user := &domain.User{Username: "user", LinksRemaining: 5}
+		user := &domain.Principal{Username: "user", LinksRemaining: 5}
+		repoMock.On("Add", mock.Anything, mock.Anything).Return(errors.New("repo error"))
+
+		url := domain.NewURL("", "http://original.url", time.Time{})
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.Error(t, err)
+		assert.Empty(t, short)
+		assert.Contains(t, err.Error(), "failed to add short URL to repository")
+	})
+}
+
+func TestShortener_ShortenWithExpiration(t *testing.T) {
+	repoMock := new(mocks.ShortenerRepository)
+	cacheMock := new(mocks.ShortenerCache)
+	authClientMock := new(mocks.AuthClient)
+	shortener := service.NewShortener(repoMock, cacheMock, newTestGenerator(t), authClientMock)
+
+	t.Run("successful shorten with expiration", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", LinksRemaining: 5}
+		url := domain.NewURL("", "http://original.url", time.Now().Add(time.Hour))
+		repoMock.On("Add", mock.Anything, url).Return(nil).Once()
+		cacheMock.On("Add", mock.Anything, url).Return(nil).Once()
+		authClientMock.On("ConsumeLinkQuota", mock.Anything, user.Username).Return(nil).Once()
+
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.NoError(t, err)
+		assert.NotEmpty(t, short)
+	})
+
+	t.Run("expiration in the past", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", LinksRemaining: 5}
+		url := domain.NewURL("", "http://original.url", time.Now().Add(-time.Hour))
+
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.ErrorIs(t, err, domain.ErrInvalidExpiration)
+		assert.Empty(t, short)
+	})
+
+	t.Run("plan lifetime applied to links without expiration", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", LinksRemaining: 5, Limits: domain.PlanLimits{MaxLinkLifetime: time.Hour}}
+		url := domain.NewURL("", "http://original.url/limited", time.Time{})
+		repoMock.On("Add", mock.Anything, url).Return(nil).Once()
+		cacheMock.On("Add", mock.Anything, url).Return(nil).Once()
+		authClientMock.On("ConsumeLinkQuota", mock.Anything, user.Username).Return(nil).Once()
+
+		_, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), url.ExpiresAt, time.Minute)
+	})
+
+	t.Run("expiration beyond plan lifetime", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", LinksRemaining: 5, Limits: domain.PlanLimits{MaxLinkLifetime: time.Hour}}
+		url := domain.NewURL("", "http://original.url", time.Now().Add(2*time.Hour))
+
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.ErrorIs(t, err, domain.ErrInvalidExpiration)
+		assert.Empty(t, short)
+	})
+
+	repoMock.AssertExpectations(t)
+	cacheMock.AssertExpectations(t)
+	authClientMock.AssertExpectations(t)
+}
+
+func TestShortener_ShortenWithRedirectCode(t *testing.T) {
+	repoMock := new(mocks.ShortenerRepository)
+	cacheMock := new(mocks.ShortenerCache)
+	authClientMock := new(mocks.AuthClient)
+	shortener := service.NewShortener(repoMock, cacheMock, newTestGenerator(t), authClientMock)
+	user := &domain.Principal{Username: "user", LinksRemaining: 5}
+
+	t.Run("supported redirect code", func(t *testing.T) {
+		url := domain.NewURL("", "http://original.url", time.Time{})
+		url.RedirectCode = http.StatusTemporaryRedirect
+		repoMock.On("Add", mock.Anything, url).Return(nil).Once()
+		cacheMock.On("Add", mock.Anything, url).Return(nil).Once()
+		authClientMock.On("ConsumeLinkQuota", mock.Anything, user.Username).Return(nil).Once()
+
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.NoError(t, err)
+		assert.NotEmpty(t, short)
+	})
+
+	t.Run("unsupported redirect code", func(t *testing.T) {
+		url := domain.NewURL("", "http://original.url", time.Time{})
+		url.RedirectCode = http.StatusOK
+
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.ErrorIs(t, err, domain.ErrInvalidRedirectCode)
+		assert.Empty(t, short)
+	})
+
+	repoMock.AssertExpectations(t)
+	cacheMock.AssertExpectations(t)
+	authClientMock.AssertExpectations(t)
+}
+
+func TestShortener_ShortenGeneratedCodeTaken(t *testing.T) {
+	repoMock := new(mocks.ShortenerRepository)
+	cacheMock := new(mocks.ShortenerCache)
+	authClientMock := new(mocks.AuthClient)
+	generatorMock := new(mocks.ShortCodeGenerator)
+	shortener := service.NewShortener(repoMock, cacheMock, generatorMock, authClientMock)
+	user := &domain.Principal{Username: "user", LinksRemaining: 5}
+
+	t.Run("taken code is generated again", func(t *testing.T) {
+		generatorMock.On("Generate", mock.Anything).Return("taken", nil).Once()
+		generatorMock.On("Generate", mock.Anything).Return("free", nil).Once()
+		repoMock.On("Add", mock.Anything, mock.MatchedBy(func(url *domain.URL) bool {
+			return url.Short == "taken"
+		})).Return(domain.ErrShortURLExists).Once()
+		repoMock.On("Add", mock.Anything, mock.MatchedBy(func(url *domain.URL) bool {
+			return url.Short == "free"
+		})).Return(nil).Once()
+		cacheMock.On("Add", mock.Anything, mock.Anything).Return(nil).Once()
+		authClientMock.On("ConsumeLinkQuota", mock.Anything, "user").Return(nil).Once()
+
+		url := domain.NewURL("", "http://original.url", time.Time{})
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "free", short)
+	})
+
+	t.Run("all attempts taken", func(t *testing.T) {
+		generatorMock.On("Generate", mock.Anything).Return("taken", nil).Times(5)
+		repoMock.On("Add", mock.Anything, mock.Anything).Return(domain.ErrShortURLExists).Times(5)
+
+		url := domain.NewURL("", "http://original.url", time.Time{})
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.Error(t, err)
+		require.NotErrorIs(t, err, domain.ErrShortURLExists)
+		assert.Empty(t, short)
+	})
+
+	repoMock.AssertExpectations(t)
+	cacheMock.AssertExpectations(t)
+	generatorMock.AssertExpectations(t)
+	authClientMock.AssertExpectations(t)
+}
+
+func TestShortener_ShortenDeduplicate(t *testing.T) {
+	repoMock := new(mocks.ShortenerRepository)
+	cacheMock := new(mocks.ShortenerCache)
+	authClientMock := new(mocks.AuthClient)
+	shortener := service.NewShortener(repoMock, cacheMock, newTestGenerator(t), authClientMock)
+	opts := domain.ShortenOptions{Deduplicate: true}
+
+	t.Run("existing link is reused without quota", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", LinksRemaining: 0}
 		repoMock.On(
-			"Add",
-			mock.Anything,
+			"FindByNormalized",
 			mock.Anything,
+			mock.MatchedBy(func(url *domain.URL) bool {
+				return url.Owner == "user" && url.Normalized == "https://example.com/?id=7"
+			}),
 			mock.Anything,
-		).Return(errors.New("repo error"))
+		).Return(&domain.URL{Short: "existing"}, nil).Once()
 
-		short, err := shortener.Shorten(context.Background(), "http://original.url", user)
-		require.Error(t, err)
+		url := domain.NewURL("", "HTTPS://Example.com:443?utm_source=mail&id=7", time.Time{})
+		short, err := shortener.Shorten(context.Background(), url, user, opts)
+		require.NoError(t, err)
+		assert.Equal(t, "existing", short)
+	})
+
+	t.Run("new link is created when there is none", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", LinksRemaining: 5}
+		url := domain.NewURL("", "https://example.com/new", time.Time{})
+		repoMock.On("FindByNormalized", mock.Anything, url, mock.Anything).Return(nil, nil).Once()
+		repoMock.On("Add", mock.Anything, mock.MatchedBy(func(url *domain.URL) bool {
+			return url.Normalized == "https://example.com/new"
+		})).Return(nil).Once()
+		cacheMock.On("Add", mock.Anything, url).Return(nil).Once()
+		authClientMock.On("ConsumeLinkQuota", mock.Anything, "user").Return(nil).Once()
+
+		short, err := shortener.Shorten(context.Background(), url, user, opts)
+		require.NoError(t, err)
+		assert.NotEmpty(t, short)
+	})
+
+	t.Run("redirect options are matched", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", LinksRemaining: 0}
+		url := domain.NewURL("", "https://example.com/options", time.Time{})
+		url.RedirectCode = http.StatusMovedPermanently
+		url.ForwardQuery = true
+		repoMock.On("FindByNormalized", mock.Anything, mock.MatchedBy(func(url *domain.URL) bool {
+			return url.RedirectCode == http.StatusMovedPermanently && url.ForwardQuery
+		}), mock.Anything).Return(&domain.URL{Short: "permanent"}, nil).Once()
+
+		short, err := shortener.Shorten(context.Background(), url, user, opts)
+		require.NoError(t, err)
+		assert.Equal(t, "permanent", short)
+	})
+
+	t.Run("expiration is rejected", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", LinksRemaining: 5}
+
+		url := domain.NewURL("", "https://example.com/", time.Now().Add(time.Hour))
+		short, err := shortener.Shorten(context.Background(), url, user, opts)
+		require.ErrorIs(t, err, domain.ErrInvalidExpiration)
+		assert.Empty(t, short)
+	})
+
+	t.Run("invalid URL", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", LinksRemaining: 5}
+
+		url := domain.NewURL("", "example.com", time.Time{})
+		short, err := shortener.Shorten(context.Background(), url, user, opts)
+		require.ErrorIs(t, err, domain.ErrInvalidURL)
+		assert.Empty(t, short)
+	})
+
+	repoMock.AssertExpectations(t)
+	cacheMock.AssertExpectations(t)
+	authClientMock.AssertExpectations(t)
+}
+
+func TestShortener_ShortenWithAlias(t *testing.T) {
+	repoMock := new(mocks.ShortenerRepository)
+	cacheMock := new(mocks.ShortenerCache)
+	authClientMock := new(mocks.AuthClient)
+	shortener := service.NewShortener(repoMock, cacheMock, newTestGenerator(t), authClientMock)
+	aliasLimits := domain.PlanLimits{CustomAliases: true}
+
+	t.Run("successful shorten with alias", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", LinksRemaining: 5, Limits: aliasLimits}
+		url := domain.NewURL("q3-report", "http://original.url", time.Time{})
+		repoMock.On("Add", mock.Anything, url).Return(nil).Once()
+		cacheMock.On("Add", mock.Anything, url).Return(nil).Once()
+		authClientMock.On("ConsumeLinkQuota", mock.Anything, user.Username).Return(nil).Once()
+
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "q3-report", short)
+	})
+
+	t.Run("alias with invalid characters", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", LinksRemaining: 5, Limits: aliasLimits}
+
+		url := domain.NewURL("q3 report!", "http://original.url", time.Time{})
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.ErrorIs(t, err, domain.ErrInvalidAlias)
+		assert.Empty(t, short)
+	})
+
+	t.Run("alias is too short", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", LinksRemaining: 5, Limits: aliasLimits}
+
+		url := domain.NewURL("q3", "http://original.url", time.Time{})
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.ErrorIs(t, err, domain.ErrInvalidAlias)
+		assert.Empty(t, short)
+	})
+
+	t.Run("reserved alias", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", LinksRemaining: 5, Limits: aliasLimits}
+
+		url := domain.NewURL("Shorten", "http://original.url", time.Time{})
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.ErrorIs(t, err, domain.ErrInvalidAlias)
+		assert.Empty(t, short)
+		assert.Contains(t, err.Error(), "is reserved")
+	})
+
+	t.Run("alias is already taken", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", LinksRemaining: 5, Limits: aliasLimits}
+		url := domain.NewURL("taken", "http://original.url", time.Time{})
+		repoMock.On("Add", mock.Anything, url).Return(domain.ErrShortURLExists).Once()
+
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.ErrorIs(t, err, domain.ErrShortURLExists)
+		assert.Empty(t, short)
+	})
+
+	t.Run("plan without custom aliases", func(t *testing.T) {
+		user := &domain.Principal{Username: "user", Plan: domain.FREE, LinksRemaining: 5}
+
+		url := domain.NewURL("q3-report", "http://original.url", time.Time{})
+		short, err := shortener.Shorten(context.Background(), url, user, domain.ShortenOptions{})
+		require.ErrorIs(t, err, domain.ErrForbidden)
 		assert.Empty(t, short)
-		assert.Contains(t, err.Error(), "failed to add short URL to repository")
 	})
+
+	repoMock.AssertExpectations(t)
+	cacheMock.AssertExpectations(t)
+	authClientMock.AssertExpectations(t)
 }
 
 func TestShortener_Remove(t *testing.T) {
 	repoMock := new(mocks.ShortenerRepository)
 	cacheMock := new(mocks.ShortenerCache)
 	authClientMock := new(mocks.AuthClient)
-	shortener := service.NewShortener(repoMock, cacheMock, 8, authClientMock)
+	shortener := service.NewShortener(repoMock, cacheMock, newTestGenerator(t), authClientMock)
+	owner := &domain.Principal{Username: "owner", Role: domain.USER, LinksRemaining: 4}
+	url := &domain.URL{Short: "shortUrl", Original: "http://original.url", Owner: "owner"}
 
-	t.Run("successful remove", func(t *testing.T) {
+	t.Run("successful remove by owner", func(t *testing.T) {
+		repoMock.On("Get", mock.Anything, "shortUrl").Return(url, nil).Once()
 		repoMock.On("Remove", mock.Anything, "shortUrl").Return(nil).Once()
 		cacheMock.On("Remove", mock.Anything, "shortUrl").Return(nil).Once()
+		authClientMock.On("RefundLinkQuota", mock.Anything, "owner").Return(nil).Once()
 
-		err := shortener.Remove(context.Background(), "shortUrl")
+		err := shortener.Remove(context.Background(), "shortUrl", owner)
 		require.NoError(t, err)
 		repoMock.AssertCalled(t, "Remove", mock.Anything, "shortUrl")
 		cacheMock.AssertCalled(t, "Remove", mock.Anything, "shortUrl")
+		authClientMock.AssertCalled(t, "RefundLinkQuota", mock.Anything, "owner")
+	})
+
+	t.Run("successful remove by admin", func(t *testing.T) {
+		admin := &domain.Principal{Username: "admin", Role: domain.ADMIN, LinksRemaining: 100}
+		repoMock.On("Get", mock.Anything, "shortUrl").Return(url, nil).Once()
+		repoMock.On("Remove", mock.Anything, "shortUrl").Return(nil).Once()
+		cacheMock.On("Remove", mock.Anything, "shortUrl").Return(nil).Once()
+		authClientMock.On("RefundLinkQuota", mock.Anything, "owner").Return(nil).Once()
+
+		err := shortener.Remove(context.Background(), "shortUrl", admin)
+		require.NoError(t, err)
+		authClientMock.AssertCalled(t, "RefundLinkQuota", mock.Anything, "owner")
+		authClientMock.AssertNotCalled(t, "RefundLinkQuota", mock.Anything, "admin")
+	})
+
+	t.Run("remove by another user", func(t *testing.T) {
+		stranger := &domain.Principal{Username: "stranger", Role: domain.USER}
+		repoMock.On("Get", mock.Anything, "shortUrl").Return(url, nil).Once()
+
+		err := shortener.Remove(context.Background(), "shortUrl", stranger)
+		require.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("short URL not found", func(t *testing.T) {
+		repoMock.On("Get", mock.Anything, "missing").Return(nil, nil).Once()
+
+		err := shortener.Remove(context.Background(), "missing", owner)
+		require.ErrorIs(t, err, domain.ErrShortURLNotFound)
 	})
 
 	t.Run("failed to remove from repository", func(t *testing.T) {
+		repoMock.On("Get", mock.Anything, "shortUrl").Return(url, nil).Once()
 		repoMock.On("Remove", mock.Anything, "shortUrl").Return(errors.New("repo error")).Once()
 
-		err := shortener.Remove(context.Background(), "shortUrl")
+		err := shortener.Remove(context.Background(), "shortUrl", owner)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to remove short URL from repository")
 	})
 
 	t.Run("failed to remove from cache", func(t *testing.T) {
+		refunds := len(authClientMock.Calls)
+		repoMock.On("Get", mock.Anything, "shortUrl").Return(url, nil).Once()
 		repoMock.On("Remove", mock.Anything, "shortUrl").Return(nil).Once()
 		cacheMock.On("Remove", mock.Anything, "shortUrl").Return(errors.New("cache error")).Once()
 
-		err := shortener.Remove(context.Background(), "shortUrl")
+		err := shortener.Remove(context.Background(), "shortUrl", owner)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to remove short URL from cache")
+		assert.Len(t, authClientMock.Calls, refunds, "The quota should not be refunded")
+	})
+
+	t.Run("failed to refund link quota", func(t *testing.T) {
+		repoMock.On("Get", mock.Anything, "shortUrl").Return(url, nil).Once()
+		repoMock.On("Remove", mock.Anything, "shortUrl").Return(nil).Once()
+		cacheMock.On("Remove", mock.Anything, "shortUrl").Return(nil).Once()
+		authClientMock.On("RefundLinkQuota", mock.Anything, "owner").Return(errors.New("auth error")).Once()
+
+		err := shortener.Remove(context.Background(), "shortUrl", owner)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to refund link quota")
+	})
+
+	repoMock.AssertExpectations(t)
+	cacheMock.AssertExpectations(t)
+	authClientMock.AssertExpectations(t)
+}
+
+func TestShortener_RemoveExpired(t *testing.T) {
+	repoMock := new(mocks.ShortenerRepository)
+	cacheMock := new(mocks.ShortenerCache)
+	authClientMock := new(mocks.AuthClient)
+	shortener := service.NewShortener(repoMock, cacheMock, newTestGenerator(t), authClientMock)
+
+	t.Run("successful remove expired", func(t *testing.T) {
+		repoMock.On("RemoveExpired", mock.Anything, mock.Anything).Return(int64(3), nil).Once()
+
+		removed, err := shortener.RemoveExpired(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), removed)
+	})
+
+	t.Run("failed to remove expired", func(t *testing.T) {
+		repoMock.On("RemoveExpired", mock.Anything, mock.Anything).Return(int64(0), errors.New("repo error")).Once()
+
+		_, err := shortener.RemoveExpired(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to remove expired URLs from repository")
+	})
+}
+
+func TestShortener_List(t *testing.T) {
+	repoMock := new(mocks.ShortenerRepository)
+	cacheMock := new(mocks.ShortenerCache)
+	authClientMock := new(mocks.AuthClient)
+	shortener := service.NewShortener(repoMock, cacheMock, newTestGenerator(t), authClientMock)
+
+	t.Run("default limit", func(t *testing.T) {
+		page := &domain.LinksPage{Links: []*domain.URL{{Short: "shortUrl"}}, NextCursor: "next"}
+		repoMock.On("ListByOwner", mock.Anything, domain.LinksQuery{
+			Owner: "user",
+			Limit: 20,
+		}).Return(page, nil).Once()
+
+		got, err := shortener.List(context.Background(), domain.LinksQuery{Owner: "user"})
+		require.NoError(t, err)
+		assert.Equal(t, page, got)
+	})
+
+	t.Run("limit is clamped", func(t *testing.T) {
+		repoMock.On("ListByOwner", mock.Anything, domain.LinksQuery{
+			Owner: "user",
+			Limit: 100,
+		}).Return(&domain.LinksPage{}, nil).Once()
+
+		_, err := shortener.List(context.Background(), domain.LinksQuery{Owner: "user", Limit: 1000})
+		require.NoError(t, err)
+	})
+
+	t.Run("failed to list", func(t *testing.T) {
+		repoMock.On("ListByOwner", mock.Anything, mock.Anything).Return(nil, domain.ErrInvalidCursor).Once()
+
+		_, err := shortener.List(context.Background(), domain.LinksQuery{Owner: "user", Cursor: "bad"})
+		require.ErrorIs(t, err, domain.ErrInvalidCursor)
 	})
+
+	repoMock.AssertExpectations(t)
 }
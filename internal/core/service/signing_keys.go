@@ -0,0 +1,146 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"min/internal/core/domain"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey is a key used to sign and verify tokens. It is identified by the kid token header.
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	// signKey is a []byte secret for HMAC, *rsa.PrivateKey for RSA or ed25519.PrivateKey for EdDSA.
+	signKey any
+	// verifyKey is the same secret for HMAC or the matching public key otherwise.
+	verifyKey any
+}
+
+// NewSigningKey creates a signing key with the given id for the algorithm (HS256, RS256 or EdDSA).
+// The material is a raw secret for HS256 and a PEM encoded private key for RS256 and EdDSA.
+func NewSigningKey(id, algorithm string, material []byte) (*SigningKey, error) {
+	if id == "" {
+		return nil, errors.New("signing key id is required")
+	}
+
+	if len(material) == 0 {
+		return nil, fmt.Errorf("signing key %s has no key material", id)
+	}
+
+	switch algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		return &SigningKey{ID: id, Method: jwt.SigningMethodHS256, signKey: material, verifyKey: material}, nil
+	case jwt.SigningMethodRS256.Alg():
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(material)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RSA private key %s: %w", id, err)
+		}
+
+		return &SigningKey{ID: id, Method: jwt.SigningMethodRS256, signKey: privateKey, verifyKey: &privateKey.PublicKey}, nil
+	case jwt.SigningMethodEdDSA.Alg():
+		privateKey, err := jwt.ParseEdPrivateKeyFromPEM(material)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Ed25519 private key %s: %w", id, err)
+		}
+
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("Ed25519 private key %s can't be used for signing", id)
+		}
+
+		return &SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, signKey: privateKey, verifyKey: signer.Public()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q for key %s", algorithm, id)
+	}
+}
+
+// KeySet holds the keys accepted for token verification. New tokens are signed with the active key,
+// while tokens signed with the other keys remain valid, so the keys can be rotated without logging users out.
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// NewKeySet creates a key set with the given keys, the key with the activeID is used for signing.
+func NewKeySet(activeID string, keys ...*SigningKey) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*SigningKey, len(keys))}
+	for _, key := range keys {
+		if _, ok := ks.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate signing key id %s", key.ID)
+		}
+
+		ks.keys[key.ID] = key
+	}
+
+	ks.active = ks.keys[activeID]
+	if ks.active == nil {
+		return nil, fmt.Errorf("active signing key %s is not configured", activeID)
+	}
+
+	return ks, nil
+}
+
+// Sign signs the claims with the active key and sets the kid header of the token.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.Method, claims)
+	token.Header["kid"] = ks.active.ID
+
+	return token.SignedString(ks.active.signKey)
+}
+
+// Keyfunc returns the key to verify the token with. Tokens without the kid header are verified with the
+// active key, as they were issued before the keys became rotatable.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	key := ks.active
+	if kid, ok := token.Header["kid"]; ok {
+		id, _ := kid.(string)
+		key = ks.keys[id]
+		if key == nil {
+			return nil, fmt.Errorf("unknown signing key %v", kid)
+		}
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.verifyKey, nil
+}
+
+// JWKS returns the public keys of the set. Symmetric keys are secret and are never published.
+func (ks *KeySet) JWKS() domain.JSONWebKeySet {
+	ids := make([]string, 0, len(ks.keys))
+	for id := range ks.keys {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	set := domain.JSONWebKeySet{Keys: make([]domain.JSONWebKey, 0, len(ids))}
+	for _, id := range ids {
+		key := ks.keys[id]
+		jwk := domain.JSONWebKey{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+		switch publicKey := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPEMKey encodes the private key in PKCS #8 PEM format.
+func newPEMKey(t *testing.T, privateKey any) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"username": "valid_user", "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func TestNewSigningKey(t *testing.T) {
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, err := NewSigningKey("key", "HS512", []byte("secret"))
+		require.Error(t, err)
+	})
+
+	t.Run("missing material", func(t *testing.T) {
+		_, err := NewSigningKey("key", "HS256", nil)
+		require.Error(t, err)
+	})
+
+	t.Run("malformed private key", func(t *testing.T) {
+		_, err := NewSigningKey("key", "RS256", []byte("not a pem"))
+		require.Error(t, err)
+	})
+}
+
+func TestNewKeySet(t *testing.T) {
+	key, err := NewSigningKey("key", "HS256", []byte("secret"))
+	require.NoError(t, err)
+
+	_, err = NewKeySet("missing", key)
+	require.Error(t, err)
+
+	_, err = NewKeySet("key", key, key)
+	require.Error(t, err)
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey, err := NewSigningKey("old", "HS256", []byte("old-secret"))
+	require.NoError(t, err)
+	newKey, err := NewSigningKey("new", "HS256", []byte("new-secret"))
+	require.NoError(t, err)
+
+	oldKeys, err := NewKeySet("old", oldKey)
+	require.NoError(t, err)
+	rotatedKeys, err := NewKeySet("new", oldKey, newKey)
+	require.NoError(t, err)
+
+	oldToken, err := oldKeys.Sign(testClaims())
+	require.NoError(t, err)
+	newToken, err := rotatedKeys.Sign(testClaims())
+	require.NoError(t, err)
+
+	t.Run("token signed with the previous key stays valid", func(t *testing.T) {
+		token, err := jwt.Parse(oldToken, rotatedKeys.Keyfunc)
+		require.NoError(t, err)
+		assert.Equal(t, "old", token.Header["kid"])
+	})
+
+	t.Run("new tokens are signed with the active key", func(t *testing.T) {
+		token, err := jwt.Parse(newToken, rotatedKeys.Keyfunc)
+		require.NoError(t, err)
+		assert.Equal(t, "new", token.Header["kid"])
+	})
+
+	t.Run("unknown key is rejected", func(t *testing.T) {
+		_, err := jwt.Parse(newToken, oldKeys.Keyfunc)
+		require.Error(t, err)
+	})
+
+	t.Run("token without kid is verified with the active key", func(t *testing.T) {
+		tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims()).SignedString([]byte("new-secret"))
+		require.NoError(t, err)
+
+		_, err = jwt.Parse(tokenString, rotatedKeys.Keyfunc)
+		require.NoError(t, err)
+	})
+}
+
+func TestKeySet_Asymmetric(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	rsaSigningKey, err := NewSigningKey("rsa", "RS256", newPEMKey(t, rsaKey))
+	require.NoError(t, err)
+	edSigningKey, err := NewSigningKey("ed", "EdDSA", newPEMKey(t, edKey))
+	require.NoError(t, err)
+	hmacSigningKey, err := NewSigningKey("hmac", "HS256", []byte("secret"))
+	require.NoError(t, err)
+
+	for _, activeID := range []string{"rsa", "ed"} {
+		t.Run(activeID+" signed token is verified", func(t *testing.T) {
+			keys, err := NewKeySet(activeID, rsaSigningKey, edSigningKey, hmacSigningKey)
+			require.NoError(t, err)
+
+			tokenString, err := keys.Sign(testClaims())
+			require.NoError(t, err)
+
+			_, err = jwt.Parse(tokenString, keys.Keyfunc)
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("algorithm of the key can't be substituted", func(t *testing.T) {
+		keys, err := NewKeySet("rsa", rsaSigningKey)
+		require.NoError(t, err)
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+		token.Header["kid"] = "rsa"
+		tokenString, err := token.SignedString(x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey))
+		require.NoError(t, err)
+
+		_, err = jwt.Parse(tokenString, keys.Keyfunc)
+		require.Error(t, err)
+	})
+
+	t.Run("JWKS publishes only public keys", func(t *testing.T) {
+		keys, err := NewKeySet("hmac", rsaSigningKey, edSigningKey, hmacSigningKey)
+		require.NoError(t, err)
+
+		jwks := keys.JWKS()
+		require.Len(t, jwks.Keys, 2)
+		assert.Equal(t, "ed", jwks.Keys[0].KeyID)
+		assert.Equal(t, "OKP", jwks.Keys[0].KeyType)
+		assert.Equal(t, "Ed25519", jwks.Keys[0].Curve)
+		assert.NotEmpty(t, jwks.Keys[0].X)
+		assert.Equal(t, "rsa", jwks.Keys[1].KeyID)
+		assert.Equal(t, "RSA", jwks.Keys[1].KeyType)
+		assert.Equal(t, "RS256", jwks.Keys[1].Algorithm)
+		assert.Equal(t, "AQAB", jwks.Keys[1].E)
+	})
+}
@@ -6,25 +6,209 @@ import (
 	log "github.com/sirupsen/logrus"
 	"min/internal/core/domain"
 	"min/internal/core/port"
+	"slices"
+	"time"
+)
+
+const (
+	// defaultStatisticsPeriod is the period covered by the statistics if the time range is not specified.
+	defaultStatisticsPeriod = 30 * 24 * time.Hour
+	// defaultTopLimit is the number of top values returned if the limit is not specified.
+	defaultTopLimit = 10
+	// maxTopLimit is the maximal number of top values returned.
+	maxTopLimit = 100
 )
 
 type StatisticsService struct {
-	repo port.StatisticsRepository
+	repo     port.StatisticsRepository
+	enricher port.EventEnricher
+	plans    port.PlanLimitsProvider
 }
 
-func NewStatisticsService(repo port.StatisticsRepository) *StatisticsService {
-	return &StatisticsService{repo: repo}
+func NewStatisticsService(
+	repo port.StatisticsRepository,
+	enricher port.EventEnricher,
+	plans port.PlanLimitsProvider,
+) *StatisticsService {
+	return &StatisticsService{repo: repo, enricher: enricher, plans: plans}
 }
 
-func (s *StatisticsService) AddEvent(ctx context.Context, event domain.Event) error {
-	log.Infof("Adding event: %v", event)
+// AddEvents enriches the batch of events in place and stores it.
+func (s *StatisticsService) AddEvents(ctx context.Context, events []domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	for i := range events {
+		s.enricher.Enrich(&events[i])
+	}
 
-	err := s.repo.AddEvent(ctx, event)
+	log.Infof("Adding %d events", len(events))
+	err := s.repo.AddEvents(ctx, events)
 	if err != nil {
-		log.Errorf("Failed to add event: %v", err)
-		return fmt.Errorf("failed to add event: %w", err)
+		log.Errorf("Failed to add events: %v", err)
+		return fmt.Errorf("failed to add events: %w", err)
 	}
 
-	log.Infof("Successfully added event: %v", event)
+	log.Infof("Successfully added %d events", len(events))
 	return nil
 }
+
+// GetSummary returns the total number of clicks and unique visitors for the short URL.
+func (s *StatisticsService) GetSummary(
+	ctx context.Context,
+	query domain.StatisticsQuery,
+) (*domain.ClickSummary, error) {
+	query, err := s.prepareQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.repo.GetSummary(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get summary: %w", err)
+	}
+
+	return summary, nil
+}
+
+// GetTimeSeries returns the number of clicks on the short URL bucketed by hour or day.
+func (s *StatisticsService) GetTimeSeries(
+	ctx context.Context,
+	query domain.StatisticsQuery,
+) ([]domain.ClickBucket, error) {
+	query, err := s.prepareQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets, err := s.repo.GetTimeSeries(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time series: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// GetTopReferrers returns the referrers with the most clicks on the short URL.
+func (s *StatisticsService) GetTopReferrers(
+	ctx context.Context,
+	query domain.StatisticsQuery,
+) ([]domain.TopValue, error) {
+	query, err := s.prepareQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	referrers, err := s.repo.GetTopReferrers(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top referrers: %w", err)
+	}
+
+	return referrers, nil
+}
+
+// GetTopUserAgents returns the user agents with the most clicks on the short URL.
+func (s *StatisticsService) GetTopUserAgents(
+	ctx context.Context,
+	query domain.StatisticsQuery,
+) ([]domain.TopValue, error) {
+	query, err := s.prepareQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	userAgents, err := s.repo.GetTopUserAgents(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top user agents: %w", err)
+	}
+
+	return userAgents, nil
+}
+
+// prepareQuery validates the statistics query and checks that the short URL has events of query.Owner if it is
+// set. The owners are taken from the events, so the statistics of the removed and expired links stay available.
+// The range doesn't start earlier than the analytics retention of the owner's plan allows.
+func (s *StatisticsService) prepareQuery(
+	ctx context.Context,
+	query domain.StatisticsQuery,
+) (domain.StatisticsQuery, error) {
+	query, err := normalizeQuery(query)
+	if err != nil {
+		return query, err
+	}
+
+	owners, err := s.repo.GetOwners(ctx, query.ShortURL)
+	if err != nil {
+		return query, fmt.Errorf("failed to get owners of short URL: %w", err)
+	}
+
+	if len(owners) == 0 {
+		return query, domain.ErrShortURLNotFound
+	}
+
+	// A short URL may have been removed and created again by another user, the owner of its latest events is
+	// the current one. The events of the other owners are filtered out by the repository.
+	owner := owners[0]
+	if query.Owner != "" {
+		if !slices.Contains(owners, query.Owner) {
+			return query, fmt.Errorf("%w: only the owner or an admin can see the statistics", domain.ErrForbidden)
+		}
+		owner = query.Owner
+	}
+
+	limits, err := s.plans.GetPlanLimits(ctx, owner)
+	if err != nil {
+		return query, fmt.Errorf("failed to get plan limits of the owner: %w", err)
+	}
+
+	if limits.AnalyticsRetention > 0 {
+		// The range ends before the retention starts if it is entirely older, so nothing is found.
+		query.From = laterOf(query.From, time.Now().Add(-limits.AnalyticsRetention))
+	}
+
+	return query, nil
+}
+
+// laterOf returns the later of the two times.
+func laterOf(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+// normalizeQuery validates the statistics query and fills in the default values.
+func normalizeQuery(query domain.StatisticsQuery) (domain.StatisticsQuery, error) {
+	if query.ShortURL == "" {
+		return query, fmt.Errorf("%w: short URL is required", domain.ErrInvalidStatisticsQuery)
+	}
+
+	if query.To.IsZero() {
+		query.To = time.Now()
+	}
+
+	if query.From.IsZero() {
+		query.From = query.To.Add(-defaultStatisticsPeriod)
+	}
+
+	if !query.From.Before(query.To) {
+		return query, fmt.Errorf("%w: from must be before to", domain.ErrInvalidStatisticsQuery)
+	}
+
+	switch query.Interval {
+	case "":
+		query.Interval = domain.DAY
+	case domain.HOUR, domain.DAY:
+	default:
+		return query, fmt.Errorf("%w: interval must be either hour or day", domain.ErrInvalidStatisticsQuery)
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = defaultTopLimit
+	}
+	query.Limit = min(query.Limit, maxTopLimit)
+
+	return query, nil
+}
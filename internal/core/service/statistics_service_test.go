@@ -0,0 +1,258 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"min/internal/core/domain"
+	"min/internal/core/service"
+	"min/internal/mocks"
+)
+
+// newRepoMock returns the statistics repository with the events of shortUrl owned by user.
+func newRepoMock() *mocks.StatisticsRepository {
+	repoMock := new(mocks.StatisticsRepository)
+	repoMock.On("GetOwners", mock.Anything, "shortUrl").Return([]string{"user"}, nil).Maybe()
+	return repoMock
+}
+
+// newPlansMock returns the plan limits provider with user on the plan with the analytics retention.
+func newPlansMock(retention time.Duration) *mocks.PlanLimitsProvider {
+	plansMock := new(mocks.PlanLimitsProvider)
+	plansMock.On("GetPlanLimits", mock.Anything, "user").Return(&domain.PlanLimits{AnalyticsRetention: retention}, nil)
+	return plansMock
+}
+
+func TestStatisticsService_AddEvents(t *testing.T) {
+	repoMock := new(mocks.StatisticsRepository)
+	enricherMock := new(mocks.EventEnricher)
+	enricherMock.On("Enrich", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*domain.Event).Browser = "Chrome"
+	})
+	statisticsService := service.NewStatisticsService(repoMock, enricherMock, new(mocks.PlanLimitsProvider))
+	events := []domain.Event{{ShortURL: "first"}, {ShortURL: "second"}}
+
+	t.Run("successful add", func(t *testing.T) {
+		enriched := []domain.Event{{ShortURL: "first", Browser: "Chrome"}, {ShortURL: "second", Browser: "Chrome"}}
+		repoMock.On("AddEvents", mock.Anything, enriched).Return(nil).Once()
+
+		require.NoError(t, statisticsService.AddEvents(context.Background(), events))
+	})
+
+	t.Run("empty batch is not stored", func(t *testing.T) {
+		require.NoError(t, statisticsService.AddEvents(context.Background(), nil))
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		repoMock.On("AddEvents", mock.Anything, events).Return(errors.New("clickhouse error")).Once()
+
+		err := statisticsService.AddEvents(context.Background(), events)
+		require.Error(t, err)
+	})
+
+	repoMock.AssertExpectations(t)
+}
+
+func TestStatisticsService_GetSummary(t *testing.T) {
+	repoMock := newRepoMock()
+	statisticsService := service.NewStatisticsService(repoMock, new(mocks.EventEnricher), newPlansMock(0))
+
+	t.Run("defaults are applied", func(t *testing.T) {
+		repoMock.On("GetSummary", mock.Anything, mock.MatchedBy(func(query domain.StatisticsQuery) bool {
+			return query.ShortURL == "shortUrl" &&
+				query.Owner == "user" &&
+				query.To.Sub(query.From) == 30*24*time.Hour &&
+				query.Interval == domain.DAY &&
+				query.Limit == 10
+		})).Return(&domain.ClickSummary{Clicks: 5, UniqueVisitors: 2}, nil).Once()
+
+		summary, err := statisticsService.GetSummary(context.Background(), domain.StatisticsQuery{
+			ShortURL: "shortUrl",
+			Owner:    "user",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &domain.ClickSummary{Clicks: 5, UniqueVisitors: 2}, summary)
+	})
+
+	t.Run("missing short URL", func(t *testing.T) {
+		_, err := statisticsService.GetSummary(context.Background(), domain.StatisticsQuery{})
+		require.ErrorIs(t, err, domain.ErrInvalidStatisticsQuery)
+	})
+
+	t.Run("from after to", func(t *testing.T) {
+		now := time.Now()
+		_, err := statisticsService.GetSummary(context.Background(), domain.StatisticsQuery{
+			ShortURL: "shortUrl",
+			From:     now,
+			To:       now.Add(-time.Hour),
+		})
+		require.ErrorIs(t, err, domain.ErrInvalidStatisticsQuery)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		repoMock.On("GetSummary", mock.Anything, mock.Anything).Return(nil, errors.New("db error")).Once()
+
+		_, err := statisticsService.GetSummary(context.Background(), domain.StatisticsQuery{ShortURL: "shortUrl"})
+		require.Error(t, err)
+	})
+}
+
+func TestStatisticsService_GetTimeSeries(t *testing.T) {
+	repoMock := newRepoMock()
+	statisticsService := service.NewStatisticsService(repoMock, new(mocks.EventEnricher), newPlansMock(0))
+
+	t.Run("hourly buckets", func(t *testing.T) {
+		from := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		buckets := []domain.ClickBucket{{Time: from, Clicks: 3}}
+		repoMock.On("GetTimeSeries", mock.Anything, mock.MatchedBy(func(query domain.StatisticsQuery) bool {
+			return query.Interval == domain.HOUR && query.From.Equal(from)
+		})).Return(buckets, nil).Once()
+
+		result, err := statisticsService.GetTimeSeries(context.Background(), domain.StatisticsQuery{
+			ShortURL: "shortUrl",
+			From:     from,
+			To:       from.Add(time.Hour),
+			Interval: domain.HOUR,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, buckets, result)
+	})
+
+	t.Run("invalid interval", func(t *testing.T) {
+		_, err := statisticsService.GetTimeSeries(context.Background(), domain.StatisticsQuery{
+			ShortURL: "shortUrl",
+			Interval: "week",
+		})
+		require.ErrorIs(t, err, domain.ErrInvalidStatisticsQuery)
+		repoMock.AssertNotCalled(t, "GetTimeSeries", mock.Anything, mock.MatchedBy(func(query domain.StatisticsQuery) bool {
+			return query.Interval == "week"
+		}))
+	})
+}
+
+func TestStatisticsService_GetTop(t *testing.T) {
+	repoMock := newRepoMock()
+	statisticsService := service.NewStatisticsService(repoMock, new(mocks.EventEnricher), newPlansMock(0))
+
+	t.Run("limit is capped", func(t *testing.T) {
+		repoMock.On("GetTopReferrers", mock.Anything, mock.MatchedBy(func(query domain.StatisticsQuery) bool {
+			return query.Limit == 100
+		})).Return([]domain.TopValue{{Value: "https://google.com", Clicks: 1}}, nil).Once()
+
+		values, err := statisticsService.GetTopReferrers(context.Background(), domain.StatisticsQuery{
+			ShortURL: "shortUrl",
+			Limit:    1000,
+		})
+		require.NoError(t, err)
+		assert.Len(t, values, 1)
+	})
+
+	t.Run("top user agents", func(t *testing.T) {
+		repoMock.On("GetTopUserAgents", mock.Anything, mock.Anything).Return([]domain.TopValue{}, nil).Once()
+
+		values, err := statisticsService.GetTopUserAgents(context.Background(), domain.StatisticsQuery{
+			ShortURL: "shortUrl",
+		})
+		require.NoError(t, err)
+		assert.Empty(t, values)
+	})
+}
+
+func TestStatisticsService_Authorization(t *testing.T) {
+	repoMock := newRepoMock()
+	repoMock.On("GetOwners", mock.Anything, "reusedUrl").Return([]string{"another", "user"}, nil)
+	repoMock.On("GetOwners", mock.Anything, "unknownUrl").Return([]string(nil), nil)
+	repoMock.On("GetOwners", mock.Anything, "brokenUrl").Return(nil, errors.New("clickhouse error"))
+	plansMock := newPlansMock(0)
+	plansMock.On("GetPlanLimits", mock.Anything, "another").Return(&domain.PlanLimits{}, nil)
+	statisticsService := service.NewStatisticsService(repoMock, new(mocks.EventEnricher), plansMock)
+
+	t.Run("admin sees any link", func(t *testing.T) {
+		repoMock.On("GetSummary", mock.Anything, mock.Anything).Return(&domain.ClickSummary{Clicks: 1}, nil).Once()
+
+		summary, err := statisticsService.GetSummary(context.Background(), domain.StatisticsQuery{ShortURL: "shortUrl"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), summary.Clicks)
+	})
+
+	t.Run("link of another user", func(t *testing.T) {
+		_, err := statisticsService.GetSummary(context.Background(), domain.StatisticsQuery{
+			ShortURL: "shortUrl",
+			Owner:    "another",
+		})
+		require.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("link created again by another user", func(t *testing.T) {
+		repoMock.On("GetSummary", mock.Anything, mock.MatchedBy(func(query domain.StatisticsQuery) bool {
+			return query.ShortURL == "reusedUrl" && query.Owner == "user"
+		})).Return(&domain.ClickSummary{Clicks: 2}, nil).Once()
+
+		summary, err := statisticsService.GetSummary(context.Background(), domain.StatisticsQuery{
+			ShortURL: "reusedUrl",
+			Owner:    "user",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), summary.Clicks)
+	})
+
+	t.Run("link without events", func(t *testing.T) {
+		_, err := statisticsService.GetTopReferrers(context.Background(), domain.StatisticsQuery{
+			ShortURL: "unknownUrl",
+			Owner:    "user",
+		})
+		require.ErrorIs(t, err, domain.ErrShortURLNotFound)
+	})
+
+	t.Run("lookup error", func(t *testing.T) {
+		_, err := statisticsService.GetTimeSeries(context.Background(), domain.StatisticsQuery{ShortURL: "brokenUrl"})
+		require.Error(t, err)
+		require.NotErrorIs(t, err, domain.ErrShortURLNotFound)
+	})
+
+	repoMock.AssertExpectations(t)
+}
+
+func TestStatisticsService_Retention(t *testing.T) {
+	repoMock := newRepoMock()
+	statisticsService := service.NewStatisticsService(repoMock, new(mocks.EventEnricher), newPlansMock(7*24*time.Hour))
+
+	t.Run("range is clamped to the retention", func(t *testing.T) {
+		earliest := time.Now().Add(-7 * 24 * time.Hour)
+		repoMock.On("GetSummary", mock.Anything, mock.MatchedBy(func(query domain.StatisticsQuery) bool {
+			return !query.From.Before(earliest) && query.From.Before(earliest.Add(time.Minute))
+		})).Return(&domain.ClickSummary{}, nil).Once()
+
+		_, err := statisticsService.GetSummary(context.Background(), domain.StatisticsQuery{ShortURL: "shortUrl"})
+		require.NoError(t, err)
+	})
+
+	t.Run("range within the retention is kept", func(t *testing.T) {
+		from := time.Now().Add(-24 * time.Hour)
+		repoMock.On("GetTimeSeries", mock.Anything, mock.MatchedBy(func(query domain.StatisticsQuery) bool {
+			return query.From.Equal(from)
+		})).Return([]domain.ClickBucket{}, nil).Once()
+
+		_, err := statisticsService.GetTimeSeries(context.Background(), domain.StatisticsQuery{
+			ShortURL: "shortUrl",
+			From:     from,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("plan lookup error", func(t *testing.T) {
+		plansMock := new(mocks.PlanLimitsProvider)
+		plansMock.On("GetPlanLimits", mock.Anything, "user").Return(nil, errors.New("auth error")).Once()
+		statisticsService := service.NewStatisticsService(repoMock, new(mocks.EventEnricher), plansMock)
+
+		_, err := statisticsService.GetTopReferrers(context.Background(), domain.StatisticsQuery{ShortURL: "shortUrl"})
+		require.Error(t, err)
+	})
+
+	repoMock.AssertExpectations(t)
+}
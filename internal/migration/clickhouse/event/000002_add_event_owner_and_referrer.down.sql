@@ -0,0 +1 @@
+ALTER TABLE events DROP COLUMN IF EXISTS owner_username, DROP COLUMN IF EXISTS referrer;
@@ -0,0 +1 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS owner_username String, ADD COLUMN IF NOT EXISTS referrer String;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS events_merge_tree
+(
+    id             UUID DEFAULT generateUUIDv4(),
+    short_url      String,
+    original_url   String,
+    timestamp      DateTime,
+    user_agent     String,
+    ip             String,
+    owner_username String,
+    referrer       String
+) ENGINE = MergeTree()
+      ORDER BY id;
+
+INSERT INTO events_merge_tree (id, short_url, original_url, timestamp, user_agent, ip, owner_username, referrer)
+SELECT id, short_url, original_url, timestamp, user_agent, ip, owner_username, referrer
+FROM events FINAL;
+
+RENAME TABLE events TO events_dedup, events_merge_tree TO events;
+
+DROP TABLE events_dedup;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS events_dedup
+(
+    id             UUID,
+    short_url      String,
+    original_url   String,
+    timestamp      DateTime,
+    user_agent     String,
+    ip             String,
+    owner_username String,
+    referrer       String
+) ENGINE = ReplacingMergeTree()
+      ORDER BY id;
+
+INSERT INTO events_dedup (id, short_url, original_url, timestamp, user_agent, ip, owner_username, referrer)
+SELECT id, short_url, original_url, timestamp, user_agent, ip, owner_username, referrer
+FROM events;
+
+RENAME TABLE events TO events_merge_tree, events_dedup TO events;
+
+DROP TABLE events_merge_tree;
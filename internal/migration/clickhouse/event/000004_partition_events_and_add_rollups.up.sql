@@ -0,0 +1,75 @@
+-- The event ID stays in the sorting key, it is what ReplacingMergeTree deduplicates the events by.
+CREATE TABLE IF NOT EXISTS events_partitioned
+(
+    id             UUID,
+    short_url      String,
+    original_url   String,
+    timestamp      DateTime,
+    user_agent     LowCardinality(String),
+    ip             String,
+    owner_username LowCardinality(String),
+    referrer       String
+) ENGINE = ReplacingMergeTree()
+      PARTITION BY toYYYYMM(timestamp)
+      ORDER BY (short_url, timestamp, id);
+
+INSERT INTO events_partitioned (id, short_url, original_url, timestamp, user_agent, ip, owner_username, referrer)
+SELECT id, short_url, original_url, timestamp, user_agent, ip, owner_username, referrer
+FROM events FINAL;
+
+RENAME TABLE events TO events_unpartitioned, events_partitioned TO events;
+
+DROP TABLE events_unpartitioned;
+
+-- Rollups count the distinct event IDs, so the duplicates of the events are not counted there either.
+-- uniqExact keeps the counts exact, a rollup row holds the IDs and IPs of the events of its hour or day.
+-- The rollups are kept as long as the raw events, the statistics service sets the TTL of both.
+CREATE TABLE IF NOT EXISTS events_hourly
+(
+    short_url      String,
+    owner_username LowCardinality(String),
+    hour           DateTime,
+    clicks         AggregateFunction(uniqExact, UUID),
+    visitors       AggregateFunction(uniqExact, String)
+) ENGINE = AggregatingMergeTree()
+      PARTITION BY toYYYYMM(hour)
+      ORDER BY (short_url, hour, owner_username);
+
+CREATE TABLE IF NOT EXISTS events_daily
+(
+    short_url      String,
+    owner_username LowCardinality(String),
+    day            DateTime,
+    clicks         AggregateFunction(uniqExact, UUID),
+    visitors       AggregateFunction(uniqExact, String)
+) ENGINE = AggregatingMergeTree()
+      PARTITION BY toYYYYMM(day)
+      ORDER BY (short_url, day, owner_username);
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS events_hourly_mv TO events_hourly AS
+SELECT short_url,
+       owner_username,
+       toStartOfHour(timestamp) AS hour,
+       uniqExactState(id)       AS clicks,
+       uniqExactState(ip)       AS visitors
+FROM events
+GROUP BY short_url, owner_username, hour;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS events_daily_mv TO events_daily AS
+SELECT short_url,
+       owner_username,
+       toStartOfDay(timestamp) AS day,
+       uniqExactState(id)      AS clicks,
+       uniqExactState(ip)      AS visitors
+FROM events
+GROUP BY short_url, owner_username, day;
+
+INSERT INTO events_hourly
+SELECT short_url, owner_username, toStartOfHour(timestamp) AS hour, uniqExactState(id), uniqExactState(ip)
+FROM events
+GROUP BY short_url, owner_username, hour;
+
+INSERT INTO events_daily
+SELECT short_url, owner_username, toStartOfDay(timestamp) AS day, uniqExactState(id), uniqExactState(ip)
+FROM events
+GROUP BY short_url, owner_username, day;
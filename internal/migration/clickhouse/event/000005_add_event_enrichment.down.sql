@@ -0,0 +1,8 @@
+ALTER TABLE events
+    DROP COLUMN IF EXISTS language,
+    DROP COLUMN IF EXISTS browser,
+    DROP COLUMN IF EXISTS os,
+    DROP COLUMN IF EXISTS device,
+    DROP COLUMN IF EXISTS bot,
+    DROP COLUMN IF EXISTS country,
+    DROP COLUMN IF EXISTS city;
@@ -0,0 +1,8 @@
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS language LowCardinality(String),
+    ADD COLUMN IF NOT EXISTS browser  LowCardinality(String),
+    ADD COLUMN IF NOT EXISTS os       LowCardinality(String),
+    ADD COLUMN IF NOT EXISTS device   LowCardinality(String),
+    ADD COLUMN IF NOT EXISTS bot      UInt8,
+    ADD COLUMN IF NOT EXISTS country  LowCardinality(String),
+    ADD COLUMN IF NOT EXISTS city     LowCardinality(String);
@@ -0,0 +1,10 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS refresh_tokens
+(
+    token_hash TEXT PRIMARY KEY,
+    username   TEXT      NOT NULL REFERENCES users (username) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_username_idx ON refresh_tokens (username);
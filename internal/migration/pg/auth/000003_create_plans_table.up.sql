@@ -0,0 +1,24 @@
+-- Durations are stored in seconds, zero means unlimited.
+CREATE TABLE IF NOT EXISTS plans
+(
+    name                TEXT PRIMARY KEY,
+    link_quota          BIGINT  NOT NULL,
+    max_link_lifetime   BIGINT  NOT NULL DEFAULT 0,
+    custom_aliases      BOOLEAN NOT NULL DEFAULT FALSE,
+    rate_limit          BIGINT  NOT NULL,
+    rate_burst          BIGINT  NOT NULL,
+    analytics_retention BIGINT  NOT NULL DEFAULT 0,
+    is_default          BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS plans_default_idx ON plans (is_default) WHERE is_default;
+
+INSERT INTO plans (name, link_quota, max_link_lifetime, custom_aliases, rate_limit, rate_burst,
+                   analytics_retention, is_default)
+VALUES ('free', 100, 0, FALSE, 10, 100, 0, TRUE),
+       ('premium', 1000, 0, TRUE, 50, 500, 0, FALSE)
+ON CONFLICT (name) DO NOTHING;
+
+UPDATE users SET plan_name = 'free' WHERE plan_name NOT IN (SELECT name FROM plans);
+
+ALTER TABLE users ADD CONSTRAINT users_plan_name_fkey FOREIGN KEY (plan_name) REFERENCES plans (name);
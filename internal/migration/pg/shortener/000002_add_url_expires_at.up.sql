@@ -0,0 +1,3 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS url_expires_at_idx ON url (expires_at) WHERE expires_at IS NOT NULL;
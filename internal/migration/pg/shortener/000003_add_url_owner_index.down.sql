@@ -0,0 +1 @@
+DROP INDEX IF EXISTS url_owner_username_created_at_idx;
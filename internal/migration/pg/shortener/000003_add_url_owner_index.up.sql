@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS url_owner_username_created_at_idx ON url (owner_username, created_at, id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS url_owner_normalized_idx;
+
+ALTER TABLE url DROP COLUMN IF EXISTS normalized_url;
@@ -0,0 +1,4 @@
+-- Links created before the column was added have no normalized URL and are never deduplicated.
+ALTER TABLE url ADD COLUMN IF NOT EXISTS normalized_url TEXT;
+
+CREATE INDEX IF NOT EXISTS url_owner_normalized_idx ON url (owner_username, normalized_url);
@@ -0,0 +1,7 @@
+DROP SEQUENCE IF EXISTS short_code_seq;
+
+DROP INDEX IF EXISTS url_short_url_key;
+
+INSERT INTO url SELECT * FROM url_short_url_conflicts;
+
+DROP TABLE IF EXISTS url_short_url_conflicts;
@@ -0,0 +1,17 @@
+-- Short URLs were never checked for collisions, so a short URL could belong to several links. The oldest link
+-- keeps it and the published short URLs never change. The others are moved to url_short_url_conflicts, where
+-- the operator recreates them under new short URLs or credits their owners back (see the README).
+CREATE TABLE IF NOT EXISTS url_short_url_conflicts (LIKE url);
+
+WITH moved AS (
+    DELETE FROM url
+    USING url AS older
+    WHERE older.short_url = url.short_url AND older.id < url.id
+    RETURNING url.*
+)
+INSERT INTO url_short_url_conflicts SELECT * FROM moved;
+
+CREATE UNIQUE INDEX IF NOT EXISTS url_short_url_key ON url (short_url);
+
+-- Source of the numbers encoded by the counter and hashids short code generators.
+CREATE SEQUENCE IF NOT EXISTS short_code_seq;
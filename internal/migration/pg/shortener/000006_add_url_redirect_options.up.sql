@@ -0,0 +1,3 @@
+-- Zero redirect code means that the link redirects with the default code of the shortener.
+ALTER TABLE url ADD COLUMN IF NOT EXISTS redirect_code SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS forward_query BOOLEAN NOT NULL DEFAULT FALSE;
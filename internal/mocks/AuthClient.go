@@ -32,23 +32,157 @@ func (_m *AuthClient) ChangeLinksRemaining(ctx context.Context, username string,
 	return r0
 }
 
+// ChangePassword provides a mock function with given fields: ctx, token, oldPassword, newPassword
+func (_m *AuthClient) ChangePassword(ctx context.Context, token string, oldPassword string, newPassword string) error {
+	ret := _m.Called(ctx, token, oldPassword, newPassword)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, token, oldPassword, newPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ChangePlan provides a mock function with given fields: ctx, username, plan
+func (_m *AuthClient) ChangePlan(ctx context.Context, username string, plan domain.PlanName) error {
+	ret := _m.Called(ctx, username, plan)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePlan")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.PlanName) error); ok {
+		r0 = rf(ctx, username, plan)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConsumeLinkQuota provides a mock function with given fields: ctx, username
+func (_m *AuthClient) ConsumeLinkQuota(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeLinkQuota")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreatePlan provides a mock function with given fields: ctx, plan
+func (_m *AuthClient) CreatePlan(ctx context.Context, plan *domain.Plan) error {
+	ret := _m.Called(ctx, plan)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePlan")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Plan) error); ok {
+		r0 = rf(ctx, plan)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetPlanLimits provides a mock function with given fields: ctx, username
+func (_m *AuthClient) GetPlanLimits(ctx context.Context, username string) (*domain.PlanLimits, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPlanLimits")
+	}
+
+	var r0 *domain.PlanLimits
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.PlanLimits, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.PlanLimits); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.PlanLimits)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPlans provides a mock function with given fields: ctx
+func (_m *AuthClient) ListPlans(ctx context.Context) ([]*domain.Plan, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListPlans")
+	}
+
+	var r0 []*domain.Plan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*domain.Plan, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*domain.Plan); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Plan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Login provides a mock function with given fields: ctx, username, password
-func (_m *AuthClient) Login(ctx context.Context, username string, password string) (string, error) {
+func (_m *AuthClient) Login(ctx context.Context, username string, password string) (*domain.Tokens, error) {
 	ret := _m.Called(ctx, username, password)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Login")
 	}
 
-	var r0 string
+	var r0 *domain.Tokens
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*domain.Tokens, error)); ok {
 		return rf(ctx, username, password)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.Tokens); ok {
 		r0 = rf(ctx, username, password)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Tokens)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
@@ -60,6 +194,72 @@ func (_m *AuthClient) Login(ctx context.Context, username string, password strin
 	return r0, r1
 }
 
+// Logout provides a mock function with given fields: ctx, token
+func (_m *AuthClient) Logout(ctx context.Context, token string) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Logout")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Refresh provides a mock function with given fields: ctx, refreshToken
+func (_m *AuthClient) Refresh(ctx context.Context, refreshToken string) (*domain.Tokens, error) {
+	ret := _m.Called(ctx, refreshToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Refresh")
+	}
+
+	var r0 *domain.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Tokens, error)); ok {
+		return rf(ctx, refreshToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Tokens); ok {
+		r0 = rf(ctx, refreshToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Tokens)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, refreshToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefundLinkQuota provides a mock function with given fields: ctx, username
+func (_m *AuthClient) RefundLinkQuota(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefundLinkQuota")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Register provides a mock function with given fields: ctx, username, password, role
 func (_m *AuthClient) Register(ctx context.Context, username string, password string, role domain.Role) error {
 	ret := _m.Called(ctx, username, password, role)
@@ -79,23 +279,23 @@ func (_m *AuthClient) Register(ctx context.Context, username string, password st
 }
 
 // ValidateToken provides a mock function with given fields: ctx, token
-func (_m *AuthClient) ValidateToken(ctx context.Context, token string) (*domain.User, error) {
+func (_m *AuthClient) ValidateToken(ctx context.Context, token string) (*domain.Principal, error) {
 	ret := _m.Called(ctx, token)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ValidateToken")
 	}
 
-	var r0 *domain.User
+	var r0 *domain.Principal
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Principal, error)); ok {
 		return rf(ctx, token)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Principal); ok {
 		r0 = rf(ctx, token)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*domain.User)
+			r0 = ret.Get(0).(*domain.Principal)
 		}
 	}
 
@@ -32,23 +32,157 @@ func (_m *AuthService) ChangeLinksRemaining(ctx context.Context, username string
 	return r0
 }
 
+// ChangePassword provides a mock function with given fields: ctx, tokenString, oldPassword, newPassword
+func (_m *AuthService) ChangePassword(ctx context.Context, tokenString string, oldPassword string, newPassword string) error {
+	ret := _m.Called(ctx, tokenString, oldPassword, newPassword)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, tokenString, oldPassword, newPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ChangePlan provides a mock function with given fields: ctx, username, plan
+func (_m *AuthService) ChangePlan(ctx context.Context, username string, plan domain.PlanName) error {
+	ret := _m.Called(ctx, username, plan)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePlan")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.PlanName) error); ok {
+		r0 = rf(ctx, username, plan)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConsumeLinkQuota provides a mock function with given fields: ctx, username
+func (_m *AuthService) ConsumeLinkQuota(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeLinkQuota")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreatePlan provides a mock function with given fields: ctx, plan
+func (_m *AuthService) CreatePlan(ctx context.Context, plan *domain.Plan) error {
+	ret := _m.Called(ctx, plan)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePlan")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Plan) error); ok {
+		r0 = rf(ctx, plan)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetPlanLimits provides a mock function with given fields: ctx, username
+func (_m *AuthService) GetPlanLimits(ctx context.Context, username string) (*domain.PlanLimits, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPlanLimits")
+	}
+
+	var r0 *domain.PlanLimits
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.PlanLimits, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.PlanLimits); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.PlanLimits)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPlans provides a mock function with given fields: ctx
+func (_m *AuthService) ListPlans(ctx context.Context) ([]*domain.Plan, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListPlans")
+	}
+
+	var r0 []*domain.Plan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*domain.Plan, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*domain.Plan); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Plan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Login provides a mock function with given fields: ctx, username, password
-func (_m *AuthService) Login(ctx context.Context, username string, password string) (string, error) {
+func (_m *AuthService) Login(ctx context.Context, username string, password string) (*domain.Tokens, error) {
 	ret := _m.Called(ctx, username, password)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Login")
 	}
 
-	var r0 string
+	var r0 *domain.Tokens
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*domain.Tokens, error)); ok {
 		return rf(ctx, username, password)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.Tokens); ok {
 		r0 = rf(ctx, username, password)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Tokens)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
@@ -60,6 +194,72 @@ func (_m *AuthService) Login(ctx context.Context, username string, password stri
 	return r0, r1
 }
 
+// Logout provides a mock function with given fields: ctx, tokenString
+func (_m *AuthService) Logout(ctx context.Context, tokenString string) error {
+	ret := _m.Called(ctx, tokenString)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Logout")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, tokenString)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Refresh provides a mock function with given fields: ctx, refreshToken
+func (_m *AuthService) Refresh(ctx context.Context, refreshToken string) (*domain.Tokens, error) {
+	ret := _m.Called(ctx, refreshToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Refresh")
+	}
+
+	var r0 *domain.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Tokens, error)); ok {
+		return rf(ctx, refreshToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Tokens); ok {
+		r0 = rf(ctx, refreshToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Tokens)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, refreshToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefundLinkQuota provides a mock function with given fields: ctx, username
+func (_m *AuthService) RefundLinkQuota(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefundLinkQuota")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Register provides a mock function with given fields: ctx, newUser
 func (_m *AuthService) Register(ctx context.Context, newUser *domain.User) error {
 	ret := _m.Called(ctx, newUser)
@@ -79,23 +279,23 @@ func (_m *AuthService) Register(ctx context.Context, newUser *domain.User) error
 }
 
 // ValidateToken provides a mock function with given fields: ctx, tokenString
-func (_m *AuthService) ValidateToken(ctx context.Context, tokenString string) (*domain.User, error) {
+func (_m *AuthService) ValidateToken(ctx context.Context, tokenString string) (*domain.Principal, error) {
 	ret := _m.Called(ctx, tokenString)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ValidateToken")
 	}
 
-	var r0 *domain.User
+	var r0 *domain.Principal
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Principal, error)); ok {
 		return rf(ctx, tokenString)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Principal); ok {
 		r0 = rf(ctx, tokenString)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*domain.User)
+			r0 = ret.Get(0).(*domain.Principal)
 		}
 	}
 
@@ -4,6 +4,7 @@ package mocks
 
 import (
 	context "context"
+	domain "min/internal/core/domain"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -13,17 +14,17 @@ type ShortenerCache struct {
 	mock.Mock
 }
 
-// Add provides a mock function with given fields: ctx, short, original
-func (_m *ShortenerCache) Add(ctx context.Context, short string, original string) error {
-	ret := _m.Called(ctx, short, original)
+// Add provides a mock function with given fields: ctx, url
+func (_m *ShortenerCache) Add(ctx context.Context, url *domain.URL) error {
+	ret := _m.Called(ctx, url)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Add")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = rf(ctx, short, original)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.URL) error); ok {
+		r0 = rf(ctx, url)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -31,23 +32,25 @@ func (_m *ShortenerCache) Add(ctx context.Context, short string, original string
 	return r0
 }
 
-// GetOriginal provides a mock function with given fields: ctx, short
-func (_m *ShortenerCache) GetOriginal(ctx context.Context, short string) (string, error) {
+// Get provides a mock function with given fields: ctx, short
+func (_m *ShortenerCache) Get(ctx context.Context, short string) (*domain.URL, error) {
 	ret := _m.Called(ctx, short)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetOriginal")
+		panic("no return value specified for Get")
 	}
 
-	var r0 string
+	var r0 *domain.URL
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.URL, error)); ok {
 		return rf(ctx, short)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.URL); ok {
 		r0 = rf(ctx, short)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.URL)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
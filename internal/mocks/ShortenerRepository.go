@@ -4,6 +4,8 @@ package mocks
 
 import (
 	context "context"
+	domain "min/internal/core/domain"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -13,17 +15,17 @@ type ShortenerRepository struct {
 	mock.Mock
 }
 
-// Add provides a mock function with given fields: ctx, short, original
-func (_m *ShortenerRepository) Add(ctx context.Context, short string, original string) error {
-	ret := _m.Called(ctx, short, original)
+// Add provides a mock function with given fields: ctx, url
+func (_m *ShortenerRepository) Add(ctx context.Context, url *domain.URL) error {
+	ret := _m.Called(ctx, url)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Add")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = rf(ctx, short, original)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.URL) error); ok {
+		r0 = rf(ctx, url)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -31,23 +33,55 @@ func (_m *ShortenerRepository) Add(ctx context.Context, short string, original s
 	return r0
 }
 
-// GetOriginal provides a mock function with given fields: ctx, short
-func (_m *ShortenerRepository) GetOriginal(ctx context.Context, short string) (string, error) {
+// FindByNormalized provides a mock function with given fields: ctx, url, now
+func (_m *ShortenerRepository) FindByNormalized(ctx context.Context, url *domain.URL, now time.Time) (*domain.URL, error) {
+	ret := _m.Called(ctx, url, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindByNormalized")
+	}
+
+	var r0 *domain.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.URL, time.Time) (*domain.URL, error)); ok {
+		return rf(ctx, url, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.URL, time.Time) *domain.URL); ok {
+		r0 = rf(ctx, url, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.URL)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.URL, time.Time) error); ok {
+		r1 = rf(ctx, url, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Get provides a mock function with given fields: ctx, short
+func (_m *ShortenerRepository) Get(ctx context.Context, short string) (*domain.URL, error) {
 	ret := _m.Called(ctx, short)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetOriginal")
+		panic("no return value specified for Get")
 	}
 
-	var r0 string
+	var r0 *domain.URL
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.URL, error)); ok {
 		return rf(ctx, short)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.URL); ok {
 		r0 = rf(ctx, short)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.URL)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
@@ -59,6 +93,36 @@ func (_m *ShortenerRepository) GetOriginal(ctx context.Context, short string) (s
 	return r0, r1
 }
 
+// ListByOwner provides a mock function with given fields: ctx, query
+func (_m *ShortenerRepository) ListByOwner(ctx context.Context, query domain.LinksQuery) (*domain.LinksPage, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListByOwner")
+	}
+
+	var r0 *domain.LinksPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.LinksQuery) (*domain.LinksPage, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.LinksQuery) *domain.LinksPage); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.LinksPage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.LinksQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Remove provides a mock function with given fields: ctx, short
 func (_m *ShortenerRepository) Remove(ctx context.Context, short string) error {
 	ret := _m.Called(ctx, short)
@@ -77,6 +141,34 @@ func (_m *ShortenerRepository) Remove(ctx context.Context, short string) error {
 	return r0
 }
 
+// RemoveExpired provides a mock function with given fields: ctx, now
+func (_m *ShortenerRepository) RemoveExpired(ctx context.Context, now time.Time) (int64, error) {
+	ret := _m.Called(ctx, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveExpired")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, now)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewShortenerRepository creates a new instance of ShortenerRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewShortenerRepository(t interface {
@@ -14,17 +14,47 @@ type ShortenerService struct {
 	mock.Mock
 }
 
-// Remove provides a mock function with given fields: ctx, short
-func (_m *ShortenerService) Remove(ctx context.Context, short string) error {
-	ret := _m.Called(ctx, short)
+// List provides a mock function with given fields: ctx, query
+func (_m *ShortenerService) List(ctx context.Context, query domain.LinksQuery) (*domain.LinksPage, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 *domain.LinksPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.LinksQuery) (*domain.LinksPage, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.LinksQuery) *domain.LinksPage); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.LinksPage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.LinksQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Remove provides a mock function with given fields: ctx, short, caller
+func (_m *ShortenerService) Remove(ctx context.Context, short string, caller *domain.Principal) error {
+	ret := _m.Called(ctx, short, caller)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Remove")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, short)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.Principal) error); ok {
+		r0 = rf(ctx, short, caller)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -32,23 +62,53 @@ func (_m *ShortenerService) Remove(ctx context.Context, short string) error {
 	return r0
 }
 
+// RemoveExpired provides a mock function with given fields: ctx
+func (_m *ShortenerService) RemoveExpired(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveExpired")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Resolve provides a mock function with given fields: ctx, short
-func (_m *ShortenerService) Resolve(ctx context.Context, short string) (string, error) {
+func (_m *ShortenerService) Resolve(ctx context.Context, short string) (*domain.URL, error) {
 	ret := _m.Called(ctx, short)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Resolve")
 	}
 
-	var r0 string
+	var r0 *domain.URL
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.URL, error)); ok {
 		return rf(ctx, short)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.URL); ok {
 		r0 = rf(ctx, short)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.URL)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
@@ -60,9 +120,9 @@ func (_m *ShortenerService) Resolve(ctx context.Context, short string) (string,
 	return r0, r1
 }
 
-// Shorten provides a mock function with given fields: ctx, url, author
-func (_m *ShortenerService) Shorten(ctx context.Context, url string, author *domain.User) (string, error) {
-	ret := _m.Called(ctx, url, author)
+// Shorten provides a mock function with given fields: ctx, url, author, opts
+func (_m *ShortenerService) Shorten(ctx context.Context, url *domain.URL, author *domain.Principal, opts domain.ShortenOptions) (string, error) {
+	ret := _m.Called(ctx, url, author, opts)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Shorten")
@@ -70,17 +130,17 @@ func (_m *ShortenerService) Shorten(ctx context.Context, url string, author *dom
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.User) (string, error)); ok {
-		return rf(ctx, url, author)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.URL, *domain.Principal, domain.ShortenOptions) (string, error)); ok {
+		return rf(ctx, url, author, opts)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.User) string); ok {
-		r0 = rf(ctx, url, author)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.URL, *domain.Principal, domain.ShortenOptions) string); ok {
+		r0 = rf(ctx, url, author, opts)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, *domain.User) error); ok {
-		r1 = rf(ctx, url, author)
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.URL, *domain.Principal, domain.ShortenOptions) error); ok {
+		r1 = rf(ctx, url, author, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
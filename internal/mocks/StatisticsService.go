@@ -14,17 +14,17 @@ type StatisticsService struct {
 	mock.Mock
 }
 
-// AddEvent provides a mock function with given fields: ctx, event
-func (_m *StatisticsService) AddEvent(ctx context.Context, event domain.Event) error {
-	ret := _m.Called(ctx, event)
+// AddEvents provides a mock function with given fields: ctx, events
+func (_m *StatisticsService) AddEvents(ctx context.Context, events []domain.Event) error {
+	ret := _m.Called(ctx, events)
 
 	if len(ret) == 0 {
-		panic("no return value specified for AddEvent")
+		panic("no return value specified for AddEvents")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, domain.Event) error); ok {
-		r0 = rf(ctx, event)
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.Event) error); ok {
+		r0 = rf(ctx, events)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -32,6 +32,126 @@ func (_m *StatisticsService) AddEvent(ctx context.Context, event domain.Event) e
 	return r0
 }
 
+// GetSummary provides a mock function with given fields: ctx, query
+func (_m *StatisticsService) GetSummary(ctx context.Context, query domain.StatisticsQuery) (*domain.ClickSummary, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSummary")
+	}
+
+	var r0 *domain.ClickSummary
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.StatisticsQuery) (*domain.ClickSummary, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.StatisticsQuery) *domain.ClickSummary); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.ClickSummary)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.StatisticsQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTimeSeries provides a mock function with given fields: ctx, query
+func (_m *StatisticsService) GetTimeSeries(ctx context.Context, query domain.StatisticsQuery) ([]domain.ClickBucket, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTimeSeries")
+	}
+
+	var r0 []domain.ClickBucket
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.StatisticsQuery) ([]domain.ClickBucket, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.StatisticsQuery) []domain.ClickBucket); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.ClickBucket)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.StatisticsQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTopReferrers provides a mock function with given fields: ctx, query
+func (_m *StatisticsService) GetTopReferrers(ctx context.Context, query domain.StatisticsQuery) ([]domain.TopValue, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTopReferrers")
+	}
+
+	var r0 []domain.TopValue
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.StatisticsQuery) ([]domain.TopValue, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.StatisticsQuery) []domain.TopValue); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.TopValue)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.StatisticsQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTopUserAgents provides a mock function with given fields: ctx, query
+func (_m *StatisticsService) GetTopUserAgents(ctx context.Context, query domain.StatisticsQuery) ([]domain.TopValue, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTopUserAgents")
+	}
+
+	var r0 []domain.TopValue
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.StatisticsQuery) ([]domain.TopValue, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.StatisticsQuery) []domain.TopValue); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.TopValue)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.StatisticsQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewStatisticsService creates a new instance of StatisticsService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewStatisticsService(t interface {
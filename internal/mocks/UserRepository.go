@@ -32,6 +32,80 @@ func (_m *UserRepository) ChangeLinksRemaining(ctx context.Context, username str
 	return r0
 }
 
+// ChangePassword provides a mock function with given fields: ctx, username, password
+func (_m *UserRepository) ChangePassword(ctx context.Context, username string, password string) error {
+	ret := _m.Called(ctx, username, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, username, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ChangePlan provides a mock function with given fields: ctx, username, plan
+func (_m *UserRepository) ChangePlan(ctx context.Context, username string, plan *domain.Plan) (bool, error) {
+	ret := _m.Called(ctx, username, plan)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePlan")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.Plan) (bool, error)); ok {
+		return rf(ctx, username, plan)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.Plan) bool); ok {
+		r0 = rf(ctx, username, plan)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *domain.Plan) error); ok {
+		r1 = rf(ctx, username, plan)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConsumeLinkQuota provides a mock function with given fields: ctx, username
+func (_m *UserRepository) ConsumeLinkQuota(ctx context.Context, username string) (bool, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeLinkQuota")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetByUsername provides a mock function with given fields: ctx, username
 func (_m *UserRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
 	ret := _m.Called(ctx, username)
@@ -62,6 +136,42 @@ func (_m *UserRepository) GetByUsername(ctx context.Context, username string) (*
 	return r0, r1
 }
 
+// IncrementTokenVersion provides a mock function with given fields: ctx, username
+func (_m *UserRepository) IncrementTokenVersion(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrementTokenVersion")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RefundLinkQuota provides a mock function with given fields: ctx, username
+func (_m *UserRepository) RefundLinkQuota(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefundLinkQuota")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Save provides a mock function with given fields: ctx, user
 func (_m *UserRepository) Save(ctx context.Context, user *domain.User) error {
 	ret := _m.Called(ctx, user)
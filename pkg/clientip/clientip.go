@@ -0,0 +1,166 @@
+package clientip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// Resolver determines the IP address of the client that made the request. The forwarding headers are honored
+// only when they are set by the trusted proxies, so clients can't spoof their address.
+type Resolver struct {
+	trusted []*net.IPNet
+}
+
+// NewResolver creates a resolver trusting the proxies from the networks in CIDR notation or with the single
+// IP addresses.
+func NewResolver(trustedProxies []string) (*Resolver, error) {
+	trusted := make([]*net.IPNet, 0, len(trustedProxies))
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy address %q", proxy)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			trusted = append(trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy network %q: %w", proxy, err)
+		}
+		trusted = append(trusted, network)
+	}
+
+	return &Resolver{trusted: trusted}, nil
+}
+
+// ClientIP returns the IP address of the client. If the request comes from a trusted proxy, the addresses
+// forwarded by the proxies (Forwarded, then X-Forwarded-For, then X-Real-IP) are walked from the nearest one
+// and the first address that is not trusted is returned. The farthest address is returned if all of them are
+// trusted, and the nearest one if the next address can't be parsed.
+func (r *Resolver) ClientIP(req *http.Request) string {
+	remote := parseIP(req.RemoteAddr)
+	if remote == nil {
+		return req.RemoteAddr
+	}
+
+	client := remote
+	if !r.isTrusted(client) {
+		return client.String()
+	}
+
+	hops := forwardedHops(req.Header)
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := parseIP(hops[i])
+		if hop == nil {
+			break
+		}
+
+		client = hop
+		if !r.isTrusted(client) {
+			break
+		}
+	}
+
+	return client.String()
+}
+
+// Handle resolves the IP address of the client and puts it into the request context for FromRequest.
+func (r *Resolver) Handle(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := context.WithValue(req.Context(), contextKey{}, r.ClientIP(req))
+		handler.ServeHTTP(w, req.WithContext(ctx))
+	}
+}
+
+// FromRequest returns the IP address of the client resolved by Resolver.Handle. Without it the address of the
+// peer is returned.
+func FromRequest(req *http.Request) string {
+	if ip, ok := req.Context().Value(contextKey{}).(string); ok {
+		return ip
+	}
+
+	if ip := parseIP(req.RemoteAddr); ip != nil {
+		return ip.String()
+	}
+
+	return req.RemoteAddr
+}
+
+// isTrusted checks if the address belongs to a trusted proxy.
+func (r *Resolver) isTrusted(ip net.IP) bool {
+	for _, network := range r.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// forwardedHops returns the addresses forwarded by the proxies from the farthest to the nearest one. Only the
+// first of the headers that is present is used.
+func forwardedHops(header http.Header) []string {
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		var hops []string
+		for _, element := range splitList(values) {
+			hops = append(hops, forwardedFor(element))
+		}
+		return hops
+	}
+
+	if values := header.Values("X-Forwarded-For"); len(values) > 0 {
+		return splitList(values)
+	}
+
+	if realIP := strings.TrimSpace(header.Get("X-Real-IP")); realIP != "" {
+		return []string{realIP}
+	}
+
+	return nil
+}
+
+// forwardedFor returns the value of the "for" parameter of the RFC 7239 Forwarded header element.
+func forwardedFor(element string) string {
+	for _, pair := range strings.Split(element, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if strings.EqualFold(name, "for") {
+			return strings.Trim(value, `"`)
+		}
+	}
+
+	return ""
+}
+
+// splitList splits the comma-separated values of all the header lines into the trimmed elements.
+func splitList(values []string) []string {
+	var elements []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			elements = append(elements, strings.TrimSpace(element))
+		}
+	}
+
+	return elements
+}
+
+// parseIP parses the IP address that may be followed by a port or enclosed in brackets, nil is returned if it
+// is not an IP address.
+func parseIP(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	return net.ParseIP(strings.Trim(addr, "[]"))
+}
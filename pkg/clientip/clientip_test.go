@@ -0,0 +1,138 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewResolver(t *testing.T) {
+	_, err := NewResolver([]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32", "::1"})
+	require.NoError(t, err)
+
+	_, err = NewResolver([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+
+	_, err = NewResolver([]string{"proxy.local"})
+	require.Error(t, err)
+}
+
+func TestResolver_ClientIP(t *testing.T) {
+	resolver, err := NewResolver([]string{"10.0.0.0/8", "2001:db8::/32"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string][]string
+		expected   string
+	}{
+		{
+			name:       "direct client",
+			remoteAddr: "198.51.100.7:4321",
+			expected:   "198.51.100.7",
+		},
+		{
+			name:       "headers from untrusted peer are ignored",
+			remoteAddr: "198.51.100.7:4321",
+			headers:    map[string][]string{"X-Forwarded-For": {"203.0.113.5"}, "X-Real-Ip": {"203.0.113.5"}},
+			expected:   "198.51.100.7",
+		},
+		{
+			name:       "trusted proxy without headers",
+			remoteAddr: "10.0.0.2:4321",
+			expected:   "10.0.0.2",
+		},
+		{
+			name:       "x-forwarded-for",
+			remoteAddr: "10.0.0.2:4321",
+			headers:    map[string][]string{"X-Forwarded-For": {"203.0.113.5"}},
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "spoofed x-forwarded-for entries are skipped",
+			remoteAddr: "10.0.0.2:4321",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.2.3.4, 203.0.113.5", "10.0.0.3"}},
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "all hops trusted",
+			remoteAddr: "10.0.0.2:4321",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.1.1.1, 10.0.0.3"}},
+			expected:   "10.1.1.1",
+		},
+		{
+			name:       "invalid hop",
+			remoteAddr: "10.0.0.2:4321",
+			headers:    map[string][]string{"X-Forwarded-For": {"203.0.113.5, unknown, 10.0.0.3"}},
+			expected:   "10.0.0.3",
+		},
+		{
+			name:       "x-real-ip",
+			remoteAddr: "10.0.0.2:4321",
+			headers:    map[string][]string{"X-Real-Ip": {"203.0.113.5"}},
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "forwarded takes precedence",
+			remoteAddr: "10.0.0.2:4321",
+			headers: map[string][]string{
+				"Forwarded":       {`for=192.0.2.60;proto=http;by=203.0.113.43`},
+				"X-Forwarded-For": {"203.0.113.5"},
+			},
+			expected: "192.0.2.60",
+		},
+		{
+			name:       "forwarded with ipv6 and ports",
+			remoteAddr: "[2001:db8::1]:443",
+			headers:    map[string][]string{"Forwarded": {`for="[2001:db9::7]:4711", For="10.0.0.3:80"`}},
+			expected:   "2001:db9::7",
+		},
+		{
+			name:       "forwarded with obfuscated identifier",
+			remoteAddr: "10.0.0.2:4321",
+			headers:    map[string][]string{"Forwarded": {"for=_hidden, for=10.0.0.3"}},
+			expected:   "10.0.0.3",
+		},
+		{
+			name:       "peer address without port",
+			remoteAddr: "198.51.100.7",
+			expected:   "198.51.100.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, values := range tt.headers {
+				for _, value := range values {
+					req.Header.Add(name, value)
+				}
+			}
+
+			assert.Equal(t, tt.expected, resolver.ClientIP(req))
+		})
+	}
+}
+
+func TestResolver_Handle(t *testing.T) {
+	resolver, err := NewResolver([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	var resolved string
+	handler := resolver.Handle(func(_ http.ResponseWriter, r *http.Request) {
+		resolved = FromRequest(r)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.2:4321"
+	req.Header.Set("X-Forwarded-For", "203.0.113.5")
+	handler(httptest.NewRecorder(), req)
+	assert.Equal(t, "203.0.113.5", resolved)
+
+	assert.Equal(t, "10.0.0.2", FromRequest(req), "without the resolver the peer address is used")
+}
@@ -0,0 +1,57 @@
+package limiter
+
+import (
+	"fmt"
+	"time"
+)
+
+// FixedWindow is a fixed window counter algorithm implementation. It allows at most limit events in every
+// window aligned to the window length, so up to twice the limit may happen around the window boundary.
+type FixedWindow struct {
+	base
+	limit  int64
+	window time.Duration
+	// counts are the numbers of events in the windows by their start in Unix nanoseconds, including the
+	// reserved ones.
+	counts map[int64]int64
+}
+
+// NewFixedWindow creates a new FixedWindow instance. It returns ErrInvalidConfig if the window is not positive.
+func NewFixedWindow(limit int64, window time.Duration, opts ...Option) (*FixedWindow, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("%w: non-positive window", ErrInvalidConfig)
+	}
+
+	w := &FixedWindow{
+		limit:  limit,
+		window: window,
+		counts: make(map[int64]int64),
+	}
+	w.init(limit, w.takeWindow, opts)
+
+	return w, nil
+}
+
+// takeWindow counts n events in the first window that has room for them if it starts within maxDelay.
+func (w *FixedWindow) takeWindow(now time.Time, n int64, maxDelay time.Duration) (time.Duration, bool) {
+	current := now.Truncate(w.window)
+	for start := range w.counts {
+		if start < current.UnixNano() {
+			delete(w.counts, start)
+		}
+	}
+
+	for start := current; ; start = start.Add(w.window) {
+		if w.counts[start.UnixNano()]+n > w.limit {
+			continue
+		}
+
+		delay := max(0, start.Sub(now))
+		if delay > maxDelay {
+			return 0, false
+		}
+
+		w.counts[start.UnixNano()] += n
+		return delay, true
+	}
+}
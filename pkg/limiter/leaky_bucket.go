@@ -0,0 +1,46 @@
+package limiter
+
+import (
+	"fmt"
+	"time"
+)
+
+// LeakyBucket is a leaky bucket algorithm implementation used as a queue. The events leave the bucket at the
+// constant rate per second, so unlike TokenBucket it allows no bursts. An event is allowed only if it leaves
+// the bucket immediately, and it can be reserved as long as at most capacity events are queued.
+type LeakyBucket struct {
+	base
+	interval time.Duration
+	capacity int64
+	// next is the time when the next event leaves the bucket.
+	next time.Time
+}
+
+// NewLeakyBucket creates a new LeakyBucket instance. It returns ErrInvalidConfig if the rate is not positive.
+func NewLeakyBucket(rate int64, capacity int64, opts ...Option) (*LeakyBucket, error) {
+	if rate <= 0 {
+		return nil, fmt.Errorf("%w: non-positive rate", ErrInvalidConfig)
+	}
+
+	lb := &LeakyBucket{capacity: capacity, interval: time.Second / time.Duration(rate)}
+	lb.init(capacity, lb.takeQueue, opts)
+
+	return lb, nil
+}
+
+// takeQueue queues n events if the bucket has room for them and they leave it within maxDelay.
+func (lb *LeakyBucket) takeQueue(now time.Time, n int64, maxDelay time.Duration) (time.Duration, bool) {
+	start := now
+	if lb.next.After(start) {
+		start = lb.next
+	}
+
+	// The queued events take the time until the start to leave the bucket.
+	delay := start.Sub(now)
+	if delay+time.Duration(n)*lb.interval > time.Duration(lb.capacity)*lb.interval || delay > maxDelay {
+		return 0, false
+	}
+
+	lb.next = start.Add(time.Duration(n) * lb.interval)
+	return delay, true
+}
@@ -0,0 +1,170 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrExceedsCapacity is returned when more permits are requested at once than the limiter ever allows.
+	ErrExceedsCapacity = errors.New("limiter: requested permits exceed the capacity")
+	// ErrWouldExceedDeadline is returned when the permits can't be acquired before the context deadline.
+	ErrWouldExceedDeadline = errors.New("limiter: wait would exceed the context deadline")
+	// ErrInvalidConfig is returned by the constructors when the window or the rate of the limiter is not positive.
+	ErrInvalidConfig = errors.New("limiter: invalid configuration")
+)
+
+// maxDelay is the delay that is never exceeded, it is used when the caller is ready to wait for any time.
+const maxDelay = time.Duration(math.MaxInt64)
+
+// Limiter limits the rate of the events.
+type Limiter interface {
+	// Allow reports whether an event may happen now.
+	Allow() bool
+	// AllowN reports whether n events may happen now.
+	AllowN(n int64) bool
+	// Reserve reserves a permit for an event that may happen after the delay of the reservation.
+	Reserve() Reservation
+	// ReserveN reserves n permits for the events that may happen after the delay of the reservation.
+	ReserveN(n int64) Reservation
+	// Wait blocks until an event may happen or the context is done.
+	Wait(ctx context.Context) error
+	// WaitN blocks until n events may happen or the context is done.
+	WaitN(ctx context.Context, n int64) error
+}
+
+// Reservation is the permission for the events to happen after the delay. The permits are taken when the
+// reservation is made, so the delay must be respected.
+type Reservation struct {
+	// OK is false if the permits exceed the capacity of the limiter, they are never reserved then.
+	OK    bool
+	Delay time.Duration
+}
+
+// Clock is the source of the time of the limiters.
+type Clock interface {
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the clock returning the system time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Option configures the limiter.
+type Option func(*base)
+
+// WithClock makes the limiter use the clock instead of the system one.
+func WithClock(clock Clock) Option {
+	return func(b *base) {
+		b.clock = clock
+	}
+}
+
+// takeFunc takes n permits at now if they are available within maxDelay and returns their delay. The permits
+// are not taken if it returns false.
+type takeFunc func(now time.Time, n int64, maxDelay time.Duration) (time.Duration, bool)
+
+// base implements the Limiter methods on top of the take function of the algorithm.
+type base struct {
+	mu       sync.Mutex
+	clock    Clock
+	capacity int64
+	take     takeFunc
+}
+
+// init sets up the base of the limiter allowing at most capacity permits at once.
+func (b *base) init(capacity int64, take takeFunc, opts []Option) {
+	b.clock = systemClock{}
+	b.capacity = capacity
+	b.take = take
+	for _, opt := range opts {
+		opt(b)
+	}
+}
+
+func (b *base) Allow() bool {
+	return b.AllowN(1)
+}
+
+func (b *base) AllowN(n int64) bool {
+	_, ok := b.reserve(n, 0)
+	return ok
+}
+
+func (b *base) Reserve() Reservation {
+	return b.ReserveN(1)
+}
+
+func (b *base) ReserveN(n int64) Reservation {
+	delay, ok := b.reserve(n, maxDelay)
+	return Reservation{OK: ok, Delay: delay}
+}
+
+func (b *base) Wait(ctx context.Context) error {
+	return b.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n events may happen. It doesn't take the permits if they are not available before the
+// context deadline, but the permits taken are not given back if the context is canceled while waiting.
+func (b *base) WaitN(ctx context.Context, n int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if n > b.capacity {
+		return ErrExceedsCapacity
+	}
+
+	limit := maxDelay
+	if deadline, ok := ctx.Deadline(); ok {
+		limit = deadline.Sub(b.clock.Now())
+	}
+
+	delay, ok := b.reserve(n, limit)
+	if !ok {
+		return ErrWouldExceedDeadline
+	}
+
+	if delay <= 0 {
+		return nil
+	}
+
+	select {
+	case <-b.clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reserve takes n permits if they are available within maxDelay.
+func (b *base) reserve(n int64, maxDelay time.Duration) (time.Duration, bool) {
+	if n <= 0 {
+		return 0, true
+	}
+
+	if n > b.capacity {
+		return 0, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.take(b.clock.Now(), n, maxDelay)
+}
+
+// durationOf converts the seconds to the duration rounded up, so the permits are surely available after it.
+func durationOf(seconds float64) time.Duration {
+	if seconds <= 0 {
+		return 0
+	}
+
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
@@ -0,0 +1,261 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is the clock that moves only when it is advanced.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	c.timers = append(c.timers, fakeTimer{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock and fires the timers that are due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.at.After(c.now) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.ch <- c.now
+	}
+	c.timers = pending
+}
+
+// waiters returns the number of the timers that haven't fired yet.
+func (c *fakeClock) waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+func TestLimiters_Wait(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter func(t *testing.T, clock Clock) Limiter
+		delay   time.Duration
+	}{
+		{
+			name:    "token bucket",
+			limiter: func(_ *testing.T, clock Clock) Limiter { return NewTokenBucket(1, 1, WithClock(clock)) },
+			delay:   time.Second,
+		},
+		{
+			name: "sliding log",
+			limiter: func(t *testing.T, clock Clock) Limiter {
+				l, err := NewSlidingLog(1, time.Second, WithClock(clock))
+				require.NoError(t, err)
+				return l
+			},
+			delay: time.Second,
+		},
+		{
+			name: "sliding window",
+			limiter: func(t *testing.T, clock Clock) Limiter {
+				l, err := NewSlidingWindow(1, time.Second, WithClock(clock))
+				require.NoError(t, err)
+				return l
+			},
+			delay: 2 * time.Second,
+		},
+		{
+			name: "fixed window",
+			limiter: func(t *testing.T, clock Clock) Limiter {
+				l, err := NewFixedWindow(1, time.Second, WithClock(clock))
+				require.NoError(t, err)
+				return l
+			},
+			delay: time.Second,
+		},
+		{
+			name: "leaky bucket",
+			limiter: func(t *testing.T, clock Clock) Limiter {
+				l, err := NewLeakyBucket(1, 2, WithClock(clock))
+				require.NoError(t, err)
+				return l
+			},
+			delay: time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			l := tt.limiter(t, clock)
+			require.NoError(t, l.Wait(context.Background()), "The first event should not wait")
+
+			done := make(chan error, 1)
+			go func() {
+				done <- l.Wait(context.Background())
+			}()
+
+			assert.Eventually(t, func() bool { return clock.waiters() == 1 }, time.Second, time.Millisecond)
+			clock.Advance(tt.delay - time.Millisecond)
+			assert.Never(t, func() bool { return len(done) > 0 }, 10*time.Millisecond, time.Millisecond)
+
+			clock.Advance(time.Millisecond)
+			assert.NoError(t, <-done)
+		})
+	}
+}
+
+func TestLimiters_WaitDeadline(t *testing.T) {
+	l, err := NewSlidingLog(1, time.Hour)
+	require.NoError(t, err)
+	require.True(t, l.Allow())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx), ErrWouldExceedDeadline)
+	assert.ErrorIs(t, l.WaitN(context.Background(), 2), ErrExceedsCapacity)
+
+	reservation := l.Reserve()
+	assert.True(t, reservation.OK)
+	assert.Greater(t, reservation.Delay, 59*time.Minute, "The permits should not be taken by the failed waits")
+	assert.LessOrEqual(t, reservation.Delay, time.Hour, "The permits should not be taken by the failed waits")
+}
+
+func TestLimiters_WaitCanceled(t *testing.T) {
+	clock := newFakeClock()
+	l, err := NewSlidingLog(1, time.Second, WithClock(clock))
+	require.NoError(t, err)
+	require.True(t, l.Allow())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- l.Wait(ctx)
+	}()
+
+	assert.Eventually(t, func() bool { return clock.waiters() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestSlidingLog(t *testing.T) {
+	clock := newFakeClock()
+	l, err := NewSlidingLog(3, time.Second, WithClock(clock))
+	require.NoError(t, err)
+
+	assert.True(t, l.AllowN(2))
+	clock.Advance(400 * time.Millisecond)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow(), "Should not allow more events than the limit in the window")
+
+	clock.Advance(599 * time.Millisecond)
+	assert.False(t, l.Allow(), "The first events should still be in the window")
+	clock.Advance(time.Millisecond)
+	assert.True(t, l.AllowN(2))
+	assert.False(t, l.Allow())
+
+	assert.Equal(t, Reservation{OK: true, Delay: 400 * time.Millisecond}, l.Reserve())
+	assert.Equal(t, Reservation{OK: true, Delay: time.Second}, l.ReserveN(2))
+	assert.False(t, l.ReserveN(4).OK)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	clock := newFakeClock()
+	l, err := NewSlidingWindow(4, time.Second, WithClock(clock))
+	require.NoError(t, err)
+
+	assert.True(t, l.AllowN(4))
+	assert.False(t, l.Allow())
+
+	// The previous window is weighted by 3/4, so the estimate is 3.
+	clock.Advance(1250 * time.Millisecond)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow(), "The estimate should not exceed the limit")
+
+	// The estimate is 4 * (1 - elapsed) + 1, so there is room for one more event at the half of the window.
+	assert.Equal(t, Reservation{OK: true, Delay: 250 * time.Millisecond}, l.Reserve())
+
+	// The current window is full, so the events are reserved in the next one with the previous window of 2.
+	assert.Equal(t, Reservation{OK: true, Delay: 750 * time.Millisecond}, l.ReserveN(2))
+	assert.Equal(t, Reservation{OK: true, Delay: 1250 * time.Millisecond}, l.Reserve())
+}
+
+func TestFixedWindow(t *testing.T) {
+	clock := newFakeClock()
+	clock.Advance(900 * time.Millisecond)
+	l, err := NewFixedWindow(2, time.Second, WithClock(clock))
+	require.NoError(t, err)
+
+	assert.True(t, l.AllowN(2))
+	assert.False(t, l.Allow())
+
+	clock.Advance(100 * time.Millisecond)
+	assert.True(t, l.AllowN(2), "The limit should reset at the window boundary")
+
+	assert.Equal(t, Reservation{OK: true, Delay: time.Second}, l.Reserve())
+	assert.Equal(t, Reservation{OK: true, Delay: 2 * time.Second}, l.ReserveN(2))
+	assert.Equal(t, Reservation{OK: true, Delay: time.Second}, l.Reserve())
+}
+
+func TestLeakyBucket(t *testing.T) {
+	clock := newFakeClock()
+	l, err := NewLeakyBucket(4, 3, WithClock(clock))
+	require.NoError(t, err)
+
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow(), "Should not allow bursts")
+
+	assert.Equal(t, Reservation{OK: true, Delay: 250 * time.Millisecond}, l.Reserve())
+	assert.Equal(t, Reservation{OK: true, Delay: 500 * time.Millisecond}, l.Reserve())
+	assert.False(t, l.Reserve().OK, "Should not queue more events than the capacity")
+
+	clock.Advance(500 * time.Millisecond)
+	assert.Equal(t, Reservation{OK: true, Delay: 250 * time.Millisecond}, l.ReserveN(2))
+	assert.False(t, l.AllowN(4))
+
+	clock.Advance(time.Second)
+	assert.True(t, l.Allow())
+}
+
+func TestLimiters_InvalidConfig(t *testing.T) {
+	_, err := NewSlidingLog(1, 0)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = NewSlidingWindow(1, -time.Second)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = NewFixedWindow(1, 0)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = NewLeakyBucket(0, 1)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
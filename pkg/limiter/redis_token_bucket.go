@@ -0,0 +1,141 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// redisTimeout is the maximal time the bucket waits for Redis before it lets the request through.
+	redisTimeout = 100 * time.Millisecond
+	// defaultIdleTimeout is the expiry of the buckets that are never refilled if the idle timeout is not set.
+	defaultIdleTimeout = time.Hour
+)
+
+// gcraScript implements the token bucket as the generic cell rate algorithm. The key stores the theoretical
+// arrival time (TAT) of the next request in microseconds of the Redis clock, so the replicas sharing the bucket
+// don't depend on their own clocks. A request is allowed if it doesn't move the TAT further than burst emission
+// intervals from now. The key expires once the bucket is full again.
+// It returns whether the request is allowed, the remaining tokens, and the microseconds until the bucket is
+// full and until the next request can be made.
+var gcraScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2]) * interval
+
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+
+local next_tat = tat + interval
+if next_tat - now > tolerance then
+	return {0, 0, math.ceil(tat - now), math.ceil(next_tat - now - tolerance)}
+end
+
+redis.call('SET', KEYS[1], string.format('%.0f', next_tat), 'PX', math.ceil((next_tat - now) / 1000))
+return {1, math.floor((tolerance - (next_tat - now)) / interval), math.ceil(next_tat - now), 0}
+`)
+
+// fixedScript implements the bucket that is never refilled, it counts the requests up to burst. The key expires
+// after the idle timeout in milliseconds without requests, so the bucket of a client that went away is full again,
+// the same as the in-memory bucket evicted after the idle timeout. It returns the same reply as gcraScript.
+var fixedScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local idle_timeout = tonumber(ARGV[2])
+local taken = tonumber(redis.call('GET', KEYS[1]) or 0)
+if taken >= burst then
+	redis.call('PEXPIRE', KEYS[1], idle_timeout)
+	return {0, 0, 0, 0}
+end
+
+redis.call('SET', KEYS[1], taken + 1, 'PX', idle_timeout)
+return {1, burst - taken - 1, 0, 0}
+`)
+
+// RedisTokenBucket is a token bucket stored in Redis, so it is shared by all the instances of the service.
+type RedisTokenBucket struct {
+	client      redis.Scripter
+	key         string
+	rate        int64
+	maxTokens   int64
+	idleTimeout time.Duration
+}
+
+// NewRedisTokenBucket creates a new RedisTokenBucket instance stored at the key. Like TokenBucket, it refills
+// rate tokens per second up to maxTokens, and allows maxTokens requests and no more if the rate is not positive.
+// The key of such a bucket expires after idleTimeout without requests (an hour if it is not positive), the key
+// of a refilled bucket expires once the bucket is full.
+func NewRedisTokenBucket(
+	client redis.Scripter,
+	key string,
+	rate int64,
+	maxTokens int64,
+	idleTimeout time.Duration,
+) *RedisTokenBucket {
+	if idleTimeout <= 0 {
+		idleTimeout = defaultIdleTimeout
+	}
+
+	return &RedisTokenBucket{
+		client:      client,
+		key:         key,
+		rate:        rate,
+		maxTokens:   maxTokens,
+		idleTimeout: idleTimeout,
+	}
+}
+
+// Allow checks if the token bucket has enough tokens to allow a request.
+func (tb *RedisTokenBucket) Allow() bool {
+	return tb.Take().Allowed
+}
+
+// Take takes a token from the bucket if there is one and returns the state of the bucket. The request is
+// allowed if Redis is unavailable, so the limiter doesn't take the service down with it.
+func (tb *RedisTokenBucket) Take() Result {
+	if tb.maxTokens <= 0 {
+		return Result{Limit: tb.maxTokens}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	var cmd *redis.Cmd
+	if tb.rate <= 0 {
+		idleTimeout := max(tb.idleTimeout.Milliseconds(), 1)
+		cmd = fixedScript.Run(ctx, tb.client, []string{tb.key}, tb.maxTokens, idleTimeout)
+	} else {
+		interval := float64(time.Second/time.Microsecond) / float64(tb.rate)
+		cmd = gcraScript.Run(
+			ctx,
+			tb.client,
+			[]string{tb.key},
+			strconv.FormatFloat(interval, 'f', -1, 64),
+			tb.maxTokens,
+		)
+	}
+
+	reply, err := cmd.Int64Slice()
+	if err == nil && len(reply) != 4 {
+		err = fmt.Errorf("unexpected reply %v", reply)
+	}
+	if err != nil {
+		log.Errorf("failed to take token from bucket %s: %v", tb.key, err)
+		return Result{Allowed: true, Limit: tb.maxTokens, Remaining: tb.maxTokens}
+	}
+
+	return Result{
+		Allowed:    reply[0] == 1,
+		Limit:      tb.maxTokens,
+		Remaining:  reply[1],
+		ResetAfter: time.Duration(reply[2]) * time.Microsecond,
+		RetryAfter: time.Duration(reply[3]) * time.Microsecond,
+	}
+}
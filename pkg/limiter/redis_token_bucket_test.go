@@ -0,0 +1,90 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisTokenBucket_Allow(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	server.SetTime(now)
+
+	tb := NewRedisTokenBucket(client, "ratelimit:127.0.0.1", 2, 3, time.Minute)
+	for i := 0; i < 3; i++ {
+		assert.True(t, tb.Allow(), "Initial tokens should allow requests")
+	}
+	assert.False(t, tb.Allow(), "Should not allow more requests than initial tokens")
+
+	replica := NewRedisTokenBucket(client, "ratelimit:127.0.0.1", 2, 3, time.Minute)
+	assert.False(t, replica.Allow(), "Bucket should be shared by the instances with the same key")
+	assert.True(t, NewRedisTokenBucket(client, "ratelimit:127.0.0.2", 2, 3, time.Minute).Allow(), "Keys should be independent")
+
+	ttl := server.TTL("ratelimit:127.0.0.1")
+	assert.True(t, ttl > time.Second && ttl <= 1500*time.Millisecond, "Key should expire once the bucket is full")
+
+	server.SetTime(now.Add(500 * time.Millisecond))
+	assert.True(t, tb.Allow(), "Should allow one request after half a second")
+	assert.False(t, tb.Allow(), "Should not allow more requests than refilled tokens")
+
+	server.SetTime(now.Add(10 * time.Second))
+	for i := 0; i < 3; i++ {
+		assert.True(t, tb.Allow(), "Should allow up to max tokens requests")
+	}
+	assert.False(t, tb.Allow(), "Should not allow more requests than max tokens")
+}
+
+func TestRedisTokenBucket_RedisUnavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	server.Close()
+
+	tb := NewRedisTokenBucket(client, "ratelimit:127.0.0.1", 1, 1, time.Minute)
+	assert.True(t, tb.Allow(), "Requests should be allowed while Redis is unavailable")
+	assert.True(t, tb.Allow(), "Requests should be allowed while Redis is unavailable")
+}
+
+func TestRedisTokenBucket_Take(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	server.SetTime(time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC))
+
+	tb := NewRedisTokenBucket(client, "ratelimit:127.0.0.1", 2, 3, time.Minute)
+	assert.Equal(t, Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: 500 * time.Millisecond}, tb.Take())
+
+	tb.Take()
+	tb.Take()
+	assert.Equal(t, Result{
+		Limit:      3,
+		ResetAfter: 1500 * time.Millisecond,
+		RetryAfter: 500 * time.Millisecond,
+	}, tb.Take())
+}
+
+func TestRedisTokenBucket_ZeroRate(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	server.SetTime(now)
+
+	tb := NewRedisTokenBucket(client, "ratelimit:127.0.0.1", 0, 2, time.Minute)
+	assert.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 1}, tb.Take())
+	assert.True(t, tb.Allow(), "Initial tokens should allow requests")
+
+	server.SetTime(now.Add(30 * time.Second))
+	server.FastForward(30 * time.Second)
+	assert.False(t, tb.Allow(), "The bucket should never be refilled")
+	assert.Equal(t, Result{Limit: 2}, tb.Take())
+	assert.Equal(t, time.Minute, server.TTL("ratelimit:127.0.0.1"), "Rejected requests should keep the bucket")
+
+	server.FastForward(time.Minute)
+	assert.False(t, server.Exists("ratelimit:127.0.0.1"), "Idle bucket should expire")
+	assert.True(t, tb.Allow(), "Expired bucket should be full again")
+
+	assert.False(t, NewRedisTokenBucket(client, "ratelimit:127.0.0.2", 1, 0, time.Minute).Allow(), "Empty bucket allows nothing")
+}
@@ -0,0 +1,82 @@
+package limiter
+
+import (
+	"fmt"
+	"time"
+)
+
+// logEntry is the time of the events in the sliding log.
+type logEntry struct {
+	at time.Time
+	n  int64
+}
+
+// SlidingLog is a sliding window log algorithm implementation. It keeps the time of every event and allows at
+// most limit events in any window of the given length, so it is exact but uses memory proportional to the
+// limit.
+type SlidingLog struct {
+	base
+	limit  int64
+	window time.Duration
+	// entries are the events in the window ordered by time, including the reserved ones.
+	entries []logEntry
+	count   int64
+}
+
+// NewSlidingLog creates a new SlidingLog instance. It returns ErrInvalidConfig if the window is not positive.
+func NewSlidingLog(limit int64, window time.Duration, opts ...Option) (*SlidingLog, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("%w: non-positive window", ErrInvalidConfig)
+	}
+
+	l := &SlidingLog{
+		limit:  limit,
+		window: window,
+	}
+	l.init(limit, l.takeLog, opts)
+
+	return l, nil
+}
+
+// takeLog adds n events to the log if the window ends at them within maxDelay.
+func (l *SlidingLog) takeLog(now time.Time, n int64, maxDelay time.Duration) (time.Duration, bool) {
+	l.expire(now)
+
+	at := now
+	if excess := l.count + n - l.limit; excess > 0 {
+		// The events fit once enough of the oldest ones leave the window.
+		var freed int64
+		for _, entry := range l.entries {
+			freed += entry.n
+			if freed >= excess {
+				at = entry.at.Add(l.window)
+				break
+			}
+		}
+	}
+
+	if len(l.entries) > 0 && l.entries[len(l.entries)-1].at.After(at) {
+		at = l.entries[len(l.entries)-1].at
+	}
+
+	delay := at.Sub(now)
+	if delay > maxDelay {
+		return 0, false
+	}
+
+	l.entries = append(l.entries, logEntry{at: at, n: n})
+	l.count += n
+	return delay, true
+}
+
+// expire removes the events that left the window ending at now.
+func (l *SlidingLog) expire(now time.Time) {
+	cutoff := now.Add(-l.window)
+	expired := 0
+	for expired < len(l.entries) && !l.entries[expired].at.After(cutoff) {
+		l.count -= l.entries[expired].n
+		expired++
+	}
+
+	l.entries = l.entries[expired:]
+}
@@ -0,0 +1,97 @@
+package limiter
+
+import (
+	"fmt"
+	"time"
+)
+
+// SlidingWindow is a sliding window counter algorithm implementation. It approximates the number of events in
+// the window ending now with the count of the current fixed window and the count of the previous one weighted
+// by the part of it that is still in the sliding window. It allows an event if the estimate stays within the
+// limit, using the memory of two counters.
+type SlidingWindow struct {
+	base
+	limit  int64
+	window time.Duration
+	// counts are the numbers of events in the fixed windows by their start in Unix nanoseconds, including the
+	// reserved ones.
+	counts map[int64]int64
+	// last is the time of the latest reserved events. The events are reserved in order, as the events added to
+	// a window after the later ones would change the estimate the later ones were allowed by.
+	last time.Time
+}
+
+// NewSlidingWindow creates a new SlidingWindow instance. It returns ErrInvalidConfig if the window is not positive.
+func NewSlidingWindow(limit int64, window time.Duration, opts ...Option) (*SlidingWindow, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("%w: non-positive window", ErrInvalidConfig)
+	}
+
+	w := &SlidingWindow{
+		limit:  limit,
+		window: window,
+		counts: make(map[int64]int64),
+	}
+	w.init(limit, w.takeWindow, opts)
+
+	return w, nil
+}
+
+// takeWindow counts n events at the earliest time the estimate has room for them if it is within maxDelay.
+func (w *SlidingWindow) takeWindow(now time.Time, n int64, maxDelay time.Duration) (time.Duration, bool) {
+	current := now.Truncate(w.window)
+	for start := range w.counts {
+		if start < current.Add(-w.window).UnixNano() {
+			delete(w.counts, start)
+		}
+	}
+
+	from := now
+	if w.last.After(from) {
+		from = w.last
+	}
+
+	for start := from.Truncate(w.window); ; start = start.Add(w.window) {
+		if start.After(from) {
+			from = start
+		}
+
+		at, ok := w.earliest(start, from, n)
+		if !ok {
+			continue
+		}
+
+		delay := at.Sub(now)
+		if delay > maxDelay {
+			return 0, false
+		}
+
+		w.counts[start.UnixNano()] += n
+		w.last = at
+		return delay, true
+	}
+}
+
+// earliest returns the earliest time not before from in the fixed window starting at start when the estimate
+// has room for n events. It returns false if there is no such time in the window.
+func (w *SlidingWindow) earliest(start, from time.Time, n int64) (time.Time, bool) {
+	room := w.limit - n - w.counts[start.UnixNano()]
+	if room < 0 {
+		return time.Time{}, false
+	}
+
+	previous := w.counts[start.Add(-w.window).UnixNano()]
+	if previous <= room {
+		return from, true
+	}
+
+	// The weight of the previous window decreases linearly from 1 to 0 over the current window, the events fit
+	// once previous * (1 - elapsed/window) <= room.
+	elapsed := durationOf((1 - float64(room)/float64(previous)) * w.window.Seconds())
+	at := start.Add(elapsed)
+	if at.Before(from) {
+		at = from
+	}
+
+	return at, at.Before(start.Add(w.window))
+}
@@ -1,48 +1,99 @@
 package limiter
 
 import (
-	"sync"
 	"time"
 )
 
-// TokenBucket is a token bucket algorithm implementation.
+// Result describes the decision of the bucket and its state after the request.
+type Result struct {
+	Allowed bool
+	// Limit is the maximal number of tokens in the bucket.
+	Limit int64
+	// Remaining is the number of tokens left in the bucket.
+	Remaining int64
+	// ResetAfter is the time after which the bucket is full again.
+	ResetAfter time.Duration
+	// RetryAfter is the time after which the denied request can be retried. It is zero for allowed requests.
+	RetryAfter time.Duration
+}
+
+// TokenBucket is a token bucket algorithm implementation. The bucket holds up to maxTokens tokens and is
+// refilled with rate tokens per second, every event takes a token.
 type TokenBucket struct {
-	rate       int64
-	maxTokens  int64
-	nowTokens  int64
+	base
+	rate      int64
+	maxTokens int64
+	// nowTokens is fractional, so the refill doesn't lose the time elapsed since the last whole token. It is
+	// negative while the tokens are reserved in advance.
+	nowTokens  float64
 	lastRefill time.Time
-	mux        sync.Mutex
 }
 
 // NewTokenBucket creates a new TokenBucket instance.
-func NewTokenBucket(rate int64, maxTokens int64) *TokenBucket {
-	return &TokenBucket{
-		rate:       rate,
-		maxTokens:  maxTokens,
-		nowTokens:  maxTokens,
-		lastRefill: time.Now(),
+func NewTokenBucket(rate int64, maxTokens int64, opts ...Option) *TokenBucket {
+	tb := &TokenBucket{
+		rate:      rate,
+		maxTokens: maxTokens,
+		nowTokens: float64(maxTokens),
 	}
+	tb.init(maxTokens, tb.takeTokens, opts)
+	tb.lastRefill = tb.clock.Now()
+
+	return tb
 }
 
-// Allow checks if the token bucket has enough tokens to allow a request.
-func (tb *TokenBucket) Allow() bool {
-	tb.mux.Lock()
-	defer tb.mux.Unlock()
+// Take takes a token from the bucket if there is one and returns the state of the bucket.
+func (tb *TokenBucket) Take() Result {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
 
-	tb.refill()
-	if tb.nowTokens > 0 {
-		tb.nowTokens--
-		return true
+	_, allowed := tb.takeTokens(tb.clock.Now(), 1, 0)
+	result := Result{
+		Allowed:    allowed,
+		Limit:      tb.maxTokens,
+		Remaining:  max(0, int64(tb.nowTokens)),
+		ResetAfter: tb.timeToRefill(float64(tb.maxTokens) - tb.nowTokens),
+	}
+	if !allowed {
+		result.RetryAfter = tb.timeToRefill(1 - tb.nowTokens)
 	}
 
-	return false
+	return result
+}
+
+// takeTokens takes n tokens if they are refilled within maxDelay.
+func (tb *TokenBucket) takeTokens(now time.Time, n int64, maxDelay time.Duration) (time.Duration, bool) {
+	tb.refill(now)
+
+	var delay time.Duration
+	if missing := float64(n) - tb.nowTokens; missing > 0 {
+		if tb.rate <= 0 {
+			return 0, false
+		}
+
+		delay = tb.timeToRefill(missing)
+		if delay > maxDelay {
+			return 0, false
+		}
+	}
+
+	tb.nowTokens -= float64(n)
+	return delay, true
 }
 
 // refill refills the token bucket with new tokens.
-func (tb *TokenBucket) refill() {
-	now := time.Now()
-	end := time.Since(tb.lastRefill)
-	needTokens := (end.Nanoseconds() * tb.rate) / int64(time.Second)
-	tb.nowTokens = min(tb.maxTokens, tb.nowTokens+needTokens)
-	tb.lastRefill = now
+func (tb *TokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(tb.lastRefill); elapsed > 0 {
+		tb.nowTokens = min(float64(tb.maxTokens), tb.nowTokens+elapsed.Seconds()*float64(tb.rate))
+		tb.lastRefill = now
+	}
+}
+
+// timeToRefill returns the time it takes to refill the number of tokens.
+func (tb *TokenBucket) timeToRefill(tokens float64) time.Duration {
+	if tb.rate <= 0 {
+		return 0
+	}
+
+	return durationOf(tokens / float64(tb.rate))
 }
@@ -9,7 +9,8 @@ import (
 )
 
 func TestTokenBucket_Allow(t *testing.T) {
-	tb := NewTokenBucket(1, 10)
+	clock := newFakeClock()
+	tb := NewTokenBucket(1, 10, WithClock(clock))
 
 	for i := 0; i < 10; i++ {
 		assert.True(t, tb.Allow(), "Initial tokens should allow requests")
@@ -17,13 +18,13 @@ func TestTokenBucket_Allow(t *testing.T) {
 
 	assert.False(t, tb.Allow(), "Should not allow more requests than initial tokens")
 
-	time.Sleep(1 * time.Second)
+	clock.Advance(1 * time.Second)
 	assert.True(t, tb.Allow(), "Should allow one request after 1 second")
 
-	time.Sleep(1 * time.Second)
+	clock.Advance(1 * time.Second)
 	assert.True(t, tb.Allow(), "Should allow another request after another second")
 
-	time.Sleep(10 * time.Second)
+	clock.Advance(10 * time.Second)
 	for i := 0; i < 10; i++ {
 		assert.True(t, tb.Allow(), "Should allow up to max tokens requests")
 	}
@@ -31,7 +32,8 @@ func TestTokenBucket_Allow(t *testing.T) {
 }
 
 func TestTokenBucket_Refill(t *testing.T) {
-	tb := NewTokenBucket(2, 5)
+	clock := newFakeClock()
+	tb := NewTokenBucket(2, 5, WithClock(clock))
 
 	for i := 0; i < 5; i++ {
 		assert.True(t, tb.Allow(), "Initial tokens should allow requests")
@@ -39,7 +41,7 @@ func TestTokenBucket_Refill(t *testing.T) {
 
 	assert.False(t, tb.Allow(), "Should not allow more requests than initial tokens")
 
-	time.Sleep(2500 * time.Millisecond)
+	clock.Advance(2500 * time.Millisecond)
 	for i := 0; i < 5; i++ {
 		assert.True(t, tb.Allow(), "Should allow up to max tokens requests after refill")
 	}
@@ -69,3 +71,55 @@ func TestTokenBucket_ConcurrentAccess(t *testing.T) {
 
 	assert.Equal(t, 10, allowed, "Concurrent access should allow up to max tokens requests")
 }
+
+func TestTokenBucket_Take(t *testing.T) {
+	clock := newFakeClock()
+	tb := NewTokenBucket(2, 3, WithClock(clock))
+	assert.Equal(t, Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: 500 * time.Millisecond}, tb.Take())
+
+	tb.Take()
+	tb.Take()
+	assert.Equal(t, Result{
+		Limit:      3,
+		ResetAfter: 1500 * time.Millisecond,
+		RetryAfter: 500 * time.Millisecond,
+	}, tb.Take())
+}
+
+func TestTokenBucket_FractionalRefill(t *testing.T) {
+	clock := newFakeClock()
+	tb := NewTokenBucket(2, 1, WithClock(clock))
+
+	assert.True(t, tb.Allow())
+	for i := 0; i < 3; i++ {
+		clock.Advance(300 * time.Millisecond)
+		assert.False(t, tb.Allow(), "The partial token should not allow a request")
+		clock.Advance(200 * time.Millisecond)
+		assert.True(t, tb.Allow(), "The time elapsed before the last whole token should not be lost")
+	}
+}
+
+func TestTokenBucket_Reserve(t *testing.T) {
+	clock := newFakeClock()
+	tb := NewTokenBucket(2, 2, WithClock(clock))
+
+	assert.Equal(t, Reservation{OK: true}, tb.ReserveN(2))
+	assert.Equal(t, Reservation{OK: true, Delay: 500 * time.Millisecond}, tb.Reserve())
+	assert.Equal(t, Reservation{OK: true, Delay: time.Second}, tb.Reserve())
+	assert.False(t, tb.ReserveN(3).OK, "Should not reserve more tokens than the bucket holds")
+
+	clock.Advance(time.Second)
+	assert.False(t, tb.Allow(), "The reserved tokens should not be available")
+	clock.Advance(500 * time.Millisecond)
+	assert.True(t, tb.Allow())
+}
+
+func TestTokenBucket_ZeroRate(t *testing.T) {
+	clock := newFakeClock()
+	tb := NewTokenBucket(0, 1, WithClock(clock))
+
+	assert.True(t, tb.Allow())
+	clock.Advance(time.Hour)
+	assert.False(t, tb.Allow(), "The bucket should never be refilled")
+	assert.False(t, tb.Reserve().OK)
+}
@@ -1,26 +1,214 @@
 package middleware
 
-import "net/http"
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Defaults of the adaptive limit.
+const (
+	defaultTolerance = 2
+	defaultBackoff   = 0.9
+	// baselineDrift is the number of requests it takes the lowest latency to follow a lasting change of the
+	// latency, so the limit doesn't stay low forever once the service gets slower without load.
+	baselineDrift = 100
+)
+
+var (
+	// errQueueFull is returned when there is no room in the queue of the requests waiting for a slot.
+	errQueueFull = errors.New("queue is full")
+	// errQueueTimeout is returned when the request waits for a slot for longer than the max wait.
+	errQueueTimeout = errors.New("queue wait timed out")
+)
+
+// ConcurrencyLimiterConfig describes how many requests are executed in parallel and how long the rest wait.
+type ConcurrencyLimiterConfig struct {
+	// Limit is the number of requests executed in parallel, it is the initial limit of the adaptive limiter.
+	Limit int
+	// QueueSize is the number of requests waiting for a slot, the requests that don't fit are rejected at once.
+	// Zero means no limit.
+	QueueSize int
+	// MaxWait is the time a request waits for a slot before it is rejected. Zero means no timeout.
+	MaxWait time.Duration
+	// RetryAfter is sent in the Retry-After header of the rejected requests, it is at least one second.
+	RetryAfter time.Duration
+	// Adaptive tunes the limit from the latency of the requests if it is set.
+	Adaptive *AdaptiveConfig
+}
+
+// AdaptiveConfig describes how the limit is tuned. The limit grows by one for every limit of requests that are
+// as fast as the service without load (additive increase) and is multiplied by Backoff once the latency grows
+// above Tolerance times the lowest latency (multiplicative decrease), as the requests start queueing somewhere.
+type AdaptiveConfig struct {
+	MinLimit int
+	MaxLimit int
+	// Tolerance is how many times the latency may exceed the lowest one, 2 by default.
+	Tolerance float64
+	// Backoff is the factor the limit is multiplied by when the latency is too high, 0.9 by default.
+	Backoff float64
+}
 
 // ConcurrencyLimiter is a middleware that limits the number of concurrent requests.
 type ConcurrencyLimiter struct {
-	semaphore chan struct{}
+	cfg ConcurrencyLimiterConfig
+	mu  sync.Mutex
+	// limit is fractional, so the additive increase can grow it by a part of a request.
+	limit    float64
+	inFlight int
+	// waiters are the channels of the queued requests, they are closed in order when the slots are free.
+	waiters *list.List
+	// minLatency is the latency of the service without load used by the adaptive limit.
+	minLatency time.Duration
+	// decreasedAt is the time the limit was decreased, the requests started before it don't decrease it again.
+	decreasedAt time.Time
 }
 
-// NewConcurrencyLimiter creates a new instance of ConcurrencyLimiter.
+// NewConcurrencyLimiter creates a new instance of ConcurrencyLimiter. The requests wait for a slot until they
+// are canceled.
 func NewConcurrencyLimiter(limit int) *ConcurrencyLimiter {
+	return NewConcurrencyLimiterWithConfig(ConcurrencyLimiterConfig{Limit: limit})
+}
+
+// NewConcurrencyLimiterWithConfig creates a new instance of ConcurrencyLimiter with the bounded queue and the
+// adaptive limit.
+func NewConcurrencyLimiterWithConfig(cfg ConcurrencyLimiterConfig) *ConcurrencyLimiter {
+	if cfg.Adaptive != nil {
+		adaptive := *cfg.Adaptive
+		if adaptive.Tolerance <= 1 {
+			adaptive.Tolerance = defaultTolerance
+		}
+		if adaptive.Backoff <= 0 || adaptive.Backoff >= 1 {
+			adaptive.Backoff = defaultBackoff
+		}
+		adaptive.MinLimit = max(1, adaptive.MinLimit)
+		adaptive.MaxLimit = max(adaptive.MinLimit, adaptive.MaxLimit)
+		cfg.Limit = min(max(cfg.Limit, adaptive.MinLimit), adaptive.MaxLimit)
+		cfg.Adaptive = &adaptive
+	}
+
 	return &ConcurrencyLimiter{
-		semaphore: make(chan struct{}, limit),
+		cfg:     cfg,
+		limit:   float64(max(1, cfg.Limit)),
+		waiters: list.New(),
 	}
 }
 
-// Limit limits the number of concurrent requests.
+// Limit limits the number of concurrent requests. The requests that don't get a slot in time are rejected
+// with 503 and Retry-After, the canceled ones stop waiting and get no response.
 func (cl *ConcurrencyLimiter) Limit(handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		cl.semaphore <- struct{}{}
-		defer func() {
-			<-cl.semaphore
-		}()
+		if err := cl.acquire(r.Context()); err != nil {
+			if r.Context().Err() != nil {
+				return
+			}
+
+			w.Header().Set("Retry-After", strconv.FormatInt(max(1, seconds(cl.cfg.RetryAfter)), 10))
+			http.Error(w, "Service is overloaded", http.StatusServiceUnavailable)
+			return
+		}
+
+		started := time.Now()
+		defer cl.release(started)
 		handler.ServeHTTP(w, r)
 	}
 }
+
+// acquire takes a slot, waiting in the queue until one is free, the max wait elapses or the context is done.
+func (cl *ConcurrencyLimiter) acquire(ctx context.Context) error {
+	cl.mu.Lock()
+	if cl.waiters.Len() == 0 && cl.inFlight < int(cl.limit) {
+		cl.inFlight++
+		cl.mu.Unlock()
+		return nil
+	}
+
+	if cl.cfg.QueueSize > 0 && cl.waiters.Len() >= cl.cfg.QueueSize {
+		cl.mu.Unlock()
+		return errQueueFull
+	}
+
+	ready := make(chan struct{})
+	element := cl.waiters.PushBack(ready)
+	cl.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if cl.cfg.MaxWait > 0 {
+		timer := time.NewTimer(cl.cfg.MaxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var err error
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = errQueueTimeout
+	}
+
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	select {
+	case <-ready:
+		// The slot was given to the request while it stopped waiting, it is passed on.
+		cl.inFlight--
+		cl.dispatch()
+	default:
+		cl.waiters.Remove(element)
+	}
+
+	return err
+}
+
+// release frees the slot of the request started at the time and adapts the limit to its latency.
+func (cl *ConcurrencyLimiter) release(started time.Time) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	cl.inFlight--
+	if cl.cfg.Adaptive != nil {
+		cl.adapt(started, time.Since(started))
+	}
+	cl.dispatch()
+}
+
+// adapt increases the limit if the latency is close to the lowest one while the slots are in use and decreases
+// it once per the requests started under the current limit if the latency is too high.
+func (cl *ConcurrencyLimiter) adapt(started time.Time, latency time.Duration) {
+	adaptive := cl.cfg.Adaptive
+	if cl.minLatency == 0 || latency < cl.minLatency {
+		cl.minLatency = latency
+	} else {
+		cl.minLatency += (latency - cl.minLatency) / baselineDrift
+	}
+
+	switch {
+	case float64(latency) > float64(cl.minLatency)*adaptive.Tolerance:
+		if started.Before(cl.decreasedAt) {
+			return
+		}
+
+		cl.limit = max(float64(adaptive.MinLimit), cl.limit*adaptive.Backoff)
+		cl.decreasedAt = time.Now()
+	case float64(cl.inFlight+1) > cl.limit/2:
+		// The limit is increased only when more than half of it is in use, so it doesn't grow while there is
+		// no load.
+		cl.limit = min(float64(adaptive.MaxLimit), cl.limit+1/cl.limit)
+	}
+}
+
+// dispatch gives the free slots to the queued requests in order.
+func (cl *ConcurrencyLimiter) dispatch() {
+	for cl.inFlight < int(cl.limit) && cl.waiters.Len() > 0 {
+		close(cl.waiters.Remove(cl.waiters.Front()).(chan struct{}))
+		cl.inFlight++
+	}
+}
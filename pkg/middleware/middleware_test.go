@@ -1,9 +1,13 @@
 package middleware
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
+	"min/pkg/clientip"
+	"min/pkg/limiter"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -114,6 +118,139 @@ func TestRateLimiterMultipleIPs(t *testing.T) {
 	assert.Contains(t, rr3.Body.String(), "Too many requests")
 }
 
+func TestRateLimiterBehindProxy(t *testing.T) {
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	resolver, err := clientip.NewResolver([]string{"10.0.0.0/8"})
+	assert.NoError(t, err)
+	limitedHandler := Chain(handler, resolver.Handle, NewRateLimiter(1, 1).Limit)
+
+	request := func(forwardedFor string) int {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.2:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+
+		rr := httptest.NewRecorder()
+		limitedHandler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("203.0.113.1"))
+	assert.Equal(t, http.StatusOK, request("203.0.113.2"), "clients behind the proxy have their own limits")
+	assert.Equal(t, http.StatusTooManyRequests, request("203.0.113.1"))
+}
+
+func TestRateLimiterHeaders(t *testing.T) {
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	limitedHandler := NewRateLimiter(1, 2).Limit(handler)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "127.0.0.1:1234"
+
+	rr := httptest.NewRecorder()
+	limitedHandler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rr.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", rr.Header().Get("RateLimit-Reset"))
+	assert.Empty(t, rr.Header().Get("Retry-After"))
+
+	limitedHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+	rr = httptest.NewRecorder()
+	limitedHandler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", rr.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+}
+
+func TestRateLimiterPolicy(t *testing.T) {
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	// The clients and their limits are taken from the headers to emulate the authenticated users.
+	policy := func(r *http.Request) (string, Limit) {
+		burst, _ := strconv.ParseInt(r.Header.Get("X-Burst"), 10, 64)
+		return r.Header.Get("X-User"), Limit{Rate: 1, Burst: burst}
+	}
+	limitedHandler := NewRateLimiterWithBuckets(policy, MemoryBuckets, RateLimiterConfig{}).Limit(handler)
+
+	request := func(user, burst string) int {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-User", user)
+		req.Header.Set("X-Burst", burst)
+
+		rr := httptest.NewRecorder()
+		limitedHandler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("free", "1"))
+	assert.Equal(t, http.StatusTooManyRequests, request("free", "1"))
+
+	assert.Equal(t, http.StatusOK, request("premium", "2"))
+	assert.Equal(t, http.StatusOK, request("premium", "2"))
+	assert.Equal(t, http.StatusTooManyRequests, request("premium", "2"))
+
+	assert.Equal(t, http.StatusOK, request("free", "3"), "upgraded user should get a bucket with the new limit")
+}
+
+// allowAll is a bucket that allows every request.
+type allowAll struct{}
+
+func (allowAll) Take() limiter.Result { return limiter.Result{Allowed: true} }
+
+func TestRateLimiterEviction(t *testing.T) {
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	request := func(limiter *RateLimiter, ip string) {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		limiter.Limit(handler).ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	t.Run("least recently used bucket is evicted", func(t *testing.T) {
+		var created []string
+		limiter := NewRateLimiterWithBuckets(IPPolicy(Limit{Rate: 1, Burst: 1}), func(key string, _ Limit) TokenBucket {
+			created = append(created, key)
+			return allowAll{}
+		}, RateLimiterConfig{MaxClients: 2})
+
+		request(limiter, "127.0.0.1")
+		request(limiter, "127.0.0.2")
+		request(limiter, "127.0.0.1")
+		request(limiter, "127.0.0.3")
+		assert.Equal(t, 2, limiter.clients.Len())
+
+		request(limiter, "127.0.0.1")
+		request(limiter, "127.0.0.2")
+		assert.Equal(t, []string{"127.0.0.1", "127.0.0.2", "127.0.0.3", "127.0.0.2"}, created)
+	})
+
+	t.Run("idle buckets are evicted", func(t *testing.T) {
+		limiter := NewRateLimiterWithBuckets(IPPolicy(Limit{Rate: 1, Burst: 1}), func(string, Limit) TokenBucket {
+			return allowAll{}
+		}, RateLimiterConfig{IdleTimeout: 50 * time.Millisecond})
+
+		request(limiter, "127.0.0.1")
+		request(limiter, "127.0.0.2")
+		time.Sleep(100 * time.Millisecond)
+		request(limiter, "127.0.0.3")
+
+		assert.Equal(t, 1, limiter.clients.Len())
+		assert.Contains(t, limiter.index, "127.0.0.3/1/1")
+	})
+}
+
 func TestConcurrencyLimiter(t *testing.T) {
 	handler := func(w http.ResponseWriter, _ *http.Request) {
 		time.Sleep(100 * time.Millisecond)
@@ -155,3 +292,136 @@ func TestConcurrencyLimiter(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rr2.Code)
 	assert.Equal(t, "OK", rr2.Body.String())
 }
+
+func TestConcurrencyLimiterQueue(t *testing.T) {
+	release := make(chan struct{})
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}
+
+	limiter := NewConcurrencyLimiterWithConfig(ConcurrencyLimiterConfig{
+		Limit:      1,
+		QueueSize:  1,
+		MaxWait:    100 * time.Millisecond,
+		RetryAfter: 3 * time.Second,
+	})
+	limitedHandler := limiter.Limit(handler)
+
+	request := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		rr := httptest.NewRecorder()
+		limitedHandler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	running := make(chan *httptest.ResponseRecorder)
+	go func() {
+		running <- request()
+	}()
+	assert.Eventually(t, func() bool { return inFlight(limiter) == 1 }, time.Second, time.Millisecond)
+
+	queued := make(chan *httptest.ResponseRecorder)
+	go func() {
+		queued <- request()
+	}()
+	assert.Eventually(t, func() bool { return queueLen(limiter) == 1 }, time.Second, time.Millisecond)
+
+	rr := request()
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code, "the request should be rejected when the queue is full")
+	assert.Equal(t, "3", rr.Header().Get("Retry-After"))
+
+	rr = <-queued
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code, "the request should be rejected after the max wait")
+	assert.Equal(t, 0, queueLen(limiter))
+
+	go func() {
+		queued <- request()
+	}()
+	assert.Eventually(t, func() bool { return queueLen(limiter) == 1 }, time.Second, time.Millisecond)
+
+	close(release)
+	assert.Equal(t, http.StatusOK, (<-running).Code)
+	assert.Equal(t, http.StatusOK, (<-queued).Code, "the queued request should get the freed slot")
+	assert.Equal(t, 0, inFlight(limiter))
+}
+
+func TestConcurrencyLimiterCanceled(t *testing.T) {
+	release := make(chan struct{})
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}
+
+	limiter := NewConcurrencyLimiter(1)
+	limitedHandler := limiter.Limit(handler)
+
+	done := make(chan struct{})
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		limitedHandler.ServeHTTP(httptest.NewRecorder(), req)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return inFlight(limiter) == 1 }, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan *httptest.ResponseRecorder)
+	go func() {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+		rr := httptest.NewRecorder()
+		limitedHandler.ServeHTTP(rr, req)
+		canceled <- rr
+	}()
+	assert.Eventually(t, func() bool { return queueLen(limiter) == 1 }, time.Second, time.Millisecond)
+
+	cancel()
+	rr := <-canceled
+	assert.Empty(t, rr.Body.String(), "the canceled request should stop waiting without a response")
+	assert.Equal(t, 0, queueLen(limiter))
+
+	close(release)
+	<-done
+	assert.Equal(t, 0, inFlight(limiter))
+}
+
+func TestConcurrencyLimiterAdaptive(t *testing.T) {
+	limiter := NewConcurrencyLimiterWithConfig(ConcurrencyLimiterConfig{
+		Limit:    2,
+		Adaptive: &AdaptiveConfig{MinLimit: 1, MaxLimit: 3},
+	})
+
+	limiter.adapt(time.Now(), 10*time.Millisecond)
+	assert.Equal(t, 2.0, limiter.limit, "the limit should not grow while it is not in use")
+
+	// Another request keeps the slots in use.
+	limiter.inFlight = 1
+	for i := 0; i < 10; i++ {
+		limiter.adapt(time.Now(), 10*time.Millisecond)
+	}
+	assert.Equal(t, 3.0, limiter.limit, "the limit should grow up to the max while the latency is low")
+
+	limiter.adapt(time.Now(), 100*time.Millisecond)
+	assert.InDelta(t, 2.7, limiter.limit, 0.001, "the limit should back off when the latency is high")
+
+	limiter.adapt(time.Now().Add(-time.Second), 100*time.Millisecond)
+	assert.InDelta(t, 2.7, limiter.limit, 0.001, "the requests started before the backoff should not back off again")
+
+	for i := 0; i < 20; i++ {
+		limiter.adapt(time.Now(), 100*time.Millisecond)
+	}
+	assert.Equal(t, 1.0, limiter.limit, "the limit should not go below the min")
+}
+
+func inFlight(limiter *ConcurrencyLimiter) int {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	return limiter.inFlight
+}
+
+func queueLen(limiter *ConcurrencyLimiter) int {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	return limiter.waiters.Len()
+}
@@ -1,52 +1,183 @@
 package middleware
 
 import (
+	"container/list"
+	"fmt"
+	"min/pkg/clientip"
 	"min/pkg/limiter"
-	"net"
 	"net/http"
+	"strconv"
 	"sync"
+	"time"
 )
 
+// defaultMaxClients is the number of clients whose buckets are kept by NewRateLimiter.
+const defaultMaxClients = 100000
+
 type TokenBucket interface {
-	Allow() bool
+	Take() limiter.Result
+}
+
+// Limit is the rate limit of a client.
+type Limit struct {
+	// Rate is the number of requests per second.
+	Rate int64
+	// Burst is the maximal number of requests at once.
+	Burst int64
 }
 
-// RateLimiter is a middleware that limits the number of requests per IP address.
+// RateLimitPolicy returns the key identifying the client that made the request and the limit of the client.
+type RateLimitPolicy func(r *http.Request) (key string, limit Limit)
+
+// BucketFactory creates the token bucket of the client identified by the key.
+type BucketFactory func(key string, limit Limit) TokenBucket
+
+// RateLimiterConfig describes how many buckets of the clients are kept in memory.
+type RateLimiterConfig struct {
+	// MaxClients is the number of buckets kept, the least recently used bucket is evicted to make room for
+	// a new one even if it is not full yet, so its client starts over with a full bucket. This bounds the memory
+	// at the cost of letting a client make more requests than the limit once more than MaxClients other clients
+	// make requests in between. Zero means no limit.
+	MaxClients int
+	// IdleTimeout evicts the buckets of the clients that made no requests for it. Zero means no timeout.
+	IdleTimeout time.Duration
+}
+
+// client is the bucket of a client in the LRU list of the limiter.
+type client struct {
+	key      string
+	bucket   TokenBucket
+	lastSeen time.Time
+}
+
+// RateLimiter is a middleware that limits the number of requests per client.
 type RateLimiter struct {
-	rate      int64
-	maxTokens int64
-	clients   map[string]TokenBucket
-	mu        sync.Mutex
+	policy    RateLimitPolicy
+	newBucket BucketFactory
+	cfg       RateLimiterConfig
+	// clients are ordered from the most to the least recently used one.
+	clients *list.List
+	index   map[string]*list.Element
+	mu      sync.Mutex
 }
 
-// NewRateLimiter creates a new RateLimiter instance.
+// NewRateLimiter creates a new RateLimiter instance limiting the requests per IP address with in-memory token
+// buckets. A bucket is evicted once it is idle for long enough to be full again, so this eviction doesn't let
+// anyone make more requests. The buckets are evicted earlier only if there are more active clients than the
+// buckets it keeps, see RateLimiterConfig.MaxClients.
 func NewRateLimiter(rate, maxTokens int64) *RateLimiter {
+	limit := Limit{Rate: rate, Burst: maxTokens}
+	return NewRateLimiterWithBuckets(
+		IPPolicy(limit),
+		MemoryBuckets,
+		RateLimiterConfig{MaxClients: defaultMaxClients, IdleTimeout: limit.RefillTime()},
+	)
+}
+
+// NewRateLimiterWithBuckets creates a new RateLimiter instance limiting the clients identified by the policy
+// with the buckets created by newBucket, such as limiter.RedisTokenBucket shared by the replicas of the service.
+func NewRateLimiterWithBuckets(
+	policy RateLimitPolicy,
+	newBucket BucketFactory,
+	cfg RateLimiterConfig,
+) *RateLimiter {
 	return &RateLimiter{
-		rate:      rate,
-		maxTokens: maxTokens,
-		clients:   make(map[string]TokenBucket),
+		policy:    policy,
+		newBucket: newBucket,
+		cfg:       cfg,
+		clients:   list.New(),
+		index:     make(map[string]*list.Element),
 	}
 }
 
-// Limit limits the number of requests per IP address.
+// IPPolicy limits every IP address with the same limit. The address resolved by clientip.Resolver is used if it
+// is in front of the limiter.
+func IPPolicy(limit Limit) RateLimitPolicy {
+	return func(r *http.Request) (string, Limit) {
+		return clientip.FromRequest(r), limit
+	}
+}
+
+// MemoryBuckets creates the in-memory token buckets.
+func MemoryBuckets(_ string, limit Limit) TokenBucket {
+	return limiter.NewTokenBucket(limit.Rate, limit.Burst)
+}
+
+// RefillTime returns the time it takes to refill the empty bucket with the limit.
+func (l Limit) RefillTime() time.Duration {
+	if l.Rate <= 0 {
+		return 0
+	}
+
+	return time.Duration((l.Burst + l.Rate - 1) / l.Rate * int64(time.Second))
+}
+
+// Limit limits the number of requests per client. The responses get the RateLimit-Limit, RateLimit-Remaining
+// and RateLimit-Reset headers, and the rejected ones get Retry-After as well.
 func (rl *RateLimiter) Limit(handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ip, _, err := net.SplitHostPort(r.RemoteAddr)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		rl.mu.Lock()
-		if _, found := rl.clients[ip]; !found {
-			rl.clients[ip] = limiter.NewTokenBucket(rl.rate, rl.maxTokens)
-		}
-		l := rl.clients[ip]
-		rl.mu.Unlock()
+		result := rl.bucket(rl.policy(r)).Take()
+
+		w.Header().Set("RateLimit-Limit", strconv.FormatInt(result.Limit, 10))
+		w.Header().Set("RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+		w.Header().Set("RateLimit-Reset", strconv.FormatInt(seconds(result.ResetAfter), 10))
 
-		if l.Allow() {
+		if result.Allowed {
 			handler.ServeHTTP(w, r)
 		} else {
+			w.Header().Set("Retry-After", strconv.FormatInt(max(1, seconds(result.RetryAfter)), 10))
 			http.Error(w, "Too many requests", http.StatusTooManyRequests)
 		}
 	}
 }
+
+// bucket returns the bucket of the client, creating it if there is none, and evicts the buckets that are idle
+// or exceed the limit. The clients whose limit changes get a new bucket.
+func (rl *RateLimiter) bucket(key string, limit Limit) TokenBucket {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	rl.evict(now)
+
+	indexKey := fmt.Sprintf("%s/%d/%d", key, limit.Rate, limit.Burst)
+	if element, found := rl.index[indexKey]; found {
+		c := element.Value.(*client)
+		c.lastSeen = now
+		rl.clients.MoveToFront(element)
+		return c.bucket
+	}
+
+	c := &client{key: indexKey, bucket: rl.newBucket(key, limit), lastSeen: now}
+	rl.index[indexKey] = rl.clients.PushFront(c)
+	if rl.cfg.MaxClients > 0 && rl.clients.Len() > rl.cfg.MaxClients {
+		rl.remove(rl.clients.Back())
+	}
+
+	return c.bucket
+}
+
+// evict removes the buckets that are idle for longer than the idle timeout.
+func (rl *RateLimiter) evict(now time.Time) {
+	if rl.cfg.IdleTimeout <= 0 {
+		return
+	}
+
+	for element := rl.clients.Back(); element != nil; element = rl.clients.Back() {
+		if now.Sub(element.Value.(*client).lastSeen) <= rl.cfg.IdleTimeout {
+			return
+		}
+		rl.remove(element)
+	}
+}
+
+// remove removes the bucket of the client.
+func (rl *RateLimiter) remove(element *list.Element) {
+	rl.clients.Remove(element)
+	delete(rl.index, element.Value.(*client).key)
+}
+
+// seconds rounds the duration up to whole seconds.
+func seconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}